@@ -1,11 +1,22 @@
 package volman
 
-import "code.cloudfoundry.org/lager/v3"
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager/v3"
+)
 
 //go:generate counterfeiter -o volmanfakes/fake_manager_client.go . Manager
 
+// Manager mounts volumes through the registered drivers. Cancelling ctx, or letting its
+// deadline pass, abandons calls to drivers that are still in flight.
 type Manager interface {
-	ListDrivers(logger lager.Logger) (ListDriversResponse, error)
-	Mount(logger lager.Logger, driverId string, volumeId string, containerId string, config map[string]interface{}) (MountResponse, error)
-	Unmount(logger lager.Logger, driverId string, volumeId string, containerId string) error
+	// ListDrivers lists the registered drivers in name order.
+	ListDrivers(ctx context.Context, logger lager.Logger) (ListDriversResponse, error)
+	ListDriversMatching(ctx context.Context, logger lager.Logger, filter DriverFilter) (ListDriversResponse, error)
+	Mount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string, config map[string]interface{}) (MountResponse, error)
+	Unmount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) error
+	// ListMounts lists the live mounts matching the given ids, where an empty id matches any.
+	ListMounts(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) (ListMountsResponse, error)
+	GetMount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) (MountInfo, error)
 }
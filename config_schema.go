@@ -0,0 +1,170 @@
+package volman
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigSchema describes the mount options a driver accepts. It is the subset of JSON
+// schema that is useful for flat option maps: types, enums, required keys, nested
+// objects and whether unknown keys are allowed. Drivers publish it as
+// <driver>.schema.json next to their spec file.
+type ConfigSchema struct {
+	Type                 string                   `json:"type,omitempty"`
+	Properties           map[string]*ConfigSchema `json:"properties,omitempty"`
+	Required             []string                 `json:"required,omitempty"`
+	AdditionalProperties *bool                    `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}            `json:"enum,omitempty"`
+	Items                *ConfigSchema            `json:"items,omitempty"`
+}
+
+// ConfigViolation is a mount option that does not satisfy the driver's schema. Nested
+// options are named by their dotted path.
+type ConfigViolation struct {
+	Key    string
+	Reason string
+}
+
+type ConfigValidationError struct {
+	Violations []ConfigViolation
+}
+
+func (e ConfigValidationError) Error() string {
+	var violations []string
+	for _, violation := range e.Violations {
+		violations = append(violations, violation.Key+": "+violation.Reason)
+	}
+	return "invalid mount config: " + strings.Join(violations, "; ")
+}
+
+// Keys returns the offending option names.
+func (e ConfigValidationError) Keys() []string {
+	var keys []string
+	for _, violation := range e.Violations {
+		keys = append(keys, violation.Key)
+	}
+	return keys
+}
+
+// Validate checks config against the schema, returning a ConfigValidationError that
+// lists every offending option, or nil if there are none.
+func (s *ConfigSchema) Validate(config map[string]interface{}) error {
+	violations := s.validateObject("", config)
+	if len(violations) == 0 {
+		return nil
+	}
+	return ConfigValidationError{Violations: violations}
+}
+
+func (s *ConfigSchema) validate(key string, value interface{}) []ConfigViolation {
+	if s.Type != "" && !hasType(value, s.Type) {
+		return []ConfigViolation{{Key: key, Reason: fmt.Sprintf("expected %s", s.Type)}}
+	}
+
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		return []ConfigViolation{{Key: key, Reason: fmt.Sprintf("must be one of %v", s.Enum)}}
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return s.validateObject(key+".", typed)
+	case []interface{}:
+		if s.Items == nil {
+			return nil
+		}
+		var violations []ConfigViolation
+		for i, item := range typed {
+			violations = append(violations, s.Items.validate(fmt.Sprintf("%s[%d]", key, i), item)...)
+		}
+		return violations
+	}
+	return nil
+}
+
+func (s *ConfigSchema) validateObject(prefix string, object map[string]interface{}) []ConfigViolation {
+	var violations []ConfigViolation
+
+	for _, required := range s.Required {
+		if _, ok := object[required]; !ok {
+			violations = append(violations, ConfigViolation{Key: prefix + required, Reason: "is required"})
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, known := s.Properties[key]
+		if !known {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				violations = append(violations, ConfigViolation{Key: prefix + key, Reason: "unknown option"})
+			}
+			continue
+		}
+		violations = append(violations, property.validate(prefix+key, object[key])...)
+	}
+	return violations
+}
+
+func hasType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "number":
+		_, ok := number(value)
+		return ok
+	case "integer":
+		n, ok := number(value)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if allowedNumber, ok := number(allowed); ok {
+			if n, ok := number(value); ok && n == allowedNumber {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package volman_test
+
+import (
+	"encoding/json"
+
+	"code.cloudfoundry.org/volman"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigSchema", func() {
+	var schema *volman.ConfigSchema
+
+	BeforeEach(func() {
+		schema = &volman.ConfigSchema{}
+		Expect(json.Unmarshal([]byte(`{
+			"type": "object",
+			"required": ["source"],
+			"additionalProperties": false,
+			"properties": {
+				"source": {"type": "string"},
+				"uid": {"type": "integer"},
+				"readonly": {"type": "boolean"},
+				"version": {"enum": ["3.0", "4.1"]},
+				"mount": {
+					"type": "object",
+					"properties": {"timeo": {"type": "number"}}
+				},
+				"hosts": {"type": "array", "items": {"type": "string"}}
+			}
+		}`), schema)).To(Succeed())
+	})
+
+	It("accepts configs that satisfy the schema", func() {
+		config := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(`{"source": "nfs://server/share", "uid": 1000, "readonly": true, "version": "4.1", "mount": {"timeo": 600}, "hosts": ["a", "b"]}`), &config)).To(Succeed())
+
+		Expect(schema.Validate(config)).To(Succeed())
+	})
+
+	It("lists every offending option", func() {
+		err := schema.Validate(map[string]interface{}{
+			"uid":     "1000",
+			"udi":     1000,
+			"version": "4.2",
+			"mount":   map[string]interface{}{"timeo": "long"},
+			"hosts":   []interface{}{"a", 2},
+		})
+
+		var validationErr volman.ConfigValidationError
+		Expect(err).To(BeAssignableToTypeOf(validationErr))
+		validationErr = err.(volman.ConfigValidationError)
+		Expect(validationErr.Keys()).To(Equal([]string{"source", "hosts[1]", "mount.timeo", "udi", "uid", "version"}))
+		Expect(validationErr.Violations[3]).To(Equal(volman.ConfigViolation{Key: "udi", Reason: "unknown option"}))
+		Expect(err.Error()).To(ContainSubstring("uid: expected integer"))
+	})
+
+	It("rejects fractional integers", func() {
+		err := schema.Validate(map[string]interface{}{"source": "share", "uid": 10.5})
+		Expect(err).To(MatchError("invalid mount config: uid: expected integer"))
+	})
+
+	It("allows unknown options unless the schema forbids them", func() {
+		schema.AdditionalProperties = nil
+		Expect(schema.Validate(map[string]interface{}{"source": "share", "anything": "goes"})).To(Succeed())
+	})
+})
@@ -0,0 +1,24 @@
+package volman
+
+import "strings"
+
+// DiscoveryFailure is a source of drivers, such as a driver path, that could not be
+// discovered.
+type DiscoveryFailure struct {
+	Source string
+	Err    error
+}
+
+// DiscoveryError is returned by a discoverer that discovered some of its sources but not
+// others. The plugins it returns alongside are those of the sources that did not fail.
+type DiscoveryError struct {
+	Failures []DiscoveryFailure
+}
+
+func (e DiscoveryError) Error() string {
+	var failures []string
+	for _, failure := range e.Failures {
+		failures = append(failures, failure.Source+": "+failure.Err.Error())
+	}
+	return "failed to discover drivers in " + strings.Join(failures, "; ")
+}
@@ -0,0 +1,159 @@
+package volman
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// LegacyPlugin is the Plugin interface from before its methods took a context.
+type LegacyPlugin interface {
+	ListVolumes(logger lager.Logger) ([]string, error)
+	Mount(logger lager.Logger, volumeId string, config map[string]interface{}) (MountResponse, error)
+	Unmount(logger lager.Logger, volumeId string) error
+	Matches(lager.Logger, PluginSpec) bool
+	GetPluginSpec() PluginSpec
+}
+
+// LegacyDiscoverer is the Discoverer interface from before it took a context.
+type LegacyDiscoverer interface {
+	Discover(logger lager.Logger) (map[string]LegacyPlugin, error)
+}
+
+// LegacyManager is the Manager interface from before its methods took a context.
+type LegacyManager interface {
+	ListDrivers(logger lager.Logger) (ListDriversResponse, error)
+	Mount(logger lager.Logger, driverId string, volumeId string, containerId string, config map[string]interface{}) (MountResponse, error)
+	Unmount(logger lager.Logger, driverId string, volumeId string, containerId string) error
+}
+
+// ErrMountsNotListed is returned by managers adapted from a LegacyManager, which has no
+// way of listing its mounts, when they are asked for them.
+var ErrMountsNotListed = errors.New("listing mounts is not supported by legacy managers")
+
+// NewPluginFromLegacy adapts a plugin that does not take a context. A legacy plugin
+// cannot be interrupted once called, so the context is only checked before each call.
+func NewPluginFromLegacy(plugin LegacyPlugin) Plugin {
+	return &legacyPlugin{plugin: plugin}
+}
+
+type legacyPlugin struct {
+	plugin LegacyPlugin
+}
+
+func (p *legacyPlugin) ListVolumes(ctx context.Context, logger lager.Logger) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.plugin.ListVolumes(logger)
+}
+
+func (p *legacyPlugin) Mount(ctx context.Context, logger lager.Logger, volumeId string, config map[string]interface{}) (MountResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return MountResponse{}, err
+	}
+	return p.plugin.Mount(logger, volumeId, config)
+}
+
+func (p *legacyPlugin) Unmount(ctx context.Context, logger lager.Logger, volumeId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.plugin.Unmount(logger, volumeId)
+}
+
+func (p *legacyPlugin) Matches(logger lager.Logger, pluginSpec PluginSpec) bool {
+	return p.plugin.Matches(logger, pluginSpec)
+}
+
+func (p *legacyPlugin) GetPluginSpec() PluginSpec {
+	return p.plugin.GetPluginSpec()
+}
+
+// NewDiscovererFromLegacy adapts a discoverer that does not take a context, adapting the
+// plugins it discovers as well.
+func NewDiscovererFromLegacy(discoverer LegacyDiscoverer) Discoverer {
+	return &legacyDiscoverer{discoverer: discoverer}
+}
+
+type legacyDiscoverer struct {
+	discoverer LegacyDiscoverer
+}
+
+func (d *legacyDiscoverer) Discover(ctx context.Context, logger lager.Logger) (map[string]Plugin, error) {
+	if err := ctx.Err(); err != nil {
+		return map[string]Plugin{}, err
+	}
+
+	legacyPlugins, err := d.discoverer.Discover(logger)
+	plugins := map[string]Plugin{}
+	for name, plugin := range legacyPlugins {
+		plugins[name] = NewPluginFromLegacy(plugin)
+	}
+	return plugins, err
+}
+
+// NewManagerFromLegacy adapts a manager that does not take a context. As with plugins,
+// the context is only checked before each call. Drivers are filtered from the full list
+// of drivers, and mounts cannot be listed, failing with ErrMountsNotListed.
+func NewManagerFromLegacy(manager LegacyManager) Manager {
+	return &legacyManager{manager: manager}
+}
+
+type legacyManager struct {
+	manager LegacyManager
+}
+
+func (m *legacyManager) ListDrivers(ctx context.Context, logger lager.Logger) (ListDriversResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return ListDriversResponse{}, err
+	}
+	return m.manager.ListDrivers(logger)
+}
+
+func (m *legacyManager) ListDriversMatching(ctx context.Context, logger lager.Logger, filter DriverFilter) (ListDriversResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return ListDriversResponse{}, err
+	}
+	response, err := m.manager.ListDrivers(logger)
+	if err != nil {
+		return ListDriversResponse{}, err
+	}
+
+	var drivers []InfoResponse
+	for _, driver := range response.Drivers {
+		if filter.Matches(driver.Name, PluginSpec{Name: driver.Name, Address: driver.Address}) {
+			drivers = append(drivers, driver)
+		}
+	}
+	return ListDriversResponse{Drivers: drivers}, nil
+}
+
+func (m *legacyManager) Mount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string, config map[string]interface{}) (MountResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return MountResponse{}, err
+	}
+	return m.manager.Mount(logger, driverId, volumeId, containerId, config)
+}
+
+func (m *legacyManager) Unmount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.manager.Unmount(logger, driverId, volumeId, containerId)
+}
+
+func (m *legacyManager) ListMounts(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) (ListMountsResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return ListMountsResponse{}, err
+	}
+	return ListMountsResponse{}, ErrMountsNotListed
+}
+
+func (m *legacyManager) GetMount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) (MountInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return MountInfo{}, err
+	}
+	return MountInfo{}, ErrMountsNotListed
+}
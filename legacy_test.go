@@ -0,0 +1,154 @@
+package volman_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type legacyPlugin struct {
+	mounted []string
+}
+
+func (p *legacyPlugin) ListVolumes(logger lager.Logger) ([]string, error) {
+	return p.mounted, nil
+}
+
+func (p *legacyPlugin) Mount(logger lager.Logger, volumeId string, config map[string]interface{}) (volman.MountResponse, error) {
+	p.mounted = append(p.mounted, volumeId)
+	return volman.MountResponse{Path: "/var/vcap/data/" + volumeId}, nil
+}
+
+func (p *legacyPlugin) Unmount(logger lager.Logger, volumeId string) error {
+	p.mounted = nil
+	return nil
+}
+
+func (p *legacyPlugin) Matches(logger lager.Logger, spec volman.PluginSpec) bool {
+	return spec.Name == "legacy"
+}
+
+func (p *legacyPlugin) GetPluginSpec() volman.PluginSpec {
+	return volman.PluginSpec{Name: "legacy"}
+}
+
+type legacyDiscoverer struct {
+	plugin volman.LegacyPlugin
+}
+
+func (d *legacyDiscoverer) Discover(logger lager.Logger) (map[string]volman.LegacyPlugin, error) {
+	return map[string]volman.LegacyPlugin{"legacy": d.plugin}, nil
+}
+
+type legacyManager struct {
+	plugin *legacyPlugin
+}
+
+func (m *legacyManager) ListDrivers(logger lager.Logger) (volman.ListDriversResponse, error) {
+	return volman.ListDriversResponse{Drivers: []volman.InfoResponse{
+		{Name: "legacy", Address: "/var/vcap/data/voldrivers/legacy.sock"},
+		{Name: "other", Address: "http://0.0.0.0:8080"},
+	}}, nil
+}
+
+func (m *legacyManager) Mount(logger lager.Logger, driverId string, volumeId string, containerId string, config map[string]interface{}) (volman.MountResponse, error) {
+	return m.plugin.Mount(logger, volumeId, config)
+}
+
+func (m *legacyManager) Unmount(logger lager.Logger, driverId string, volumeId string, containerId string) error {
+	return m.plugin.Unmount(logger, volumeId)
+}
+
+var _ = Describe("Legacy adapters", func() {
+	var (
+		logger *lagertest.TestLogger
+		legacy *legacyPlugin
+		plugin volman.Plugin
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("legacy")
+		legacy = &legacyPlugin{}
+		plugin = volman.NewPluginFromLegacy(legacy)
+	})
+
+	Describe("NewPluginFromLegacy", func() {
+		It("delegates to the legacy plugin", func() {
+			response, err := plugin.Mount(context.Background(), logger, "some-volume", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Path).To(Equal("/var/vcap/data/some-volume"))
+
+			volumes, err := plugin.ListVolumes(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(volumes).To(ConsistOf("some-volume"))
+
+			Expect(plugin.Unmount(context.Background(), logger, "some-volume")).To(Succeed())
+			Expect(plugin.Matches(logger, volman.PluginSpec{Name: "legacy"})).To(BeTrue())
+			Expect(plugin.GetPluginSpec().Name).To(Equal("legacy"))
+		})
+
+		It("does not call the legacy plugin once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := plugin.Mount(ctx, logger, "some-volume", nil)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(legacy.mounted).To(BeEmpty())
+		})
+	})
+
+	Describe("NewDiscovererFromLegacy", func() {
+		It("adapts the discovered plugins", func() {
+			discoverer := volman.NewDiscovererFromLegacy(&legacyDiscoverer{plugin: legacy})
+
+			plugins, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plugins).To(HaveKey("legacy"))
+
+			_, err = plugins["legacy"].Mount(context.Background(), logger, "some-volume", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(legacy.mounted).To(ConsistOf("some-volume"))
+		})
+	})
+
+	Describe("NewManagerFromLegacy", func() {
+		var manager volman.Manager
+
+		BeforeEach(func() {
+			manager = volman.NewManagerFromLegacy(&legacyManager{plugin: legacy})
+		})
+
+		It("delegates to the legacy manager", func() {
+			response, err := manager.Mount(context.Background(), logger, "legacy", "some-volume", "some-container", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Path).To(Equal("/var/vcap/data/some-volume"))
+
+			Expect(manager.Unmount(context.Background(), logger, "legacy", "some-volume", "some-container")).To(Succeed())
+			Expect(legacy.mounted).To(BeEmpty())
+		})
+
+		It("filters the drivers the legacy manager lists", func() {
+			drivers, err := manager.ListDriversMatching(context.Background(), logger, volman.DriverFilter{Transport: volman.TransportUnix})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drivers.Drivers).To(HaveLen(1))
+			Expect(drivers.Drivers[0].Name).To(Equal("legacy"))
+
+			drivers, err = manager.ListDriversMatching(context.Background(), logger, volman.DriverFilter{NamePrefix: "oth"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drivers.Drivers).To(HaveLen(1))
+			Expect(drivers.Drivers[0].Name).To(Equal("other"))
+		})
+
+		It("does not support listing mounts", func() {
+			_, err := manager.ListMounts(context.Background(), logger, "", "", "")
+			Expect(err).To(Equal(volman.ErrMountsNotListed))
+
+			_, err = manager.GetMount(context.Background(), logger, "legacy", "some-volume", "some-container")
+			Expect(err).To(Equal(volman.ErrMountsNotListed))
+		})
+	})
+})
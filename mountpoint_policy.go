@@ -0,0 +1,119 @@
+package volman
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type MountpointPolicyMode string
+
+const (
+	// MountpointPolicyEnforce fails mounts whose mountpoint violates the policy. It is
+	// also what an unset mode means.
+	MountpointPolicyEnforce MountpointPolicyMode = "enforce"
+	// MountpointPolicyWarn only logs violations and returns the mountpoint anyway.
+	MountpointPolicyWarn MountpointPolicyMode = "warn"
+)
+
+// MountpointPolicy restricts where drivers may mount volumes, since whatever path a driver
+// returns is bind-mounted into the container.
+type MountpointPolicy struct {
+	// AllowedPrefixes are the directories mountpoints must be in. Leaving it empty allows
+	// any absolute path.
+	AllowedPrefixes []string
+
+	// ResolveSymlinks checks where a mountpoint really is, rather than where its path
+	// says it is, so that a symlink under an allowed prefix cannot point out of it.
+	ResolveSymlinks bool
+
+	// AllowTraversal allows mountpoints with ".." elements, which are rejected otherwise.
+	AllowTraversal bool
+
+	Mode MountpointPolicyMode
+}
+
+// DefaultMountpointPolicy only allows mountpoints that resolve to a path under
+// /var/vcap/data.
+func DefaultMountpointPolicy() MountpointPolicy {
+	return MountpointPolicy{
+		AllowedPrefixes: []string{"/var/vcap/data"},
+		ResolveSymlinks: true,
+		Mode:            MountpointPolicyEnforce,
+	}
+}
+
+// WarnOnlyMountpointPolicy is the default policy in warn mode, which is how volman
+// treated mountpoints before the policy could be enforced.
+func WarnOnlyMountpointPolicy() MountpointPolicy {
+	policy := DefaultMountpointPolicy()
+	policy.Mode = MountpointPolicyWarn
+	return policy
+}
+
+// MountpointPolicyError is returned for a mountpoint that violates the policy.
+type MountpointPolicyError struct {
+	Mountpoint string
+	Reason     string
+}
+
+func (e MountpointPolicyError) Error() string {
+	return fmt.Sprintf("mountpoint '%s' %s", e.Mountpoint, e.Reason)
+}
+
+// Check returns a MountpointPolicyError if mountpoint violates the policy, regardless of
+// its mode, or nil if it does not.
+func (p MountpointPolicy) Check(mountpoint string) error {
+	if !filepath.IsAbs(mountpoint) {
+		return MountpointPolicyError{Mountpoint: mountpoint, Reason: "is not an absolute path"}
+	}
+
+	if !p.AllowTraversal {
+		for _, element := range strings.Split(filepath.ToSlash(mountpoint), "/") {
+			if element == ".." {
+				return MountpointPolicyError{Mountpoint: mountpoint, Reason: "contains a '..' traversal"}
+			}
+		}
+	}
+
+	if len(p.AllowedPrefixes) == 0 {
+		return nil
+	}
+
+	path := filepath.Clean(mountpoint)
+	if p.ResolveSymlinks {
+		path = resolveSymlinks(path)
+	}
+
+	for _, prefix := range p.AllowedPrefixes {
+		prefix = filepath.Clean(prefix)
+		if p.ResolveSymlinks {
+			prefix = resolveSymlinks(prefix)
+		}
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, string(filepath.Separator))+string(filepath.Separator)) {
+			return nil
+		}
+	}
+
+	if path != filepath.Clean(mountpoint) {
+		return MountpointPolicyError{Mountpoint: mountpoint, Reason: fmt.Sprintf("resolves to '%s', outside of %v", path, p.AllowedPrefixes)}
+	}
+	return MountpointPolicyError{Mountpoint: mountpoint, Reason: fmt.Sprintf("is outside of %v", p.AllowedPrefixes)}
+}
+
+// resolveSymlinks resolves the longest part of path that exists, since drivers may return
+// mountpoints that are not there yet.
+func resolveSymlinks(path string) string {
+	existing, rest := path, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(existing); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
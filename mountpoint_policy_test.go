@@ -0,0 +1,88 @@
+package volman_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/volman"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MountpointPolicy", func() {
+	var (
+		allowedDir string
+		policy     volman.MountpointPolicy
+	)
+
+	BeforeEach(func() {
+		var err error
+		allowedDir, err = os.MkdirTemp("", "mountpoint-policy")
+		Expect(err).NotTo(HaveOccurred())
+		allowedDir, err = filepath.EvalSymlinks(allowedDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		policy = volman.MountpointPolicy{AllowedPrefixes: []string{allowedDir}, ResolveSymlinks: true}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(allowedDir)).To(Succeed())
+	})
+
+	It("allows mountpoints under an allowed prefix, whether or not they exist yet", func() {
+		Expect(policy.Check(filepath.Join(allowedDir, "mounts", "some-volume"))).To(Succeed())
+		Expect(policy.Check(allowedDir)).To(Succeed())
+	})
+
+	It("rejects mountpoints outside of the allowed prefixes", func() {
+		Expect(policy.Check("/var/tmp")).To(MatchError(volman.MountpointPolicyError{
+			Mountpoint: "/var/tmp",
+			Reason:     "is outside of [" + allowedDir + "]",
+		}))
+	})
+
+	It("does not mistake a sibling directory sharing the prefix for it", func() {
+		Expect(policy.Check(allowedDir + "-other/some-volume")).To(HaveOccurred())
+	})
+
+	It("rejects relative mountpoints", func() {
+		Expect(policy.Check("mounts/some-volume")).To(MatchError(ContainSubstring("is not an absolute path")))
+	})
+
+	It("rejects '..' traversal unless it is allowed", func() {
+		mountpoint := filepath.Join(allowedDir, "mounts") + "/../some-volume"
+		Expect(policy.Check(mountpoint)).To(MatchError(ContainSubstring("traversal")))
+
+		policy.AllowTraversal = true
+		Expect(policy.Check(mountpoint)).To(Succeed())
+	})
+
+	Context("when a mountpoint is a symlink out of the allowed prefixes", func() {
+		var mountpoint string
+
+		BeforeEach(func() {
+			mountpoint = filepath.Join(allowedDir, "escape")
+			Expect(os.Symlink("/etc", mountpoint)).To(Succeed())
+		})
+
+		It("rejects it once resolved", func() {
+			Expect(policy.Check(mountpoint)).To(MatchError(ContainSubstring("resolves to '/etc'")))
+			Expect(policy.Check(filepath.Join(mountpoint, "some-volume"))).To(HaveOccurred())
+		})
+
+		It("allows it when symlinks are not resolved", func() {
+			policy.ResolveSymlinks = false
+			Expect(policy.Check(mountpoint)).To(Succeed())
+		})
+	})
+
+	It("allows any absolute mountpoint without allowed prefixes", func() {
+		Expect(volman.MountpointPolicy{}.Check("/anywhere")).To(Succeed())
+	})
+
+	It("defaults to enforcing /var/vcap/data", func() {
+		Expect(volman.DefaultMountpointPolicy().Check("/var/vcap/data/mounts/some-volume")).To(Succeed())
+		Expect(volman.DefaultMountpointPolicy().Check("/var/vcap/database")).To(HaveOccurred())
+		Expect(volman.DefaultMountpointPolicy().Mode).To(Equal(volman.MountpointPolicyEnforce))
+	})
+})
@@ -0,0 +1,198 @@
+package volman
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const redacted = "*REDACTED*"
+
+// DefaultSensitiveKeys are redacted from every log volman emits. Keys match when they
+// contain one of these, ignoring case, so "password" also covers "smb_password".
+var DefaultSensitiveKeys = []string{"password", "secret", "token", "key"}
+
+// inlinePatterns holds the compiled inline pattern of each set of sensitive keys, since
+// loggers are wrapped with the same keys for every request.
+var inlinePatterns sync.Map
+
+type redactingLogger struct {
+	lager.Logger
+	keys          []string
+	inlinePattern *regexp.Regexp
+}
+
+// NewRedactingLogger returns a logger that scrubs sensitive values from the data of every
+// message, including data nested in maps, slices and structs, and from key=value pairs
+// inside strings such as mount option lists. It redacts DefaultSensitiveKeys plus keys.
+// Wrapping a redacting logger again only adds keys.
+func NewRedactingLogger(logger lager.Logger, keys ...string) lager.Logger {
+	if existing, ok := logger.(*redactingLogger); ok {
+		if existing.redactsAll(keys) {
+			return existing
+		}
+		return newRedactingLogger(existing.Logger, append(append([]string{}, existing.keys...), keys...))
+	}
+	return newRedactingLogger(logger, append(append([]string{}, DefaultSensitiveKeys...), keys...))
+}
+
+func newRedactingLogger(logger lager.Logger, keys []string) *redactingLogger {
+	var lowered []string
+	seen := map[string]bool{}
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		if key != "" && !seen[key] {
+			seen[key] = true
+			lowered = append(lowered, key)
+		}
+	}
+
+	return &redactingLogger{Logger: logger, keys: lowered, inlinePattern: inlinePatternFor(lowered)}
+}
+
+// inlinePatternFor returns the pattern matching key=value pairs of the lowered keys,
+// compiling it only the first time it is asked for.
+func inlinePatternFor(keys []string) *regexp.Regexp {
+	cacheKey := strings.Join(keys, "\x00")
+	if pattern, ok := inlinePatterns.Load(cacheKey); ok {
+		return pattern.(*regexp.Regexp)
+	}
+
+	var quoted []string
+	for _, key := range keys {
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	pattern, _ := inlinePatterns.LoadOrStore(cacheKey, regexp.MustCompile(`(?i)([\w-]*(?:`+strings.Join(quoted, "|")+`)[\w-]*)=[^,;&\s]*`))
+	return pattern.(*regexp.Regexp)
+}
+
+// redactsAll reports whether the logger already redacts every one of keys.
+func (l *redactingLogger) redactsAll(keys []string) bool {
+	for _, key := range keys {
+		if key != "" && !containsKey(l.keys, strings.ToLower(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, candidate := range keys {
+		if candidate == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *redactingLogger) Session(task string, data ...lager.Data) lager.Logger {
+	return &redactingLogger{Logger: l.Logger.Session(task, l.redactAll(data)...), keys: l.keys, inlinePattern: l.inlinePattern}
+}
+
+func (l *redactingLogger) WithData(data lager.Data) lager.Logger {
+	return &redactingLogger{Logger: l.Logger.WithData(l.redactData(data)), keys: l.keys, inlinePattern: l.inlinePattern}
+}
+
+func (l *redactingLogger) Debug(action string, data ...lager.Data) {
+	l.Logger.Debug(action, l.redactAll(data)...)
+}
+
+func (l *redactingLogger) Info(action string, data ...lager.Data) {
+	l.Logger.Info(action, l.redactAll(data)...)
+}
+
+func (l *redactingLogger) Error(action string, err error, data ...lager.Data) {
+	l.Logger.Error(action, l.redactError(err), l.redactAll(data)...)
+}
+
+func (l *redactingLogger) Fatal(action string, err error, data ...lager.Data) {
+	l.Logger.Fatal(action, l.redactError(err), l.redactAll(data)...)
+}
+
+func (l *redactingLogger) redactAll(data []lager.Data) []lager.Data {
+	redactedData := make([]lager.Data, 0, len(data))
+	for _, d := range data {
+		redactedData = append(redactedData, l.redactData(d))
+	}
+	return redactedData
+}
+
+func (l *redactingLogger) redactData(data lager.Data) lager.Data {
+	if data == nil {
+		return nil
+	}
+	redactedData := lager.Data{}
+	for key, value := range data {
+		redactedData[key] = l.redactValue(key, value)
+	}
+	return redactedData
+}
+
+// redactError keeps errors that carry no secrets as they are, so that their type is not
+// lost to sinks that care.
+func (l *redactingLogger) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := l.inlinePattern.ReplaceAllString(err.Error(), "$1="+redacted)
+	if message == err.Error() {
+		return err
+	}
+	return errors.New(message)
+}
+
+func (l *redactingLogger) sensitive(key string) bool {
+	lowered := strings.ToLower(key)
+	for _, sensitiveKey := range l.keys {
+		if strings.Contains(lowered, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *redactingLogger) redactValue(key string, value interface{}) interface{} {
+	if l.sensitive(key) {
+		return redacted
+	}
+
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return l.inlinePattern.ReplaceAllString(typed, "$1="+redacted)
+	case error:
+		return l.redactError(typed).Error()
+	case lager.Data:
+		return l.redactData(typed)
+	case map[string]interface{}:
+		return map[string]interface{}(l.redactData(typed))
+	case []interface{}:
+		redactedValues := make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			redactedValues = append(redactedValues, l.redactValue("", item))
+		}
+		return redactedValues
+	}
+
+	// structs and other composite values are redacted through their JSON form, which is
+	// how lager would render them anyway
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			return value
+		}
+		return l.redactValue(key, decoded)
+	}
+	return value
+}
@@ -0,0 +1,86 @@
+package volman_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RedactingLogger", func() {
+	var (
+		testLogger *lagertest.TestLogger
+		logger     lager.Logger
+	)
+
+	BeforeEach(func() {
+		testLogger = lagertest.NewTestLogger("redaction")
+		logger = volman.NewRedactingLogger(testLogger)
+	})
+
+	lastData := func() lager.Data {
+		logs := testLogger.Logs()
+		Expect(logs).NotTo(BeEmpty())
+		return logs[len(logs)-1].Data
+	}
+
+	It("redacts sensitive keys, ignoring case and surrounding words", func() {
+		logger.Info("mount", lager.Data{"smb_Password": "hunter2", "apiToken": "abc", "username": "alice"})
+
+		Expect(lastData()).To(HaveKeyWithValue("smb_Password", "*REDACTED*"))
+		Expect(lastData()).To(HaveKeyWithValue("apiToken", "*REDACTED*"))
+		Expect(lastData()).To(HaveKeyWithValue("username", "alice"))
+	})
+
+	It("redacts secrets nested in maps, slices and structs", func() {
+		type mountRequest struct {
+			Name string
+			Opts map[string]interface{}
+		}
+		logger.Debug("mount", lager.Data{
+			"config":  map[string]interface{}{"source": "//server/share", "password": "hunter2"},
+			"request": mountRequest{Name: "some-volume", Opts: map[string]interface{}{"secret": "shh"}},
+			"list":    []interface{}{map[string]interface{}{"token": "abc"}},
+		})
+
+		Expect(testLogger.Buffer().Contents()).NotTo(ContainSubstring("hunter2"))
+		Expect(testLogger.Buffer().Contents()).NotTo(ContainSubstring("shh"))
+		Expect(testLogger.Buffer().Contents()).NotTo(ContainSubstring(`"abc"`))
+		Expect(testLogger.Buffer().Contents()).To(ContainSubstring("//server/share"))
+		Expect(testLogger.Buffer().Contents()).To(ContainSubstring("some-volume"))
+	})
+
+	It("redacts key=value pairs inside strings and errors", func() {
+		logger.Error("mount-failed", errors.New("mount -o username=alice,password=hunter2 failed"), lager.Data{"options": "ro,password=hunter2"})
+
+		Expect(lastData()).To(HaveKeyWithValue("options", "ro,password=*REDACTED*"))
+		Expect(lastData()).To(HaveKeyWithValue("error", "mount -o username=alice,password=*REDACTED* failed"))
+	})
+
+	It("redacts session and WithData data", func() {
+		logger.Session("mount", lager.Data{"password": "hunter2"}).WithData(lager.Data{"token": "abc"}).Info("start")
+
+		Expect(lastData()).To(HaveKeyWithValue("password", "*REDACTED*"))
+		Expect(lastData()).To(HaveKeyWithValue("token", "*REDACTED*"))
+	})
+
+	It("redacts additional configured keys", func() {
+		logger = volman.NewRedactingLogger(logger, "sec")
+		logger.Info("mount", lager.Data{"sec": "krb5", "password": "hunter2"})
+
+		Expect(lastData()).To(HaveKeyWithValue("sec", "*REDACTED*"))
+		Expect(lastData()).To(HaveKeyWithValue("password", "*REDACTED*"))
+	})
+
+	It("does not wrap a redacting logger twice", func() {
+		Expect(volman.NewRedactingLogger(logger)).To(BeIdenticalTo(logger))
+	})
+
+	It("does not wrap a redacting logger again for keys it already redacts", func() {
+		logger = volman.NewRedactingLogger(logger, "sec")
+		Expect(volman.NewRedactingLogger(logger, "SEC", "password")).To(BeIdenticalTo(logger))
+	})
+})
@@ -0,0 +1,52 @@
+package volman
+
+import "sync"
+
+type RegistryEventType string
+
+const (
+	RegistryEventAdded   RegistryEventType = "added"
+	RegistryEventRemoved RegistryEventType = "removed"
+	RegistryEventUpdated RegistryEventType = "updated"
+)
+
+// RegistryEvent is a change to one driver in a plugin registry. OldSpec is unset for
+// added drivers, and NewSpec for removed ones. Drivers are updated when they are replaced,
+// such as after their address changed.
+type RegistryEvent struct {
+	Type     RegistryEventType
+	DriverId string
+	OldSpec  PluginSpec
+	NewSpec  PluginSpec
+}
+
+// RegistryEventSource is implemented by plugin registries, and managers, that report
+// changes to their drivers.
+type RegistryEventSource interface {
+	// Subscribe calls handle with every change from now on, in order, until unsubscribe
+	// is called. Each subscription has its events delivered on a goroutine of its own, so
+	// a slow subscriber only holds up itself.
+	Subscribe(handle func(RegistryEvent)) (unsubscribe func())
+}
+
+// SubscribeChannel subscribes to source, delivering events on the returned channel
+// instead. The channel is not closed by unsubscribing, which releases any pending send.
+func SubscribeChannel(source RegistryEventSource) (<-chan RegistryEvent, func()) {
+	events := make(chan RegistryEvent)
+	done := make(chan struct{})
+
+	unsubscribe := source.Subscribe(func(event RegistryEvent) {
+		select {
+		case events <- event:
+		case <-done:
+		}
+	})
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			close(done)
+			unsubscribe()
+		})
+	}
+}
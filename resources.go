@@ -1,44 +1,119 @@
 package volman
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
 	"code.cloudfoundry.org/lager/v3"
 )
 
 //go:generate counterfeiter -o volmanfakes/fake_plugin.go . Plugin
 type Plugin interface {
-	ListVolumes(logger lager.Logger) ([]string, error)
-	Mount(logger lager.Logger, volumeId string, config map[string]interface{}) (MountResponse, error)
-	Unmount(logger lager.Logger, volumeId string) error
+	ListVolumes(ctx context.Context, logger lager.Logger) ([]string, error)
+	Mount(ctx context.Context, logger lager.Logger, volumeId string, config map[string]interface{}) (MountResponse, error)
+	Unmount(ctx context.Context, logger lager.Logger, volumeId string) error
 	Matches(lager.Logger, PluginSpec) bool
 	GetPluginSpec() PluginSpec
 }
 
 //go:generate counterfeiter -o volmanfakes/fake_discoverer.go . Discoverer
 type Discoverer interface {
-	Discover(logger lager.Logger) (map[string]Plugin, error)
+	Discover(ctx context.Context, logger lager.Logger) (map[string]Plugin, error)
+}
+
+//go:generate counterfeiter -o volmanfakes/fake_driver_discoverer.go . DriverDiscoverer
+
+// DriverDiscoverer is implemented by discoverers that can also discover a single driver
+// by name, so that a change to one driver does not need every driver rediscovered. It
+// reports false if the driver is no longer there, or can no longer be activated.
+type DriverDiscoverer interface {
+	Discoverer
+	DiscoverDriver(ctx context.Context, logger lager.Logger, driverId string) (Plugin, bool, error)
+}
+
+//go:generate counterfeiter -o volmanfakes/fake_sourced_discoverer.go . SourcedDiscoverer
+
+// SourcedDiscoverer is implemented by discoverers with several sources of drivers, such
+// as several driver paths, to tell which source a driver was last discovered from, so
+// that a failing source only keeps its own drivers registered. Sources are named the way
+// the DiscoveryFailures of the discoverer name them.
+type SourcedDiscoverer interface {
+	Discoverer
+	DriverSource(driverId string) (string, bool)
+}
+
+//go:generate counterfeiter -o volmanfakes/fake_driver_watcher.go . DriverWatcher
+
+// DriverWatcher reports the ids of drivers whose spec files appear, change or disappear,
+// until ctx ends, at which point it closes the channel.
+type DriverWatcher interface {
+	Watch(ctx context.Context, logger lager.Logger) <-chan string
 }
 
 type ListDriversResponse struct {
 	Drivers []InfoResponse `json:"drivers"`
 }
 
+// DriverFilter narrows ListDriversMatching to drivers whose name starts with NamePrefix
+// and that volman reaches over Transport. Empty fields match any driver.
+type DriverFilter struct {
+	NamePrefix string `json:"namePrefix,omitempty"`
+	Transport  string `json:"transport,omitempty"`
+}
+
+// Matches reports whether the named driver with the given spec passes the filter.
+func (f DriverFilter) Matches(name string, spec PluginSpec) bool {
+	if !strings.HasPrefix(name, f.NamePrefix) {
+		return false
+	}
+	return f.Transport == "" || f.Transport == spec.Transport()
+}
+
 type MountRequest struct {
-	DriverId string                 `json:"driverId"`
-	VolumeId string                 `json:"volumeId"`
-	Config   map[string]interface{} `json:"config"`
+	DriverId    string                 `json:"driverId"`
+	VolumeId    string                 `json:"volumeId"`
+	ContainerId string                 `json:"containerId"`
+	Config      map[string]interface{} `json:"config"`
 }
 
 type MountResponse struct {
 	Path string `json:"path"`
 }
 
+// MountInfo describes a volume volman currently has mounted for a container.
+type MountInfo struct {
+	DriverId    string    `json:"driverId"`
+	VolumeId    string    `json:"volumeId"`
+	ContainerId string    `json:"containerId"`
+	Path        string    `json:"path"`
+	MountedAt   time.Time `json:"mountedAt"`
+}
+
+type ListMountsResponse struct {
+	Mounts []MountInfo `json:"mounts"`
+}
+
+// ErrMountNotFound is returned by GetMount when the container does not have the volume mounted.
+var ErrMountNotFound = errors.New("mount not found")
+
+// InfoResponse describes a registered driver. Scope and LastActivation are only known
+// for plugins that implement HealthReporter.
 type InfoResponse struct {
-	Name string `json:"name"`
+	Name            string    `json:"name"`
+	Address         string    `json:"address,omitempty"`
+	Transport       string    `json:"transport,omitempty"`
+	UniqueVolumeIds bool      `json:"uniqueVolumeIds"`
+	Scope           string    `json:"scope,omitempty"`
+	LastActivation  time.Time `json:"lastActivation"`
+	Reachable       bool      `json:"reachable"`
 }
 
 type UnmountRequest struct {
-	DriverId string `json:"driverId"`
-	VolumeId string `json:"volumeId"`
+	DriverId    string `json:"driverId"`
+	VolumeId    string `json:"volumeId"`
+	ContainerId string `json:"containerId"`
 }
 
 type PluginSpec struct {
@@ -46,6 +121,44 @@ type PluginSpec struct {
 	Address         string     `json:"Addr"`
 	TLSConfig       *TLSConfig `json:"TLSConfig"`
 	UniqueVolumeIds bool
+	// Timeouts are the timeouts the driver declares in its .json spec, if any.
+	Timeouts *OperationTimeouts `json:"Timeouts,omitempty"`
+	// ConfigSchema describes the mount options the driver accepts, if it publishes one.
+	ConfigSchema *ConfigSchema `json:"ConfigSchema,omitempty"`
+}
+
+const (
+	TransportUnix  = "unix"
+	TransportHTTP  = "http"
+	TransportHTTPS = "https"
+)
+
+// Transport reports how volman reaches the driver: over a unix socket, http or https.
+func (p PluginSpec) Transport() string {
+	switch {
+	case p.Address == "":
+		return ""
+	case strings.HasPrefix(p.Address, "unix://"), strings.HasPrefix(p.Address, "/"):
+		return TransportUnix
+	case strings.HasPrefix(p.Address, "https://"), p.TLSConfig != nil:
+		return TransportHTTPS
+	default:
+		return TransportHTTP
+	}
+}
+
+// PluginHealth describes a plugin's driver as of the last time it was activated.
+type PluginHealth struct {
+	Scope          string
+	LastActivation time.Time
+	Reachable      bool
+}
+
+// HealthReporter is implemented by plugins that keep track of whether their driver is
+// reachable. Health should answer from what the plugin already knows, without calling
+// the driver, since it is asked about every driver each time drivers are listed.
+type HealthReporter interface {
+	Health(ctx context.Context, logger lager.Logger) PluginHealth
 }
 
 type TLSConfig struct {
@@ -62,6 +175,49 @@ type PluginRegistry interface {
 	Keys() []string
 }
 
+// DrainingRegistry is implemented by plugin registries that keep drivers that vanished,
+// or were marked for removal, for a while as draining. Draining drivers are no longer
+// returned by Plugin or Plugins, but can still be looked up to unmount the volumes that
+// were mounted through them.
+type DrainingRegistry interface {
+	// Draining returns a draining driver as it was last registered.
+	Draining(id string) (Plugin, bool)
+	// Drain marks a registered driver for removal, keeping it out of the registry even
+	// while discovery still finds it. It reports false if the driver is not registered.
+	Drain(id string) bool
+	// Drained forgets a draining driver, once nothing is left mounted through it.
+	Drained(id string)
+}
+
+// DriverNotFoundError is returned when a request names a driver that is not registered.
+type DriverNotFoundError struct {
+	DriverId string
+}
+
+func (e DriverNotFoundError) Error() string {
+	return "Plugin '" + e.DriverId + "' not found in list of known plugins"
+}
+
+// DriverUnavailableError is the SafeError returned while volman holds calls to a driver
+// back, such as while the driver's circuit breaker is open.
+type DriverUnavailableError struct {
+	SafeError
+}
+
+func (e DriverUnavailableError) Unwrap() error {
+	return e.SafeError
+}
+
+// DriverBusyError is the SafeError returned when a driver has too many mounts in progress
+// to take another.
+type DriverBusyError struct {
+	SafeError
+}
+
+func (e DriverBusyError) Unwrap() error {
+	return e.SafeError
+}
+
 type SafeError struct {
 	SafeDescription string `json:"SafeDescription"`
 }
@@ -0,0 +1,61 @@
+package volman
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	OperationMount    = "mount"
+	OperationUnmount  = "unmount"
+	OperationActivate = "activate"
+)
+
+// OperationTimeouts bounds calls to a driver. A zero duration leaves that call unbounded.
+type OperationTimeouts struct {
+	Mount    time.Duration
+	Unmount  time.Duration
+	Activate time.Duration
+}
+
+// Or returns the timeouts with every unset one taken from defaults.
+func (t OperationTimeouts) Or(defaults OperationTimeouts) OperationTimeouts {
+	if t.Mount == 0 {
+		t.Mount = defaults.Mount
+	}
+	if t.Unmount == 0 {
+		t.Unmount = defaults.Unmount
+	}
+	if t.Activate == 0 {
+		t.Activate = defaults.Activate
+	}
+	return t
+}
+
+// TimeoutConfig holds the default driver timeouts and per-driver overrides.
+type TimeoutConfig struct {
+	Defaults OperationTimeouts
+	Drivers  map[string]OperationTimeouts
+}
+
+// For resolves the timeouts of the named driver. Overrides configured in volman win over
+// the timeouts the driver declares in its spec, which win over the defaults.
+func (c TimeoutConfig) For(driverId string, spec PluginSpec) OperationTimeouts {
+	timeouts := c.Drivers[driverId]
+	if spec.Timeouts != nil {
+		timeouts = timeouts.Or(*spec.Timeouts)
+	}
+	return timeouts.Or(c.Defaults)
+}
+
+// TimeoutError is returned when a driver does not complete an operation within its
+// configured timeout.
+type TimeoutError struct {
+	DriverId  string
+	Operation string
+	Timeout   time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("driver '%s' did not complete %s within %s", e.DriverId, e.Operation, e.Timeout)
+}
@@ -0,0 +1,36 @@
+package volman_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/volman"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TimeoutConfig", func() {
+	var config volman.TimeoutConfig
+
+	BeforeEach(func() {
+		config = volman.TimeoutConfig{
+			Defaults: volman.OperationTimeouts{Mount: time.Minute, Unmount: time.Minute, Activate: time.Second},
+			Drivers: map[string]volman.OperationTimeouts{
+				"slow-driver": {Mount: time.Hour},
+			},
+		}
+	})
+
+	It("uses the defaults for drivers without overrides", func() {
+		Expect(config.For("some-driver", volman.PluginSpec{})).To(Equal(config.Defaults))
+	})
+
+	It("prefers the timeouts declared in the driver spec over the defaults", func() {
+		spec := volman.PluginSpec{Timeouts: &volman.OperationTimeouts{Unmount: 5 * time.Minute}}
+		Expect(config.For("some-driver", spec)).To(Equal(volman.OperationTimeouts{Mount: time.Minute, Unmount: 5 * time.Minute, Activate: time.Second}))
+	})
+
+	It("prefers the volman overrides over the driver spec", func() {
+		spec := volman.PluginSpec{Timeouts: &volman.OperationTimeouts{Mount: 5 * time.Minute, Activate: 2 * time.Second}}
+		Expect(config.For("slow-driver", spec)).To(Equal(volman.OperationTimeouts{Mount: time.Hour, Unmount: time.Minute, Activate: 2 * time.Second}))
+	})
+})
@@ -2,12 +2,18 @@ package voldiscoverers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"strings"
+	"sync"
+	"time"
 
+	loggingclient "code.cloudfoundry.org/diego-logging-client"
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
 	"code.cloudfoundry.org/lager/v3"
@@ -15,12 +21,33 @@ import (
 	"code.cloudfoundry.org/volman/voldocker"
 )
 
+const volmanActivateTimeoutsCounter = "VolmanActivateTimeouts"
+
+// specTypes are the kinds of driver spec files, in the order that they are looked for.
+var specTypes = [3]string{"json", "spec", "sock"}
+
 type dockerDriverDiscoverer struct {
 	logger        lager.Logger
 	driverFactory DockerDriverFactory
 
 	driverRegistry volman.PluginRegistry
 	driverPaths    []string
+
+	metronClient loggingclient.IngressClient
+	timeouts     volman.TimeoutConfig
+	gate         ActivationGate
+
+	mountpointPolicy volman.MountpointPolicy
+
+	// sources maps each driver to the driver path it was last discovered in
+	sourcesMutex sync.Mutex
+	sources      map[string]string
+}
+
+// ActivationGate lets discovery hold back activating drivers that are known to be down,
+// such as those whose circuit breaker is open.
+type ActivationGate interface {
+	AllowActivation(driverId string) bool
 }
 
 func NewDockerDriverDiscoverer(logger lager.Logger, driverRegistry volman.PluginRegistry, driverPaths []string) volman.Discoverer {
@@ -30,6 +57,8 @@ func NewDockerDriverDiscoverer(logger lager.Logger, driverRegistry volman.Plugin
 
 		driverRegistry: driverRegistry,
 		driverPaths:    driverPaths,
+
+		mountpointPolicy: volman.WarnOnlyMountpointPolicy(),
 	}
 }
 
@@ -40,25 +69,60 @@ func NewDockerDriverDiscovererWithDriverFactory(logger lager.Logger, driverRegis
 
 		driverRegistry: driverRegistry,
 		driverPaths:    driverPaths,
+
+		mountpointPolicy: volman.WarnOnlyMountpointPolicy(),
 	}
 }
 
-func (r *dockerDriverDiscoverer) Discover(logger lager.Logger) (map[string]volman.Plugin, error) {
-	logger = logger.Session("discover")
+// NewDockerDriverDiscovererWithTimeouts returns a discoverer that gives up on activating
+// a driver once its activate timeout passes, counting each such timeout.
+func NewDockerDriverDiscovererWithTimeouts(logger lager.Logger, driverRegistry volman.PluginRegistry, driverPaths []string, factory DockerDriverFactory, metronClient loggingclient.IngressClient, timeouts volman.TimeoutConfig) volman.Discoverer {
+	return NewDockerDriverDiscovererWithActivationGate(logger, driverRegistry, driverPaths, factory, metronClient, timeouts, nil)
+}
+
+// NewDockerDriverDiscovererWithActivationGate returns a discoverer that keeps already
+// registered drivers without re-activating them while gate holds them back.
+func NewDockerDriverDiscovererWithActivationGate(logger lager.Logger, driverRegistry volman.PluginRegistry, driverPaths []string, factory DockerDriverFactory, metronClient loggingclient.IngressClient, timeouts volman.TimeoutConfig, gate ActivationGate) volman.Discoverer {
+	return NewDockerDriverDiscovererWithMountpointPolicy(logger, driverRegistry, driverPaths, factory, metronClient, timeouts, gate, volman.WarnOnlyMountpointPolicy())
+}
+
+// NewDockerDriverDiscovererWithMountpointPolicy returns a discoverer whose drivers have
+// the mountpoints they return checked against policy.
+func NewDockerDriverDiscovererWithMountpointPolicy(logger lager.Logger, driverRegistry volman.PluginRegistry, driverPaths []string, factory DockerDriverFactory, metronClient loggingclient.IngressClient, timeouts volman.TimeoutConfig, gate ActivationGate, policy volman.MountpointPolicy) volman.Discoverer {
+	return &dockerDriverDiscoverer{
+		logger:        logger,
+		driverFactory: factory,
+
+		driverRegistry: driverRegistry,
+		driverPaths:    driverPaths,
+
+		metronClient: metronClient,
+		timeouts:     timeouts,
+		gate:         gate,
+
+		mountpointPolicy: policy,
+	}
+}
+
+func (r *dockerDriverDiscoverer) Discover(ctx context.Context, logger lager.Logger) (map[string]volman.Plugin, error) {
+	logger = volman.NewRedactingLogger(logger).Session("discover")
 	logger.Debug("start")
 	logger.Info("discovering-drivers", lager.Data{"driver-paths": r.driverPaths})
 	defer logger.Debug("end")
 
 	endpoints := make(map[string]volman.Plugin)
+	sources := map[string]string{}
+	var failures []volman.DiscoveryFailure
 
+	// a driver path that cannot be listed does not stop the others being discovered
 	for _, driverPath := range r.driverPaths {
-		specTypes := [3]string{"json", "spec", "sock"}
 		for _, specType := range specTypes {
 			matchingDriverSpecs, err := r.getMatchingDriverSpecs(logger, driverPath, specType)
 
 			if err != nil {
-				// untestable on linux, does glob work differently on windows???
-				return map[string]volman.Plugin{}, fmt.Errorf("Volman configured with an invalid driver path '%s', error occured list files (%s)", driverPath, err.Error())
+				logger.Error("failed-listing-driver-path", err, lager.Data{"driver-path": driverPath})
+				failures = append(failures, volman.DiscoveryFailure{Source: driverPath, Err: err})
+				break
 			}
 			if len(matchingDriverSpecs) > 0 {
 				logger.Debug("driver-specs", lager.Data{"drivers": matchingDriverSpecs})
@@ -69,13 +133,73 @@ func (r *dockerDriverDiscoverer) Discover(logger lager.Logger) (map[string]volma
 				}
 
 				endpoints = r.findAllPlugins(logger, endpoints, driverPath, matchingDriverSpecs, existing)
-				endpoints = r.activatePlugins(logger, endpoints, driverPath, matchingDriverSpecs)
+				endpoints = r.activatePlugins(ctx, logger, endpoints, driverPath, matchingDriverSpecs)
+			}
+		}
+
+		for name := range endpoints {
+			if _, found := sources[name]; !found {
+				sources[name] = driverPath
 			}
 		}
 	}
+
+	r.sourcesMutex.Lock()
+	r.sources = sources
+	r.sourcesMutex.Unlock()
+
+	if len(failures) > 0 {
+		return endpoints, volman.DiscoveryError{Failures: failures}
+	}
 	return endpoints, nil
 }
 
+// DriverSource returns the driver path that the driver was last discovered in.
+func (r *dockerDriverDiscoverer) DriverSource(driverId string) (string, bool) {
+	r.sourcesMutex.Lock()
+	defer r.sourcesMutex.Unlock()
+
+	source, found := r.sources[driverId]
+	return source, found
+}
+
+// DiscoverDriver discovers the named driver alone, looking for its spec in the same order
+// as Discover does and settling for the first one that activates.
+func (r *dockerDriverDiscoverer) DiscoverDriver(ctx context.Context, logger lager.Logger, driverId string) (volman.Plugin, bool, error) {
+	logger = volman.NewRedactingLogger(logger).Session("discover-driver", lager.Data{"driverId": driverId})
+	logger.Debug("start")
+	defer logger.Debug("end")
+
+	var existing map[string]volman.Plugin
+	if r.driverRegistry != nil {
+		existing = r.driverRegistry.Plugins()
+	}
+
+	for _, driverPath := range r.driverPaths {
+		for _, specType := range specTypes {
+			spec := filepath.Join(driverPath, driverId+"."+specType)
+			if _, err := os.Stat(spec); err != nil {
+				continue
+			}
+
+			plugins := r.findAllPlugins(logger, map[string]volman.Plugin{}, driverPath, []string{spec}, existing)
+			plugins = r.activatePlugins(ctx, logger, plugins, driverPath, []string{spec})
+			if plugin, found := plugins[driverId]; found {
+				r.sourcesMutex.Lock()
+				if r.sources == nil {
+					r.sources = map[string]string{}
+				}
+				r.sources[driverId] = driverPath
+				r.sourcesMutex.Unlock()
+				return plugin, true, nil
+			}
+		}
+	}
+
+	logger.Info("driver-not-found")
+	return nil, false, nil
+}
+
 func (r *dockerDriverDiscoverer) findAllPlugins(logger lager.Logger, newPlugins map[string]volman.Plugin, driverPath string, specs []string, existingPlugins map[string]volman.Plugin) map[string]volman.Plugin {
 	logger = logger.Session("insert-if-not-found")
 	logger.Debug("start")
@@ -111,17 +235,26 @@ func (r *dockerDriverDiscoverer) findAllPlugins(logger lager.Logger, newPlugins
 	return newPlugins
 }
 
-func (r *dockerDriverDiscoverer) activatePlugins(logger lager.Logger, plugins map[string]volman.Plugin, driverPath string, specs []string) map[string]volman.Plugin {
+func (r *dockerDriverDiscoverer) activatePlugins(ctx context.Context, logger lager.Logger, plugins map[string]volman.Plugin, driverPath string, specs []string) map[string]volman.Plugin {
 
 	activatedPlugins := map[string]volman.Plugin{}
 
 	for k, plugin := range plugins {
-		dockerPlugin := plugin.(*voldocker.DockerDriverPlugin)
+		dockerPlugin, ok := plugin.(*voldocker.DockerDriverPlugin)
+		if !ok {
+			logger.Error("driver-invalid", fmt.Errorf("plugin %s is not a docker driver plugin", k))
+			continue
+		}
+		if r.gate != nil && !r.gate.AllowActivation(k) {
+			logger.Info("skipping-activation", lager.Data{"spec-name": k})
+			activatedPlugins[k] = dockerPlugin
+			continue
+		}
 		dockerDriver := dockerPlugin.DockerDriver.(dockerdriver.Driver)
-		env := driverhttp.NewHttpDriverEnv(logger, context.Background())
-		resp := dockerDriver.Activate(env)
+		resp := r.activate(ctx, logger, k, dockerPlugin.GetPluginSpec(), dockerDriver)
 		if resp.Err == "" {
 			if implementVolumeDriver(resp) {
+				recordActivation(ctx, logger, r.timeouts, dockerPlugin)
 				activatedPlugins[k] = dockerPlugin
 			} else {
 				logger.Error("driver-invalid", fmt.Errorf("driver-implements: %#v, expecting: VolumeDriver", resp.Implements))
@@ -140,15 +273,16 @@ func (r *dockerDriverDiscoverer) activatePlugins(logger lager.Logger, plugins ma
 			if err != nil {
 				logger.Error("error-creating-driver", err)
 			}
-			env := driverhttp.NewHttpDriverEnv(logger, context.TODO())
-			resp := driver.Activate(env)
+			resp := r.activate(ctx, logger, k, plugin.GetPluginSpec(), driver)
 			if resp.Err == "" {
 				if implementVolumeDriver(resp) {
+					recordActivation(ctx, logger, r.timeouts, dockerPlugin)
 					activatedPlugins[k] = dockerPlugin
 				} else {
 					logger.Error("driver-invalid", fmt.Errorf("driver-implements: %#v, expecting: VolumeDriver", resp.Implements))
 				}
 			} else {
+				dockerPlugin.Unreachable()
 				logger.Info("updated-driver-unreachable", lager.Data{"spec-name": dockerPlugin.GetPluginSpec().Name, "address": dockerPlugin.GetPluginSpec().Address, "tls": dockerPlugin.GetPluginSpec().TLSConfig})
 			}
 		}
@@ -158,13 +292,69 @@ func (r *dockerDriverDiscoverer) activatePlugins(logger lager.Logger, plugins ma
 	return activatedPlugins
 }
 
+// recordActivation records a successful activation of plugin and, until the driver has
+// reported one, the scope of its capabilities, so that listing drivers does not have to
+// contact them.
+func recordActivation(ctx context.Context, logger lager.Logger, timeouts volman.TimeoutConfig, plugin *voldocker.DockerDriverPlugin) {
+	plugin.Activated(time.Now())
+	if plugin.Scope() != "" {
+		return
+	}
+
+	spec := plugin.GetPluginSpec()
+	if timeout := timeouts.For(spec.Name, spec).Activate; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	driver := plugin.DockerDriver.(dockerdriver.Driver)
+	plugin.SetScope(driver.Capabilities(driverhttp.NewHttpDriverEnv(logger, ctx)).Capabilities.Scope)
+}
+
+// activate activates the driver within its activate timeout, if it has one.
+func (r *dockerDriverDiscoverer) activate(ctx context.Context, logger lager.Logger, name string, spec volman.PluginSpec, driver dockerdriver.Driver) dockerdriver.ActivateResponse {
+	return activateDriver(ctx, logger, r.metronClient, r.timeouts, name, spec, driver)
+}
+
+func activateDriver(ctx context.Context, logger lager.Logger, metronClient loggingclient.IngressClient, timeouts volman.TimeoutConfig, name string, spec volman.PluginSpec, driver dockerdriver.Driver) dockerdriver.ActivateResponse {
+	timeout := timeouts.For(name, spec).Activate
+	if timeout <= 0 {
+		return driver.Activate(driverhttp.NewHttpDriverEnv(logger, ctx))
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp := driver.Activate(driverhttp.NewHttpDriverEnv(logger, timeoutCtx))
+	if resp.Err != "" && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		err := volman.TimeoutError{DriverId: name, Operation: volman.OperationActivate, Timeout: timeout}
+		logger.Error("activate-timed-out", err)
+		if metronClient != nil {
+			if metricErr := metronClient.IncrementCounter(volmanActivateTimeoutsCounter); metricErr != nil {
+				logger.Debug("failed-emitting-activate-timeout-metric", lager.Data{"error": metricErr})
+			}
+		}
+		resp.Err = err.Error()
+	}
+	return resp
+}
+
 func (r *dockerDriverDiscoverer) getMatchingDriverSpecs(logger lager.Logger, path string, pattern string) ([]string, error) {
 	logger.Debug("binaries", lager.Data{"path": path, "pattern": pattern})
 	matchingDriverSpecs, err := filepath.Glob(path + string(os.PathSeparator) + "*." + pattern)
 	if err != nil { // untestable on linux, does glob work differently on windows???
 		return nil, fmt.Errorf("Volman configured with an invalid driver path '%s', error occured list files (%s)", path, err.Error())
 	}
-	return matchingDriverSpecs, nil
+
+	// config schemas sit next to the specs but are not specs themselves
+	specs := matchingDriverSpecs[:0]
+	for _, spec := range matchingDriverSpecs {
+		if !strings.HasSuffix(spec, configSchemaSuffix) {
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
 
 }
 
@@ -172,11 +362,27 @@ func (r *dockerDriverDiscoverer) pluginDoesNotMatch(logger lager.Logger, plugin
 	if plugin == nil {
 		return true
 	}
+	if _, ok := plugin.(*voldocker.DockerDriverPlugin); !ok {
+		logger.Info("existing-plugin-not-a-docker-driver", lager.Data{"specName": pluginSpec.Name})
+		return true
+	}
 	doesNotMatch := !plugin.Matches(logger, pluginSpec)
 	if doesNotMatch {
 		logger.Info("existing-plugin-mismatch", lager.Data{"specName": plugin.GetPluginSpec().Name, "existing-address": plugin.GetPluginSpec().Address, "new-adddress": pluginSpec.Address})
+		return true
+	}
+	return specChanged(logger, plugin, pluginSpec)
+}
+
+// specChanged reports whether the spec of a driver changed since its plugin was created,
+// in ways that Matches does not look at, such as its timeouts or config schema. The
+// plugin has to be created again for the new spec to take effect.
+func specChanged(logger lager.Logger, plugin volman.Plugin, pluginSpec volman.PluginSpec) bool {
+	if reflect.DeepEqual(plugin.GetPluginSpec(), pluginSpec) {
+		return false
 	}
-	return doesNotMatch
+	logger.Info("existing-plugin-spec-changed", lager.Data{"specName": pluginSpec.Name})
+	return true
 }
 
 func (r *dockerDriverDiscoverer) getPluginSpec(logger lager.Logger, specName string, driverPath string, specFile string) (volman.PluginSpec, error) {
@@ -187,9 +393,87 @@ func (r *dockerDriverDiscoverer) getPluginSpec(logger lager.Logger, specName str
 	}
 
 	pluginSpec := mapDriverSpecToPluginSpec(driverSpec)
+	if filepath.Ext(specFile) == ".json" {
+		pluginSpec.Timeouts = readSpecTimeouts(logger, filepath.Join(driverPath, specFile))
+	}
+	pluginSpec.ConfigSchema = readConfigSchema(logger, filepath.Join(driverPath, specName+configSchemaSuffix))
 	return pluginSpec, err
 }
 
+const configSchemaSuffix = ".schema.json"
+
+// readConfigSchema reads the schema a driver publishes for its mount options. Drivers
+// without one, or with one that cannot be parsed, have their configs passed unchecked.
+func readConfigSchema(logger lager.Logger, schemaPath string) *volman.ConfigSchema {
+	contents, err := os.ReadFile(schemaPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("error-reading-config-schema", err, lager.Data{"schema": schemaPath})
+		}
+		return nil
+	}
+
+	var schema volman.ConfigSchema
+	if err := json.Unmarshal(contents, &schema); err != nil {
+		logger.Error("invalid-config-schema", err, lager.Data{"schema": schemaPath})
+		return nil
+	}
+	return &schema
+}
+
+// specTimeouts is the optional Timeouts section of a .json driver spec, holding
+// durations such as "30s" keyed by operation.
+type specTimeouts struct {
+	Timeouts *struct {
+		Mount    string `json:"Mount"`
+		Unmount  string `json:"Unmount"`
+		Activate string `json:"Activate"`
+	} `json:"Timeouts"`
+}
+
+func readSpecTimeouts(logger lager.Logger, specPath string) *volman.OperationTimeouts {
+	contents, err := os.ReadFile(specPath)
+	if err != nil {
+		logger.Error("error-reading-driver-spec-timeouts", err)
+		return nil
+	}
+
+	var spec specTimeouts
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		return nil
+	}
+	return parseSpecTimeouts(logger, specPath, spec)
+}
+
+// parseSpecTimeouts turns the timeouts of a spec into durations, skipping those that are
+// not valid durations. source names the spec in the logs.
+func parseSpecTimeouts(logger lager.Logger, source string, spec specTimeouts) *volman.OperationTimeouts {
+	if spec.Timeouts == nil {
+		return nil
+	}
+
+	timeouts := &volman.OperationTimeouts{}
+	for _, field := range []struct {
+		value    string
+		duration *time.Duration
+	}{
+		{spec.Timeouts.Mount, &timeouts.Mount},
+		{spec.Timeouts.Unmount, &timeouts.Unmount},
+		{spec.Timeouts.Activate, &timeouts.Activate},
+	} {
+		if field.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(field.value)
+		if err != nil {
+			logger.Error("invalid-driver-spec-timeout", err, lager.Data{"spec": source})
+			continue
+		}
+		*field.duration = duration
+	}
+	return timeouts
+}
+
 func (r *dockerDriverDiscoverer) findDockerSpecFileByName(logger lager.Logger, nameToFind string, driverPath string, specs []string) (bool, string) {
 	for _, spec := range specs {
 		found, specName, specFile := specName(logger, spec)
@@ -209,7 +493,7 @@ func (r *dockerDriverDiscoverer) createPlugin(logger lager.Logger, specName stri
 		return nil, err
 	}
 
-	return voldocker.NewVolmanPluginWithDockerDriver(driver, pluginSpec), nil
+	return voldocker.NewVolmanPluginWithMountpointPolicy(driver, pluginSpec, r.metronClient, r.mountpointPolicy), nil
 }
 
 func specName(logger lager.Logger, spec string) (bool, string, string) {
@@ -1,12 +1,19 @@
 package voldiscoverers_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	mfakes "code.cloudfoundry.org/diego-logging-client/testhelpers"
 	"code.cloudfoundry.org/lager/v3/lagertest"
+	"github.com/onsi/gomega/gbytes"
 
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/dockerdriverfakes"
@@ -51,7 +58,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 	Describe("#Discover", func() {
 		Context("when given driverspath with no drivers", func() {
 			It("no drivers are found", func() {
-				drivers, err := discoverer.Discover(logger)
+				drivers, err := discoverer.Discover(context.Background(), logger)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(drivers)).To(Equal(0))
 			})
@@ -74,7 +81,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 				err := dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, driverSpecExtension, driverSpecContents)
 				Expect(err).NotTo(HaveOccurred())
 
-				drivers, err = discoverer.Discover(logger)
+				drivers, err = discoverer.Discover(context.Background(), logger)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -115,7 +122,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 					Expect(len(drivers)).To(Equal(1))
 					Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(1))
 
-					drivers, err = discoverer.Discover(logger)
+					drivers, err = discoverer.Discover(context.Background(), logger)
 				})
 
 				It("should not replace the driver in the registry", func() {
@@ -143,7 +150,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 					Expect(len(drivers)).To(Equal(1))
 					Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(1))
 
-					drivers, err = discoverer.Discover(logger)
+					drivers, err = discoverer.Discover(context.Background(), logger)
 					registry.Set(drivers)
 				})
 
@@ -155,6 +162,24 @@ var _ = Describe("Docker Driver Discoverer", func() {
 				})
 			})
 
+			Context("when another kind of plugin is registered under the driver's name", func() {
+				var otherPlugin *volmanfakes.FakePlugin
+
+				BeforeEach(func() {
+					otherPlugin = new(volmanfakes.FakePlugin)
+					otherPlugin.MatchesReturns(true)
+					otherPlugin.GetPluginSpecReturns(volman.PluginSpec{Name: driverName, Address: "http://0.0.0.0:8080"})
+					registry.Set(map[string]volman.Plugin{driverName: otherPlugin})
+				})
+
+				It("creates a plugin for the driver in its place", func() {
+					Expect(len(drivers)).To(Equal(1))
+					Expect(drivers[driverName]).NotTo(BeIdenticalTo(otherPlugin))
+					Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(1))
+					Expect(fakeDriver.ActivateCallCount()).To(Equal(1))
+				})
+			})
+
 			Context("when the driver opts in to unique volume IDs", func() {
 				BeforeEach(func() {
 					driverSpecContents = []byte("{\"Addr\":\"http://0.0.0.0:8080\",\"UniqueVolumeIds\": true}")
@@ -185,7 +210,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 					Expect(err).NotTo(HaveOccurred())
 				}
 
-				drivers, err = discoverer.Discover(logger)
+				drivers, err = discoverer.Discover(context.Background(), logger)
 
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(drivers)).To(Equal(expectedNumberOfDrivers))
@@ -220,7 +245,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 				})
 
 				It("should find drivers", func() {
-					drivers, err := discoverer.Discover(logger)
+					drivers, err := discoverer.Discover(context.Background(), logger)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(len(drivers)).To(Equal(1))
 					Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(1))
@@ -228,6 +253,35 @@ var _ = Describe("Docker Driver Discoverer", func() {
 
 			})
 
+			Context("when one of the driver paths cannot be listed", func() {
+				BeforeEach(func() {
+					discoverer = voldiscoverers.NewDockerDriverDiscovererWithDriverFactory(logger, registry, []string{"/bad[path", secondPluginsDirectory}, fakeDriverFactory)
+					Expect(dockerdriver.WriteDriverSpec(logger, secondPluginsDirectory, driverName, "spec", []byte("http://0.0.0.0:8080"))).To(Succeed())
+				})
+
+				It("should still find the drivers in the others, and report the failing path", func() {
+					drivers, err := discoverer.Discover(context.Background(), logger)
+					Expect(drivers).To(HaveKey(driverName))
+
+					var discoveryErr volman.DiscoveryError
+					Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+					Expect(discoveryErr.Failures).To(HaveLen(1))
+					Expect(discoveryErr.Failures[0].Source).To(Equal("/bad[path"))
+				})
+
+				It("should tell which driver path each driver was found in", func() {
+					_, err := discoverer.Discover(context.Background(), logger)
+					Expect(err).To(HaveOccurred())
+
+					source, found := discoverer.(volman.SourcedDiscoverer).DriverSource(driverName)
+					Expect(found).To(BeTrue())
+					Expect(source).To(Equal(secondPluginsDirectory))
+
+					_, found = discoverer.(volman.SourcedDiscoverer).DriverSource("unknown-driver")
+					Expect(found).To(BeFalse())
+				})
+			})
+
 			Context("with multiple drivers in multiple directories", func() {
 				BeforeEach(func() {
 					err := dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "json", []byte("{\"Addr\":\"http://0.0.0.0:8080\"}"))
@@ -237,7 +291,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 				})
 
 				It("should find both drivers", func() {
-					drivers, err := discoverer.Discover(logger)
+					drivers, err := discoverer.Discover(context.Background(), logger)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(len(drivers)).To(Equal(2))
 				})
@@ -252,7 +306,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 				})
 
 				It("should preferentially select the driver in the first directory", func() {
-					_, err := discoverer.Discover(logger)
+					_, err := discoverer.Discover(context.Background(), logger)
 					Expect(err).ToNot(HaveOccurred())
 					_, _, _, specFileName := fakeDriverFactory.DockerDriverArgsForCall(0)
 					Expect(specFileName).To(Equal(driverName + ".json"))
@@ -274,8 +328,8 @@ var _ = Describe("Docker Driver Discoverer", func() {
 				driverDiscoverer = voldiscoverers.NewDockerDriverDiscovererWithDriverFactory(logger, nil, []string{defaultPluginsDirectory}, driverFactory)
 			})
 
-			TestCanonicalization := func(context, actual, it, expected string) {
-				Context(context, func() {
+			TestCanonicalization := func(description, actual, it, expected string) {
+				Context(description, func() {
 					BeforeEach(func() {
 						err := dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "spec", []byte(actual))
 						Expect(err).NotTo(HaveOccurred())
@@ -291,7 +345,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 					})
 
 					It(it, func() {
-						drivers, err := driverDiscoverer.Discover(logger)
+						drivers, err := driverDiscoverer.Discover(context.Background(), logger)
 						Expect(err).ToNot(HaveOccurred())
 						Expect(len(drivers)).To(Equal(1))
 						Expect(fakeRemoteClientFactory.NewRemoteClientCallCount()).To(Equal(1))
@@ -315,7 +369,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 				})
 
 				It("doesn't make a driver", func() {
-					_, err := driverDiscoverer.Discover(logger)
+					_, err := driverDiscoverer.Discover(context.Background(), logger)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(fakeRemoteClientFactory.NewRemoteClientCallCount()).To(Equal(0))
 				})
@@ -333,7 +387,7 @@ var _ = Describe("Docker Driver Discoverer", func() {
 					Implements: []string{"something-else"},
 				})
 
-				drivers, err := discoverer.Discover(logger)
+				drivers, err := discoverer.Discover(context.Background(), logger)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(drivers)).To(Equal(0))
 			})
@@ -343,14 +397,195 @@ var _ = Describe("Docker Driver Discoverer", func() {
 					Err: "some-error",
 				})
 
-				drivers, err := discoverer.Discover(logger)
+				drivers, err := discoverer.Discover(context.Background(), logger)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(len(drivers)).To(Equal(0))
 			})
 		})
+
+		Context("with timeouts", func() {
+			var fakeMetronClient *mfakes.FakeIngressClient
+
+			BeforeEach(func() {
+				fakeMetronClient = new(mfakes.FakeIngressClient)
+				timeouts := volman.TimeoutConfig{Defaults: volman.OperationTimeouts{Activate: 10 * time.Millisecond}}
+				discoverer = voldiscoverers.NewDockerDriverDiscovererWithTimeouts(logger, registry, []string{defaultPluginsDirectory}, fakeDriverFactory, fakeMetronClient, timeouts)
+			})
+
+			It("reads the timeouts declared in a json spec", func() {
+				specContents := []byte(`{"Addr": "http://0.0.0.0:8080", "Timeouts": {"Mount": "5m", "Activate": "1s"}}`)
+				Expect(dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "json", specContents)).To(Succeed())
+
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers).To(HaveKey(driverName))
+				Expect(drivers[driverName].GetPluginSpec().Timeouts).To(Equal(&volman.OperationTimeouts{Mount: 5 * time.Minute, Activate: time.Second}))
+			})
+
+			It("gives up on drivers that do not activate in time", func() {
+				Expect(dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "spec", []byte("http://0.0.0.0:8080"))).To(Succeed())
+				fakeDriver.ActivateStub = func(env dockerdriver.Env) dockerdriver.ActivateResponse {
+					<-env.Context().Done()
+					return dockerdriver.ActivateResponse{Err: env.Context().Err().Error()}
+				}
+
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers).To(BeEmpty())
+				Expect(fakeMetronClient.IncrementCounterCallCount()).To(BeNumerically(">=", 1))
+				Expect(fakeMetronClient.IncrementCounterArgsForCall(0)).To(Equal("VolmanActivateTimeouts"))
+				Expect(logger.Buffer()).To(gbytes.Say("activate-timed-out"))
+			})
+		})
+
+		Context("when the driver publishes a config schema", func() {
+			BeforeEach(func() {
+				Expect(dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "json", []byte(`{"Addr": "http://0.0.0.0:8080"}`))).To(Succeed())
+				schema := []byte(`{"type": "object", "properties": {"uid": {"type": "integer"}}, "additionalProperties": false}`)
+				Expect(os.WriteFile(filepath.Join(defaultPluginsDirectory, driverName+".schema.json"), schema, 0644)).To(Succeed())
+			})
+
+			It("attaches the schema to the plugin spec without treating it as a driver", func() {
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers).To(HaveLen(1))
+
+				schema := drivers[driverName].GetPluginSpec().ConfigSchema
+				Expect(schema).NotTo(BeNil())
+				Expect(schema.Properties).To(HaveKey("uid"))
+				Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(1))
+			})
+
+			It("creates the driver again when its schema changes", func() {
+				fakeDriver.MatchesReturns(true)
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				registry.Set(drivers)
+
+				schema := []byte(`{"type": "object", "properties": {"gid": {"type": "integer"}}}`)
+				Expect(os.WriteFile(filepath.Join(defaultPluginsDirectory, driverName+".schema.json"), schema, 0644)).To(Succeed())
+
+				rediscovered, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rediscovered[driverName]).NotTo(BeIdenticalTo(drivers[driverName]))
+				Expect(rediscovered[driverName].GetPluginSpec().ConfigSchema.Properties).To(HaveKey("gid"))
+				Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(2))
+			})
+
+			It("reuses the driver while its spec is unchanged", func() {
+				fakeDriver.MatchesReturns(true)
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				registry.Set(drivers)
+
+				rediscovered, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rediscovered[driverName]).To(BeIdenticalTo(drivers[driverName]))
+				Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(1))
+			})
+
+			It("ignores a schema it cannot parse", func() {
+				Expect(os.WriteFile(filepath.Join(defaultPluginsDirectory, driverName+".schema.json"), []byte("not json"), 0644)).To(Succeed())
+
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers[driverName].GetPluginSpec().ConfigSchema).To(BeNil())
+				Expect(logger.Buffer()).To(gbytes.Say("invalid-config-schema"))
+			})
+		})
+
+		Context("with an activation gate", func() {
+			var gate *heldBackDrivers
+
+			BeforeEach(func() {
+				gate = &heldBackDrivers{}
+				discoverer = voldiscoverers.NewDockerDriverDiscovererWithActivationGate(logger, registry, []string{defaultPluginsDirectory}, fakeDriverFactory, nil, volman.TimeoutConfig{}, gate)
+
+				Expect(dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "spec", []byte("http://0.0.0.0:8080"))).To(Succeed())
+				fakeDriver.MatchesReturns(true)
+
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				registry.Set(drivers)
+				Expect(fakeDriver.ActivateCallCount()).To(Equal(1))
+			})
+
+			It("keeps held back drivers registered without activating them", func() {
+				gate.names = []string{driverName}
+				fakeDriver.ActivateReturns(dockerdriver.ActivateResponse{Err: "connection refused"})
+
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers).To(HaveKey(driverName))
+				Expect(fakeDriver.ActivateCallCount()).To(Equal(1))
+				Expect(logger.Buffer()).To(gbytes.Say("skipping-activation"))
+			})
+
+			It("activates drivers that are not held back", func() {
+				_, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeDriver.ActivateCallCount()).To(Equal(2))
+			})
+		})
+	})
+
+	Describe("#DiscoverDriver", func() {
+		var driverDiscoverer volman.DriverDiscoverer
+
+		BeforeEach(func() {
+			driverDiscoverer = discoverer.(volman.DriverDiscoverer)
+			Expect(dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "spec", []byte("http://0.0.0.0:8080"))).To(Succeed())
+			Expect(dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, "other-driver", "spec", []byte("http://0.0.0.0:9090"))).To(Succeed())
+		})
+
+		It("discovers and activates only the named driver", func() {
+			plugin, found, err := driverDiscoverer.DiscoverDriver(context.Background(), logger, driverName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(plugin.GetPluginSpec().Name).To(Equal(driverName))
+
+			Expect(fakeDriverFactory.DockerDriverCallCount()).To(Equal(1))
+			Expect(fakeDriver.ActivateCallCount()).To(Equal(1))
+		})
+
+		It("prefers the spec types in the same order as a full discovery", func() {
+			Expect(dockerdriver.WriteDriverSpec(logger, defaultPluginsDirectory, driverName, "json", []byte(`{"Addr": "http://0.0.0.0:7070"}`))).To(Succeed())
+
+			plugin, found, err := driverDiscoverer.DiscoverDriver(context.Background(), logger, driverName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(plugin.GetPluginSpec().Address).To(Equal("http://0.0.0.0:7070"))
+		})
+
+		It("reports drivers without a spec as not found", func() {
+			_, found, err := driverDiscoverer.DiscoverDriver(context.Background(), logger, "missing-driver")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("reports drivers that do not activate as not found", func() {
+			fakeDriver.ActivateReturns(dockerdriver.ActivateResponse{Err: "connection refused"})
+
+			_, found, err := driverDiscoverer.DiscoverDriver(context.Background(), logger, driverName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
 	})
 })
 
+type heldBackDrivers struct {
+	names []string
+}
+
+func (g *heldBackDrivers) AllowActivation(driverId string) bool {
+	for _, name := range g.names {
+		if name == driverId {
+			return false
+		}
+	}
+	return true
+}
+
 func sockSpec() specTuple {
 	return specTuple{DriverName: "driver3", Spec: "sock", SpecFileContents: ``}
 }
@@ -13,6 +13,7 @@ import (
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
 	"code.cloudfoundry.org/goshims/osshim"
 	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
 )
 
 //go:generate counterfeiter -o ../volmanfakes/fake_docker_driver_factory.go . DockerDriverFactory
@@ -23,6 +24,14 @@ type DockerDriverFactory interface {
 	DockerDriver(logger lager.Logger, driverId string, driverPath, driverFileName string) (dockerdriver.Driver, error)
 }
 
+//go:generate counterfeiter -o ../volmanfakes/fake_spec_driver_factory.go . SpecDriverFactory
+
+// SpecDriverFactory instantiates remote clients for drivers whose specs do not come from
+// spec files, such as drivers listed in volman's config.
+type SpecDriverFactory interface {
+	DockerDriverForSpec(logger lager.Logger, spec volman.PluginSpec) (dockerdriver.Driver, error)
+}
+
 type dockerDriverFactory struct {
 	Factory driverhttp.RemoteClientFactory
 	useOs   osshim.Os
@@ -42,6 +51,42 @@ func NewDockerDriverFactoryWithOs(useOs osshim.Os) DockerDriverFactory {
 	return &dockerDriverFactory{remoteClientFactory, useOs}
 }
 
+// NewSpecDriverFactory returns a factory of remote clients for drivers described by
+// their specs alone.
+func NewSpecDriverFactory() SpecDriverFactory {
+	return &dockerDriverFactory{driverhttp.NewRemoteClientFactory(), &osshim.OsShim{}}
+}
+
+func (r *dockerDriverFactory) DockerDriverForSpec(logger lager.Logger, spec volman.PluginSpec) (dockerdriver.Driver, error) {
+	logger = logger.Session("driver-for-spec", lager.Data{"driverId": spec.Name})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	address, err := r.canonicalize(logger, spec.Address)
+	if err != nil {
+		logger.Error("invalid-address", err, lager.Data{"address": spec.Address})
+		return nil, err
+	}
+
+	var tls *dockerdriver.TLSConfig
+	if spec.TLSConfig != nil {
+		tls = &dockerdriver.TLSConfig{
+			InsecureSkipVerify: spec.TLSConfig.InsecureSkipVerify,
+			CAFile:             spec.TLSConfig.CAFile,
+			CertFile:           spec.TLSConfig.CertFile,
+			KeyFile:            spec.TLSConfig.KeyFile,
+		}
+	}
+
+	logger.Info("getting-driver", lager.Data{"address": address})
+	driver, err := r.Factory.NewRemoteClient(address, tls)
+	if err != nil {
+		logger.Error("error-building-driver", err, lager.Data{"address": address})
+		return nil, err
+	}
+	return driver, nil
+}
+
 func (r *dockerDriverFactory) DockerDriver(logger lager.Logger, driverId string, driverPath string, driverFileName string) (dockerdriver.Driver, error) {
 	logger = logger.Session("driver", lager.Data{"driverId": driverId, "driverFileName": driverFileName})
 	logger.Info("start")
@@ -0,0 +1,207 @@
+package voldiscoverers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultDriverPollInterval is used when no poll interval is configured.
+const defaultDriverPollInterval = time.Second * 2
+
+type driverWatcher struct {
+	driverPaths  []string
+	clock        clock.Clock
+	pollInterval time.Duration
+}
+
+// NewDriverWatcher returns a watcher of the spec files in driverPaths that relies on
+// inotify, and falls back to listing the driver paths every pollInterval when they
+// cannot be watched, such as when one does not exist yet.
+func NewDriverWatcher(driverPaths []string, clock clock.Clock, pollInterval time.Duration) volman.DriverWatcher {
+	return &driverWatcher{
+		driverPaths:  driverPaths,
+		clock:        clock,
+		pollInterval: pollInterval,
+	}
+}
+
+// NewPollingDriverWatcher returns a watcher that only ever polls the driver paths.
+func NewPollingDriverWatcher(driverPaths []string, clock clock.Clock, pollInterval time.Duration) volman.DriverWatcher {
+	if pollInterval <= 0 {
+		pollInterval = defaultDriverPollInterval
+	}
+	return &pollingDriverWatcher{
+		driverPaths:  driverPaths,
+		clock:        clock,
+		pollInterval: pollInterval,
+	}
+}
+
+func (w *driverWatcher) Watch(ctx context.Context, logger lager.Logger) <-chan string {
+	logger = logger.Session("driver-watcher")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		for _, driverPath := range w.driverPaths {
+			if err = watcher.Add(driverPath); err != nil {
+				watcher.Close()
+				break
+			}
+		}
+	}
+	if err != nil {
+		logger.Info("falling-back-to-polling", lager.Data{"error": err.Error(), "interval": w.pollInterval.String()})
+		return NewPollingDriverWatcher(w.driverPaths, w.clock, w.pollInterval).Watch(ctx, logger)
+	}
+
+	logger.Info("watching-driver-paths", lager.Data{"driver-paths": w.driverPaths})
+	changes := make(chan string)
+	go func() {
+		defer close(changes)
+		defer watcher.Close()
+
+		for {
+			select {
+			case event := <-watcher.Events:
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				driverId, ok := driverIdForSpec(event.Name)
+				if !ok {
+					continue
+				}
+				logger.Debug("driver-spec-changed", lager.Data{"driverId": driverId, "event": event.Op.String()})
+				select {
+				case changes <- driverId:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-watcher.Errors:
+				// missed events are caught up with by the periodic full discovery
+				logger.Error("watch-error", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
+
+type pollingDriverWatcher struct {
+	driverPaths  []string
+	clock        clock.Clock
+	pollInterval time.Duration
+}
+
+type specFileState struct {
+	modTime time.Time
+	size    int64
+	mode    os.FileMode
+}
+
+func (s specFileState) equal(other specFileState) bool {
+	return s.modTime.Equal(other.modTime) && s.size == other.size && s.mode == other.mode
+}
+
+func (w *pollingDriverWatcher) Watch(ctx context.Context, logger lager.Logger) <-chan string {
+	logger = logger.Session("driver-poller")
+
+	// changes are reported from the moment Watch returns
+	previous := w.snapshot()
+
+	changes := make(chan string)
+	go func() {
+		defer close(changes)
+
+		ticker := w.clock.NewTicker(w.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+			case <-ctx.Done():
+				return
+			}
+
+			current := w.snapshot()
+			for _, driverId := range changedDrivers(previous, current) {
+				logger.Debug("driver-spec-changed", lager.Data{"driverId": driverId})
+				select {
+				case changes <- driverId:
+				case <-ctx.Done():
+					return
+				}
+			}
+			previous = current
+		}
+	}()
+	return changes
+}
+
+func (w *pollingDriverWatcher) snapshot() map[string]specFileState {
+	states := map[string]specFileState{}
+	for _, driverPath := range w.driverPaths {
+		entries, err := os.ReadDir(driverPath)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			states[filepath.Join(driverPath, entry.Name())] = specFileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+		}
+	}
+	return states
+}
+
+// changedDrivers returns the drivers whose spec files differ between the snapshots, in
+// no particular order and each only once.
+func changedDrivers(previous map[string]specFileState, current map[string]specFileState) []string {
+	changed := map[string]bool{}
+	for path, state := range current {
+		if previousState, found := previous[path]; !found || !previousState.equal(state) {
+			changed[path] = true
+		}
+	}
+	for path := range previous {
+		if _, found := current[path]; !found {
+			changed[path] = true
+		}
+	}
+
+	seen := map[string]bool{}
+	var driverIds []string
+	for path := range changed {
+		if driverId, ok := driverIdForSpec(path); ok && !seen[driverId] {
+			seen[driverId] = true
+			driverIds = append(driverIds, driverId)
+		}
+	}
+	return driverIds
+}
+
+// driverIdForSpec names the driver that a file in a driver path belongs to, including
+// the config schemas that drivers publish next to their specs.
+func driverIdForSpec(path string) (string, bool) {
+	name := filepath.Base(path)
+	if driverId := strings.TrimSuffix(name, configSchemaSuffix); driverId != name {
+		return driverId, driverId != ""
+	}
+
+	for _, specType := range specTypes {
+		if driverId := strings.TrimSuffix(name, "."+specType); driverId != name && driverId != "" {
+			return driverId, true
+		}
+	}
+	return "", false
+}
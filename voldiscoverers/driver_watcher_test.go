@@ -0,0 +1,138 @@
+package voldiscoverers_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"github.com/onsi/gomega/gbytes"
+
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/voldiscoverers"
+)
+
+var _ = Describe("DriverWatcher", func() {
+	var (
+		logger    *lagertest.TestLogger
+		fakeClock *fakeclock.FakeClock
+		ctx       context.Context
+		cancel    context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("driver-watcher")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	writeFile := func(dir string, name string, contents string) {
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)).To(Succeed())
+	}
+
+	Context("when the driver paths can be watched", func() {
+		var changes <-chan string
+
+		BeforeEach(func() {
+			watcher := voldiscoverers.NewDriverWatcher([]string{defaultPluginsDirectory, secondPluginsDirectory}, fakeClock, time.Second)
+			changes = watcher.Watch(ctx, logger)
+			Expect(logger).To(gbytes.Say("watching-driver-paths"))
+		})
+
+		It("reports drivers whose specs appear, change or disappear", func() {
+			writeFile(defaultPluginsDirectory, "some-driver.spec", "http://0.0.0.0:8080")
+			Eventually(changes).Should(Receive(Equal("some-driver")))
+
+			writeFile(secondPluginsDirectory, "other-driver.json", `{"Addr": "http://0.0.0.0:9090"}`)
+			Eventually(changes).Should(Receive(Equal("other-driver")))
+
+			Expect(os.Remove(filepath.Join(defaultPluginsDirectory, "some-driver.spec"))).To(Succeed())
+			Eventually(changes).Should(Receive(Equal("some-driver")))
+		})
+
+		It("reports config schema changes as changes to their driver", func() {
+			writeFile(defaultPluginsDirectory, "some-driver.schema.json", `{"type": "object"}`)
+			Eventually(changes).Should(Receive(Equal("some-driver")))
+		})
+
+		It("ignores files that are not driver specs", func() {
+			writeFile(defaultPluginsDirectory, "README", "not a driver")
+			Consistently(changes, 200*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("stops when its context ends", func() {
+			cancel()
+			Eventually(changes).Should(BeClosed())
+		})
+	})
+
+	Context("when a driver path cannot be watched", func() {
+		var (
+			missingDirectory string
+			changes          <-chan string
+		)
+
+		BeforeEach(func() {
+			missingDirectory = filepath.Join(defaultPluginsDirectory, "not-there-yet")
+			watcher := voldiscoverers.NewDriverWatcher([]string{missingDirectory}, fakeClock, time.Second)
+			changes = watcher.Watch(ctx, logger)
+		})
+
+		It("falls back to polling", func() {
+			Expect(logger).To(gbytes.Say("falling-back-to-polling"))
+
+			Expect(os.Mkdir(missingDirectory, 0755)).To(Succeed())
+			writeFile(missingDirectory, "some-driver.sock", "")
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+			Eventually(changes).Should(Receive(Equal("some-driver")))
+		})
+	})
+
+	Describe("polling", func() {
+		var (
+			watcher volman.DriverWatcher
+			changes <-chan string
+		)
+
+		BeforeEach(func() {
+			writeFile(defaultPluginsDirectory, "some-driver.spec", "http://0.0.0.0:8080")
+			writeFile(defaultPluginsDirectory, "old-driver.spec", "http://0.0.0.0:7070")
+
+			watcher = voldiscoverers.NewPollingDriverWatcher([]string{defaultPluginsDirectory}, fakeClock, time.Second)
+			changes = watcher.Watch(ctx, logger)
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+		})
+
+		It("reports nothing until the driver paths change", func() {
+			fakeClock.Increment(time.Second)
+			Consistently(changes, 200*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("reports every driver that changed since the last poll", func() {
+			writeFile(defaultPluginsDirectory, "some-driver.spec", "http://0.0.0.0:8081")
+			writeFile(defaultPluginsDirectory, "new-driver.json", "{}")
+			Expect(os.Remove(filepath.Join(defaultPluginsDirectory, "old-driver.spec"))).To(Succeed())
+
+			fakeClock.Increment(time.Second)
+
+			var reported []string
+			for i := 0; i < 3; i++ {
+				var driverId string
+				Eventually(changes).Should(Receive(&driverId))
+				reported = append(reported, driverId)
+			}
+			Expect(reported).To(ConsistOf("some-driver", "new-driver", "old-driver"))
+		})
+	})
+})
@@ -0,0 +1,192 @@
+package voldiscoverers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	loggingclient "code.cloudfoundry.org/diego-logging-client"
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/voldocker"
+)
+
+// specDriverDiscoverer discovers drivers from specs that are handed to it rather than
+// found in driver paths, activating them the same way the docker discoverer does.
+type specDriverDiscoverer struct {
+	logger        lager.Logger
+	source        string
+	specs         func(logger lager.Logger) ([]volman.PluginSpec, error)
+	driverFactory SpecDriverFactory
+
+	driverRegistry volman.PluginRegistry
+
+	metronClient loggingclient.IngressClient
+	timeouts     volman.TimeoutConfig
+	gate         ActivationGate
+
+	mountpointPolicy volman.MountpointPolicy
+}
+
+// NewStaticDriverDiscoverer returns a discoverer of a fixed list of drivers, such as
+// drivers listed in volman's config, that are registered while they activate.
+func NewStaticDriverDiscoverer(logger lager.Logger, driverRegistry volman.PluginRegistry, specs []volman.PluginSpec, factory SpecDriverFactory, metronClient loggingclient.IngressClient, timeouts volman.TimeoutConfig, gate ActivationGate, policy volman.MountpointPolicy) volman.Discoverer {
+	return &specDriverDiscoverer{
+		logger: logger,
+		source: "static drivers",
+		specs: func(lager.Logger) ([]volman.PluginSpec, error) {
+			return specs, nil
+		},
+		driverFactory: factory,
+
+		driverRegistry: driverRegistry,
+
+		metronClient: metronClient,
+		timeouts:     timeouts,
+		gate:         gate,
+
+		mountpointPolicy: policy,
+	}
+}
+
+// NewManifestDriverDiscoverer returns a discoverer of the drivers listed in a JSON
+// manifest, which is read again on every discovery. The manifest lists drivers in the
+// format of .json driver specs under "drivers".
+func NewManifestDriverDiscoverer(logger lager.Logger, driverRegistry volman.PluginRegistry, manifestPath string, factory SpecDriverFactory, metronClient loggingclient.IngressClient, timeouts volman.TimeoutConfig, gate ActivationGate, policy volman.MountpointPolicy) volman.Discoverer {
+	return &specDriverDiscoverer{
+		logger: logger,
+		source: manifestPath,
+		specs: func(logger lager.Logger) ([]volman.PluginSpec, error) {
+			return readDriverManifest(logger, manifestPath)
+		},
+		driverFactory: factory,
+
+		driverRegistry: driverRegistry,
+
+		metronClient: metronClient,
+		timeouts:     timeouts,
+		gate:         gate,
+
+		mountpointPolicy: policy,
+	}
+}
+
+func (r *specDriverDiscoverer) Discover(ctx context.Context, logger lager.Logger) (map[string]volman.Plugin, error) {
+	logger = volman.NewRedactingLogger(logger).Session("discover", lager.Data{"source": r.source})
+	logger.Debug("start")
+	defer logger.Debug("end")
+
+	specs, err := r.specs(logger)
+	if err != nil {
+		logger.Error("failed-reading-driver-specs", err)
+		return map[string]volman.Plugin{}, volman.DiscoveryError{Failures: []volman.DiscoveryFailure{{Source: r.source, Err: err}}}
+	}
+
+	var existing map[string]volman.Plugin
+	if r.driverRegistry != nil {
+		existing = r.driverRegistry.Plugins()
+	}
+
+	plugins := map[string]volman.Plugin{}
+	for _, spec := range specs {
+		if plugin, ok := r.activatePlugin(ctx, logger, spec, existing[spec.Name]); ok {
+			plugins[spec.Name] = plugin
+		}
+	}
+	return plugins, nil
+}
+
+// DiscoverDriver discovers the named driver alone, if it is one of the discoverer's.
+func (r *specDriverDiscoverer) DiscoverDriver(ctx context.Context, logger lager.Logger, driverId string) (volman.Plugin, bool, error) {
+	logger = volman.NewRedactingLogger(logger).Session("discover-driver", lager.Data{"source": r.source, "driverId": driverId})
+	logger.Debug("start")
+	defer logger.Debug("end")
+
+	specs, err := r.specs(logger)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existing volman.Plugin
+	if r.driverRegistry != nil {
+		existing, _ = r.driverRegistry.Plugin(driverId)
+	}
+
+	for _, spec := range specs {
+		if spec.Name == driverId {
+			plugin, ok := r.activatePlugin(ctx, logger, spec, existing)
+			return plugin, ok, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// activatePlugin activates the driver of spec, reusing the registered plugin when it
+// was created from the same spec. It reports false if the driver cannot be registered.
+func (r *specDriverDiscoverer) activatePlugin(ctx context.Context, logger lager.Logger, spec volman.PluginSpec, existing volman.Plugin) (volman.Plugin, bool) {
+	if spec.Name == "" {
+		logger.Error("invalid-driver-spec", errors.New("driver spec has no name"), lager.Data{"address": spec.Address})
+		return nil, false
+	}
+
+	dockerPlugin, ok := existing.(*voldocker.DockerDriverPlugin)
+	if !ok || !existing.Matches(logger, spec) || specChanged(logger, existing, spec) {
+		logger.Info("creating-driver", lager.Data{"spec-name": spec.Name, "address": spec.Address})
+		driver, err := r.driverFactory.DockerDriverForSpec(logger, spec)
+		if err != nil {
+			logger.Error("error-creating-driver", err, lager.Data{"spec-name": spec.Name})
+			return nil, false
+		}
+		dockerPlugin = voldocker.NewVolmanPluginWithMountpointPolicy(driver, spec, r.metronClient, r.mountpointPolicy).(*voldocker.DockerDriverPlugin)
+	}
+
+	if r.gate != nil && !r.gate.AllowActivation(spec.Name) {
+		logger.Info("skipping-activation", lager.Data{"spec-name": spec.Name})
+		return dockerPlugin, true
+	}
+
+	resp := activateDriver(ctx, logger, r.metronClient, r.timeouts, spec.Name, spec, dockerPlugin.DockerDriver.(dockerdriver.Driver))
+	if resp.Err != "" {
+		dockerPlugin.Unreachable()
+		logger.Error("driver-unreachable", errors.New(resp.Err), lager.Data{"spec-name": spec.Name, "address": spec.Address, "tls": spec.TLSConfig})
+		return nil, false
+	}
+	if !implementVolumeDriver(resp) {
+		logger.Error("driver-invalid", fmt.Errorf("driver-implements: %#v, expecting: VolumeDriver", resp.Implements))
+		return nil, false
+	}
+
+	recordActivation(ctx, logger, r.timeouts, dockerPlugin)
+	return dockerPlugin, true
+}
+
+// driverManifest lists drivers in the format of .json driver specs, timeouts included.
+type driverManifest struct {
+	Drivers []struct {
+		dockerdriver.DriverSpec
+		specTimeouts
+	} `json:"drivers"`
+}
+
+func readDriverManifest(logger lager.Logger, manifestPath string) ([]volman.PluginSpec, error) {
+	contents, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest driverManifest
+	if err := json.Unmarshal(contents, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid driver manifest: %s", err.Error())
+	}
+
+	var specs []volman.PluginSpec
+	for _, driver := range manifest.Drivers {
+		spec := mapDriverSpecToPluginSpec(&driver.DriverSpec)
+		spec.Timeouts = parseSpecTimeouts(logger, manifestPath, driver.specTimeouts)
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
@@ -0,0 +1,205 @@
+package voldiscoverers_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/dockerdriverfakes"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/voldiscoverers"
+	"code.cloudfoundry.org/volman/vollocal"
+	"code.cloudfoundry.org/volman/volmanfakes"
+)
+
+type closedGate struct{}
+
+func (closedGate) AllowActivation(string) bool { return false }
+
+var _ = Describe("Spec Driver Discoverer", func() {
+	var (
+		logger *lagertest.TestLogger
+
+		fakeDriverFactory *volmanfakes.FakeSpecDriverFactory
+		fakeDriver        *dockerdriverfakes.FakeMatchableDriver
+
+		registry volman.PluginRegistry
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("spec-driver-discovery-test")
+
+		fakeDriver = new(dockerdriverfakes.FakeMatchableDriver)
+		fakeDriver.ActivateReturns(dockerdriver.ActivateResponse{Implements: []string{"VolumeDriver"}})
+
+		fakeDriverFactory = new(volmanfakes.FakeSpecDriverFactory)
+		fakeDriverFactory.DockerDriverForSpecReturns(fakeDriver, nil)
+
+		registry = vollocal.NewPluginRegistry()
+	})
+
+	Describe("static drivers", func() {
+		var (
+			specs      []volman.PluginSpec
+			gate       voldiscoverers.ActivationGate
+			discoverer volman.Discoverer
+		)
+
+		BeforeEach(func() {
+			specs = []volman.PluginSpec{
+				{Name: "some-driver", Address: "http://0.0.0.0:8080"},
+				{Name: "other-driver", Address: "/var/vcap/data/voldrivers/other-driver.sock"},
+			}
+			gate = nil
+		})
+
+		JustBeforeEach(func() {
+			discoverer = voldiscoverers.NewStaticDriverDiscoverer(logger, registry, specs, fakeDriverFactory, nil, volman.TimeoutConfig{}, gate, volman.WarnOnlyMountpointPolicy())
+		})
+
+		It("registers every driver that activates", func() {
+			drivers, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drivers).To(HaveLen(2))
+			Expect(drivers["some-driver"].GetPluginSpec()).To(Equal(specs[0]))
+			Expect(drivers["other-driver"].GetPluginSpec()).To(Equal(specs[1]))
+
+			Expect(fakeDriverFactory.DockerDriverForSpecCallCount()).To(Equal(2))
+			Expect(fakeDriver.ActivateCallCount()).To(Equal(2))
+		})
+
+		It("records the activation and scope of the drivers it registers", func() {
+			fakeDriver.CapabilitiesReturns(dockerdriver.CapabilitiesResponse{Capabilities: dockerdriver.CapabilityInfo{Scope: "local"}})
+
+			drivers, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			health := drivers["some-driver"].(volman.HealthReporter).Health(context.Background(), logger)
+			Expect(health.Reachable).To(BeTrue())
+			Expect(health.Scope).To(Equal("local"))
+			Expect(health.LastActivation).NotTo(BeZero())
+		})
+
+		It("leaves out drivers that cannot be created or do not activate", func() {
+			unreachableDriver := new(dockerdriverfakes.FakeMatchableDriver)
+			unreachableDriver.ActivateReturns(dockerdriver.ActivateResponse{Err: "connection refused"})
+			fakeDriverFactory.DockerDriverForSpecStub = func(_ lager.Logger, spec volman.PluginSpec) (dockerdriver.Driver, error) {
+				if spec.Name == "some-driver" {
+					return unreachableDriver, nil
+				}
+				return nil, errors.New("invalid address")
+			}
+
+			drivers, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drivers).To(BeEmpty())
+		})
+
+		It("reuses registered drivers that still match their spec", func() {
+			drivers, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			registry.Set(drivers)
+
+			fakeDriver.MatchesReturns(true)
+			rediscovered, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rediscovered["some-driver"]).To(BeIdenticalTo(drivers["some-driver"]))
+			Expect(fakeDriverFactory.DockerDriverForSpecCallCount()).To(Equal(2))
+		})
+
+		It("creates drivers again when their spec changes", func() {
+			drivers, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			registry.Set(drivers)
+
+			specs[0].Timeouts = &volman.OperationTimeouts{Mount: time.Minute}
+			fakeDriver.MatchesReturns(true)
+			rediscovered, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rediscovered["some-driver"]).NotTo(BeIdenticalTo(drivers["some-driver"]))
+			Expect(rediscovered["some-driver"].GetPluginSpec().Timeouts.Mount).To(Equal(time.Minute))
+			Expect(rediscovered["other-driver"]).To(BeIdenticalTo(drivers["other-driver"]))
+			Expect(fakeDriverFactory.DockerDriverForSpecCallCount()).To(Equal(3))
+		})
+
+		Context("when the gate holds activation back", func() {
+			BeforeEach(func() {
+				gate = closedGate{}
+			})
+
+			It("registers the drivers without activating them", func() {
+				drivers, err := discoverer.Discover(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers).To(HaveLen(2))
+				Expect(fakeDriver.ActivateCallCount()).To(Equal(0))
+			})
+		})
+
+		It("discovers single drivers", func() {
+			driverDiscoverer := discoverer.(volman.DriverDiscoverer)
+
+			plugin, found, err := driverDiscoverer.DiscoverDriver(context.Background(), logger, "other-driver")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(plugin.GetPluginSpec()).To(Equal(specs[1]))
+
+			_, found, err = driverDiscoverer.DiscoverDriver(context.Background(), logger, "unknown-driver")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("manifests", func() {
+		var (
+			manifestPath string
+			discoverer   volman.Discoverer
+		)
+
+		BeforeEach(func() {
+			manifestPath = filepath.Join(defaultPluginsDirectory, "drivers.json")
+			discoverer = voldiscoverers.NewManifestDriverDiscoverer(logger, registry, manifestPath, fakeDriverFactory, nil, volman.TimeoutConfig{}, nil, volman.WarnOnlyMountpointPolicy())
+		})
+
+		It("registers the drivers listed in the manifest", func() {
+			Expect(os.WriteFile(manifestPath, []byte(`{"drivers": [
+				{"Name": "some-driver", "Addr": "https://0.0.0.0:8080", "TLSConfig": {"CAFile": "/ca.pem"}, "UniqueVolumeIds": true, "Timeouts": {"Mount": "30s"}}
+			]}`), 0644)).To(Succeed())
+
+			drivers, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drivers).To(HaveLen(1))
+			Expect(drivers["some-driver"].GetPluginSpec()).To(Equal(volman.PluginSpec{
+				Name:            "some-driver",
+				Address:         "https://0.0.0.0:8080",
+				TLSConfig:       &volman.TLSConfig{CAFile: "/ca.pem"},
+				UniqueVolumeIds: true,
+				Timeouts:        &volman.OperationTimeouts{Mount: 30 * time.Second},
+			}))
+		})
+
+		It("reports a manifest that cannot be read as a discovery error", func() {
+			_, err := discoverer.Discover(context.Background(), logger)
+
+			var discoveryErr volman.DiscoveryError
+			Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+			Expect(discoveryErr.Failures).To(HaveLen(1))
+			Expect(discoveryErr.Failures[0].Source).To(Equal(manifestPath))
+		})
+
+		It("reports a manifest that is not valid JSON", func() {
+			Expect(os.WriteFile(manifestPath, []byte(`{"drivers": [`), 0644)).To(Succeed())
+
+			_, err := discoverer.Discover(context.Background(), logger)
+			Expect(err).To(MatchError(ContainSubstring("invalid driver manifest")))
+		})
+	})
+})
@@ -5,23 +5,43 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"sync"
+	"time"
 
+	loggingclient "code.cloudfoundry.org/diego-logging-client"
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/volman"
 )
 
+const volmanMountpointPolicyViolationsCounter = "VolmanMountpointPolicyViolations"
+
 type DockerDriverPlugin struct {
 	DockerDriver interface{}
 	PluginSpec   volman.PluginSpec
+
+	mountpointPolicy volman.MountpointPolicy
+	metronClient     loggingclient.IngressClient
+
+	activationMutex sync.Mutex
+	lastActivation  time.Time
+	reachable       bool
+	scope           string
 }
 
 func NewVolmanPluginWithDockerDriver(driver dockerdriver.Driver, pluginSpec volman.PluginSpec) volman.Plugin {
+	return NewVolmanPluginWithMountpointPolicy(driver, pluginSpec, nil, volman.WarnOnlyMountpointPolicy())
+}
+
+// NewVolmanPluginWithMountpointPolicy returns a plugin that checks every mountpoint the
+// driver returns against policy before handing it out, counting each violation.
+func NewVolmanPluginWithMountpointPolicy(driver dockerdriver.Driver, pluginSpec volman.PluginSpec, metronClient loggingclient.IngressClient, policy volman.MountpointPolicy) volman.Plugin {
 	return &DockerDriverPlugin{
-		DockerDriver: driver,
-		PluginSpec:   pluginSpec,
+		DockerDriver:     driver,
+		PluginSpec:       pluginSpec,
+		mountpointPolicy: policy,
+		metronClient:     metronClient,
 	}
 }
 
@@ -49,13 +69,13 @@ func (dw *DockerDriverPlugin) Matches(logger lager.Logger, pluginSpec volman.Plu
 	return matches
 }
 
-func (d *DockerDriverPlugin) ListVolumes(logger lager.Logger) ([]string, error) {
-	logger = logger.Session("list-volumes")
+func (d *DockerDriverPlugin) ListVolumes(ctx context.Context, logger lager.Logger) ([]string, error) {
+	logger = volman.NewRedactingLogger(logger).Session("list-volumes")
 	logger.Info("start")
 	defer logger.Info("end")
 
 	volumes := []string{}
-	env := driverhttp.NewHttpDriverEnv(logger, context.TODO())
+	env := driverhttp.NewHttpDriverEnv(logger, ctx)
 
 	response := d.DockerDriver.(dockerdriver.Driver).List(env)
 	if response.Err != "" {
@@ -69,12 +89,12 @@ func (d *DockerDriverPlugin) ListVolumes(logger lager.Logger) ([]string, error)
 	return volumes, nil
 }
 
-func (d *DockerDriverPlugin) Mount(logger lager.Logger, volumeId string, opts map[string]interface{}) (volman.MountResponse, error) {
-	logger = logger.Session("mount")
+func (d *DockerDriverPlugin) Mount(ctx context.Context, logger lager.Logger, volumeId string, opts map[string]interface{}) (volman.MountResponse, error) {
+	logger = volman.NewRedactingLogger(logger).Session("mount")
 	logger.Info("start")
 	defer logger.Info("end")
 
-	env := driverhttp.NewHttpDriverEnv(logger, context.TODO())
+	env := driverhttp.NewHttpDriverEnv(logger, ctx)
 
 	logger.Debug("creating-volume", lager.Data{"volumeId": volumeId})
 	response := d.DockerDriver.(dockerdriver.Driver).Create(env, dockerdriver.CreateRequest{Name: volumeId, Opts: opts})
@@ -87,10 +107,6 @@ func (d *DockerDriverPlugin) Mount(logger lager.Logger, volumeId string, opts ma
 	mountResponse := d.DockerDriver.(dockerdriver.Driver).Mount(env, mountRequest)
 	logger.Debug("response-from-docker-driver", lager.Data{"response": mountResponse})
 
-	if !strings.HasPrefix(mountResponse.Mountpoint, "/var/vcap/data") {
-		logger.Info("invalid-mountpath", lager.Data{"detail": fmt.Sprintf("Invalid or dangerous mountpath %s outside of /var/vcap/data", mountResponse.Mountpoint)})
-	}
-
 	if mountResponse.Err != "" {
 		safeError := dockerdriver.SafeError{}
 		err := json.Unmarshal([]byte(mountResponse.Err), &safeError)
@@ -101,15 +117,45 @@ func (d *DockerDriverPlugin) Mount(logger lager.Logger, volumeId string, opts ma
 		}
 	}
 
+	if err := d.checkMountpoint(env, logger, volumeId, mountResponse.Mountpoint); err != nil {
+		return volman.MountResponse{}, err
+	}
+
 	return volman.MountResponse{Path: mountResponse.Mountpoint}, nil
 }
 
-func (d *DockerDriverPlugin) Unmount(logger lager.Logger, volumeId string) error {
-	logger = logger.Session("unmount")
+// checkMountpoint applies the mountpoint policy. A rejected volume is unmounted again, as
+// nothing else would ever unmount it.
+func (d *DockerDriverPlugin) checkMountpoint(env dockerdriver.Env, logger lager.Logger, volumeId string, mountpoint string) error {
+	err := d.mountpointPolicy.Check(mountpoint)
+	if err == nil {
+		return nil
+	}
+
+	if d.metronClient != nil {
+		if metricErr := d.metronClient.IncrementCounter(volmanMountpointPolicyViolationsCounter); metricErr != nil {
+			logger.Debug("failed-emitting-mountpoint-policy-violation-metric", lager.Data{"error": metricErr})
+		}
+	}
+
+	if d.mountpointPolicy.Mode == volman.MountpointPolicyWarn {
+		logger.Info("invalid-mountpath", lager.Data{"detail": fmt.Sprintf("Invalid or dangerous mountpath: %s", err)})
+		return nil
+	}
+
+	logger.Error("mountpoint-policy-violation", err)
+	if response := d.DockerDriver.(dockerdriver.Driver).Unmount(env, dockerdriver.UnmountRequest{Name: volumeId}); response.Err != "" {
+		logger.Error("failed-unmounting-rejected-mountpoint", errors.New(response.Err))
+	}
+	return err
+}
+
+func (d *DockerDriverPlugin) Unmount(ctx context.Context, logger lager.Logger, volumeId string) error {
+	logger = volman.NewRedactingLogger(logger).Session("unmount")
 	logger.Info("start")
 	defer logger.Info("end")
 
-	env := driverhttp.NewHttpDriverEnv(logger, context.TODO())
+	env := driverhttp.NewHttpDriverEnv(logger, ctx)
 
 	if response := d.DockerDriver.(dockerdriver.Driver).Unmount(env, dockerdriver.UnmountRequest{Name: volumeId}); response.Err != "" {
 
@@ -127,6 +173,54 @@ func (d *DockerDriverPlugin) Unmount(logger lager.Logger, volumeId string) error
 	return nil
 }
 
+// Activated records a successful activation of the driver, as reported by Health.
+func (d *DockerDriverPlugin) Activated(at time.Time) {
+	d.activationMutex.Lock()
+	defer d.activationMutex.Unlock()
+
+	d.reachable = true
+	if at.After(d.lastActivation) {
+		d.lastActivation = at
+	}
+}
+
+// Unreachable records a failed activation of the driver, as reported by Health.
+func (d *DockerDriverPlugin) Unreachable() {
+	d.activationMutex.Lock()
+	defer d.activationMutex.Unlock()
+
+	d.reachable = false
+}
+
+// SetScope records the scope the driver reports in its capabilities.
+func (d *DockerDriverPlugin) SetScope(scope string) {
+	d.activationMutex.Lock()
+	defer d.activationMutex.Unlock()
+
+	d.scope = scope
+}
+
+// Scope returns the scope recorded with SetScope, if any.
+func (d *DockerDriverPlugin) Scope() string {
+	d.activationMutex.Lock()
+	defer d.activationMutex.Unlock()
+
+	return d.scope
+}
+
+// Health reports the driver as of its last activation, without contacting it. Drivers
+// that have never been activated are reported unreachable.
+func (d *DockerDriverPlugin) Health(ctx context.Context, logger lager.Logger) volman.PluginHealth {
+	d.activationMutex.Lock()
+	defer d.activationMutex.Unlock()
+
+	return volman.PluginHealth{
+		Scope:          d.scope,
+		LastActivation: d.lastActivation,
+		Reachable:      d.reachable,
+	}
+}
+
 func (d *DockerDriverPlugin) GetPluginSpec() volman.PluginSpec {
 	return d.PluginSpec
 }
@@ -1,16 +1,20 @@
 package voldocker_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	mfakes "code.cloudfoundry.org/diego-logging-client/testhelpers"
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/dockerdriverfakes"
 	"code.cloudfoundry.org/volman/voldocker"
 
+	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagertest"
 	"code.cloudfoundry.org/volman"
 	"github.com/onsi/gomega/gbytes"
@@ -42,16 +46,41 @@ var _ = Describe("DockerDriverMounter", func() {
 				})
 
 				It("should be able to mount without warning", func() {
-					mountPath, err := dockerPlugin.Mount(logger, volumeId, map[string]interface{}{"volume_id": volumeId})
+					mountPath, err := dockerPlugin.Mount(context.Background(), logger, volumeId, map[string]interface{}{"volume_id": volumeId})
 					Expect(err).NotTo(HaveOccurred())
 					Expect(mountPath).NotTo(Equal(""))
 					Expect(logger.Buffer()).NotTo(gbytes.Say("Invalid or dangerous mountpath"))
 				})
 
+				It("should pass the caller's context to the driver", func() {
+					ctx, cancel := context.WithCancel(context.Background())
+					defer cancel()
+
+					_, err := dockerPlugin.Mount(ctx, logger, volumeId, map[string]interface{}{"volume_id": volumeId})
+					Expect(err).NotTo(HaveOccurred())
+
+					createEnv, _ := fakeDockerDriver.CreateArgsForCall(0)
+					Expect(createEnv.Context()).To(Equal(ctx))
+					mountEnv, _ := fakeDockerDriver.MountArgsForCall(0)
+					Expect(mountEnv.Context()).To(Equal(ctx))
+				})
+
+				It("should keep secrets in the mount options out of the logs, including the driver's", func() {
+					fakeDockerDriver.CreateStub = func(env dockerdriver.Env, request dockerdriver.CreateRequest) dockerdriver.ErrorResponse {
+						env.Logger().Info("creating", lager.Data{"request": request})
+						return dockerdriver.ErrorResponse{}
+					}
+
+					_, err := dockerPlugin.Mount(context.Background(), logger, volumeId, map[string]interface{}{"username": "alice", "password": "hunter2"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(logger.Buffer()).To(gbytes.Say("alice"))
+					Expect(logger.Buffer().Contents()).NotTo(ContainSubstring("hunter2"))
+				})
+
 				It("should not be able to mount if mount fails", func() {
 					mountResponse := dockerdriver.MountResponse{Err: "an error"}
 					fakeDockerDriver.MountReturns(mountResponse)
-					_, err := dockerPlugin.Mount(logger, volumeId, map[string]interface{}{"volume_id": volumeId})
+					_, err := dockerPlugin.Mount(context.Background(), logger, volumeId, map[string]interface{}{"volume_id": volumeId})
 					Expect(err).To(HaveOccurred())
 				})
 
@@ -63,7 +92,7 @@ var _ = Describe("DockerDriverMounter", func() {
 					})
 
 					JustBeforeEach(func() {
-						_, err = dockerPlugin.Mount(logger, volumeId, map[string]interface{}{"volume_id": volumeId})
+						_, err = dockerPlugin.Mount(context.Background(), logger, volumeId, map[string]interface{}{"volume_id": volumeId})
 					})
 
 					It("should return a warning in the log", func() {
@@ -84,7 +113,7 @@ var _ = Describe("DockerDriverMounter", func() {
 
 						mountResponse := dockerdriver.MountResponse{Err: errString}
 						fakeDockerDriver.MountReturns(mountResponse)
-						_, err = dockerPlugin.Mount(logger, volumeId, map[string]interface{}{"volume_id": volumeId})
+						_, err = dockerPlugin.Mount(context.Background(), logger, volumeId, map[string]interface{}{"volume_id": volumeId})
 					})
 
 					Context("with safe error msg", func() {
@@ -133,20 +162,78 @@ var _ = Describe("DockerDriverMounter", func() {
 				})
 
 			})
+
+			Context("with an enforced mountpoint policy", func() {
+				var (
+					fakeMetronClient *mfakes.FakeIngressClient
+					mountResponse    volman.MountResponse
+					err              error
+				)
+
+				BeforeEach(func() {
+					fakeMetronClient = new(mfakes.FakeIngressClient)
+					dockerPlugin = voldocker.NewVolmanPluginWithMountpointPolicy(fakeDockerDriver, volman.PluginSpec{}, fakeMetronClient, volman.DefaultMountpointPolicy())
+				})
+
+				JustBeforeEach(func() {
+					mountResponse, err = dockerPlugin.Mount(context.Background(), logger, volumeId, map[string]interface{}{"volume_id": volumeId})
+				})
+
+				Context("when the driver mounts under an allowed path", func() {
+					BeforeEach(func() {
+						fakeDockerDriver.MountReturns(dockerdriver.MountResponse{Mountpoint: "/var/vcap/data/mounts/" + volumeId})
+					})
+
+					It("should return the mountpoint", func() {
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mountResponse.Path).To(Equal("/var/vcap/data/mounts/" + volumeId))
+						Expect(fakeMetronClient.IncrementCounterCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when the driver mounts outside of the allowed paths", func() {
+					BeforeEach(func() {
+						fakeDockerDriver.MountReturns(dockerdriver.MountResponse{Mountpoint: "/var/vcap/data/../../../etc"})
+					})
+
+					It("should fail the mount and count the violation", func() {
+						Expect(err).To(BeAssignableToTypeOf(volman.MountpointPolicyError{}))
+						Expect(mountResponse.Path).To(BeEmpty())
+						Expect(fakeMetronClient.IncrementCounterCallCount()).To(Equal(1))
+						Expect(fakeMetronClient.IncrementCounterArgsForCall(0)).To(Equal("VolmanMountpointPolicyViolations"))
+						Expect(logger.Buffer()).To(gbytes.Say("mountpoint-policy-violation"))
+					})
+
+					It("should unmount the rejected volume", func() {
+						Expect(fakeDockerDriver.UnmountCallCount()).To(Equal(1))
+						_, unmountRequest := fakeDockerDriver.UnmountArgsForCall(0)
+						Expect(unmountRequest.Name).To(Equal(volumeId))
+					})
+				})
+			})
 		})
 	})
 
 	Describe("Unmount", func() {
 		It("should be able to unmount", func() {
-			err := dockerPlugin.Unmount(logger, volumeId)
+			err := dockerPlugin.Unmount(context.Background(), logger, volumeId)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(fakeDockerDriver.UnmountCallCount()).To(Equal(1))
 			Expect(fakeDockerDriver.RemoveCallCount()).To(Equal(0))
 		})
 
+		It("should pass the caller's context to the driver", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			Expect(dockerPlugin.Unmount(ctx, logger, volumeId)).To(Succeed())
+			env, _ := fakeDockerDriver.UnmountArgsForCall(0)
+			Expect(env.Context()).To(Equal(ctx))
+		})
+
 		It("should not be able to unmount when driver unmount fails", func() {
 			fakeDockerDriver.UnmountReturns(dockerdriver.ErrorResponse{Err: "unmount failure"})
-			err := dockerPlugin.Unmount(logger, volumeId)
+			err := dockerPlugin.Unmount(context.Background(), logger, volumeId)
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -160,7 +247,7 @@ var _ = Describe("DockerDriverMounter", func() {
 
 			JustBeforeEach(func() {
 				fakeDockerDriver.UnmountReturns(dockerdriver.ErrorResponse{Err: errString})
-				err = dockerPlugin.Unmount(logger, volumeId)
+				err = dockerPlugin.Unmount(context.Background(), logger, volumeId)
 			})
 
 			Context("with safe error msg", func() {
@@ -224,7 +311,7 @@ var _ = Describe("DockerDriverMounter", func() {
 		})
 
 		JustBeforeEach(func() {
-			volumes, err = dockerPlugin.ListVolumes(logger)
+			volumes, err = dockerPlugin.ListVolumes(context.Background(), logger)
 		})
 
 		It("should be able list volumes", func() {
@@ -247,4 +334,40 @@ var _ = Describe("DockerDriverMounter", func() {
 		})
 	})
 
+	Describe("Health", func() {
+		var health volman.PluginHealth
+
+		JustBeforeEach(func() {
+			health = dockerPlugin.(volman.HealthReporter).Health(context.Background(), logger)
+		})
+
+		It("should report a driver that has never been activated as unreachable", func() {
+			Expect(health).To(Equal(volman.PluginHealth{}))
+		})
+
+		Context("when the driver has been activated", func() {
+			BeforeEach(func() {
+				dockerPlugin.(*voldocker.DockerDriverPlugin).Activated(time.Unix(42, 0))
+				dockerPlugin.(*voldocker.DockerDriverPlugin).SetScope("local")
+			})
+
+			It("should report the recorded activation and scope without contacting the driver", func() {
+				Expect(health).To(Equal(volman.PluginHealth{Scope: "local", LastActivation: time.Unix(42, 0), Reachable: true}))
+				Expect(fakeDockerDriver.ActivateCallCount()).To(Equal(0))
+				Expect(fakeDockerDriver.CapabilitiesCallCount()).To(Equal(0))
+			})
+
+			Context("and has since failed to activate", func() {
+				BeforeEach(func() {
+					dockerPlugin.(*voldocker.DockerDriverPlugin).Unreachable()
+				})
+
+				It("should report the driver unreachable since its last activation", func() {
+					Expect(health.Reachable).To(BeFalse())
+					Expect(health.LastActivation).To(Equal(time.Unix(42, 0)))
+				})
+			})
+		})
+	})
+
 })
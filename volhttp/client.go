@@ -0,0 +1,240 @@
+package volhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+	"github.com/tedsuo/rata"
+)
+
+const (
+	// RequestIdHeader carries the id the client logs a call under, so that the server
+	// can log its handling of the call under the same id.
+	RequestIdHeader = "X-Volman-Request-Id"
+	// SessionHeader carries the lager session name of the caller.
+	SessionHeader = "X-Volman-Session"
+)
+
+type remoteClient struct {
+	httpClient *http.Client
+	reqGen     *rata.RequestGenerator
+}
+
+// NewRemoteClient returns a volman.Manager that talks to a volhttp server listening on
+// address, which is either a unix socket path prefixed with unix:// or a TCP host:port,
+// optionally prefixed with http://.
+func NewRemoteClient(address string) (volman.Manager, error) {
+	if strings.HasPrefix(address, unixScheme) {
+		socketPath := strings.TrimPrefix(address, unixScheme)
+		if socketPath == "" {
+			return nil, errors.New("missing socket path in address " + address)
+		}
+
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		}
+		return newRemoteClient(&http.Client{Transport: transport}, "http://volman"), nil
+	}
+
+	if strings.HasPrefix(address, "https://") {
+		return nil, errors.New("https is not supported: " + address)
+	}
+	if !strings.HasPrefix(address, "http://") {
+		address = "http://" + address
+	}
+	return newRemoteClient(&http.Client{}, address), nil
+}
+
+func newRemoteClient(httpClient *http.Client, baseURL string) *remoteClient {
+	return &remoteClient{
+		httpClient: httpClient,
+		reqGen:     rata.NewRequestGenerator(baseURL, Routes),
+	}
+}
+
+func (c *remoteClient) ListDrivers(ctx context.Context, logger lager.Logger) (volman.ListDriversResponse, error) {
+	requestId := newRequestId()
+	logger = logger.Session("remote-list-drivers", lager.Data{"request-id": requestId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	var response volman.ListDriversResponse
+	err := c.do(ctx, logger, requestId, ListDriversRoute, nil, nil, &response)
+	return response, err
+}
+
+func (c *remoteClient) ListDriversMatching(ctx context.Context, logger lager.Logger, filter volman.DriverFilter) (volman.ListDriversResponse, error) {
+	requestId := newRequestId()
+	logger = logger.Session("remote-list-drivers", lager.Data{"filter": filter, "request-id": requestId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	query := url.Values{}
+	if filter.NamePrefix != "" {
+		query.Set("namePrefix", filter.NamePrefix)
+	}
+	if filter.Transport != "" {
+		query.Set("transport", filter.Transport)
+	}
+
+	var response volman.ListDriversResponse
+	err := c.do(ctx, logger, requestId, ListDriversRoute, query, nil, &response)
+	return response, err
+}
+
+func (c *remoteClient) Mount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string, config map[string]interface{}) (volman.MountResponse, error) {
+	requestId := newRequestId()
+	logger = logger.Session("remote-mount", lager.Data{"driverId": driverId, "volumeId": volumeId, "containerId": containerId, "request-id": requestId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	request := volman.MountRequest{DriverId: driverId, VolumeId: volumeId, ContainerId: containerId, Config: config}
+
+	var response volman.MountResponse
+	err := c.do(ctx, logger, requestId, MountRoute, nil, request, &response)
+	return response, err
+}
+
+func (c *remoteClient) Unmount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) error {
+	requestId := newRequestId()
+	logger = logger.Session("remote-unmount", lager.Data{"driverId": driverId, "volumeId": volumeId, "containerId": containerId, "request-id": requestId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	request := volman.UnmountRequest{DriverId: driverId, VolumeId: volumeId, ContainerId: containerId}
+	return c.do(ctx, logger, requestId, UnmountRoute, nil, request, nil)
+}
+
+func (c *remoteClient) ListMounts(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) (volman.ListMountsResponse, error) {
+	requestId := newRequestId()
+	logger = logger.Session("remote-list-mounts", lager.Data{"driverId": driverId, "volumeId": volumeId, "containerId": containerId, "request-id": requestId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	var response volman.ListMountsResponse
+	err := c.do(ctx, logger, requestId, ListMountsRoute, mountQuery(driverId, volumeId, containerId), nil, &response)
+	return response, err
+}
+
+func (c *remoteClient) GetMount(ctx context.Context, logger lager.Logger, driverId string, volumeId string, containerId string) (volman.MountInfo, error) {
+	requestId := newRequestId()
+	logger = logger.Session("remote-get-mount", lager.Data{"driverId": driverId, "volumeId": volumeId, "containerId": containerId, "request-id": requestId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	var response volman.MountInfo
+	err := c.do(ctx, logger, requestId, GetMountRoute, mountQuery(driverId, volumeId, containerId), nil, &response)
+	return response, err
+}
+
+func mountQuery(driverId string, volumeId string, containerId string) url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{"driverId": driverId, "volumeId": volumeId, "containerId": containerId} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
+
+// do sends the request body to the named route and decodes a successful response into
+// response. Errors reported by the server are returned as a volman.SafeError when the
+// server marked them as safe, so callers see the same errors as with a local manager.
+func (c *remoteClient) do(ctx context.Context, logger lager.Logger, requestId string, route string, query url.Values, body interface{}, response interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		payloadBytes, err := json.Marshal(body)
+		if err != nil {
+			logger.Error("failed-marshalling-request", err)
+			return err
+		}
+		payload = bytes.NewReader(payloadBytes)
+	}
+
+	request, err := c.reqGen.CreateRequest(route, nil, payload)
+	if err != nil {
+		logger.Error("failed-creating-request", err)
+		return err
+	}
+
+	request = request.WithContext(ctx)
+	request.URL.RawQuery = query.Encode()
+	request.Header.Set(RequestIdHeader, requestId)
+	request.Header.Set(SessionHeader, logger.SessionName())
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		logger.Error("failed-sending-request", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	responseBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error("failed-reading-response", err)
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := responseError(resp.StatusCode, responseBytes)
+		logger.Error("failed-response", err, lager.Data{"status": resp.StatusCode})
+		return err
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(responseBytes, response); err != nil {
+		logger.Error("failed-parsing-response", err)
+		return err
+	}
+	return nil
+}
+
+func responseError(statusCode int, body []byte) error {
+	var errorResponse ErrorResponse
+	if err := json.Unmarshal(body, &errorResponse); err != nil || errorResponse.Err == "" {
+		return fmt.Errorf("unexpected status %d: %s", statusCode, strings.TrimSpace(string(body)))
+	}
+
+	if statusCode == http.StatusNotFound {
+		return volman.ErrMountNotFound
+	}
+	if errorResponse.SafeDescription != "" {
+		safeErr := volman.SafeError{SafeDescription: errorResponse.SafeDescription}
+		switch statusCode {
+		case http.StatusServiceUnavailable:
+			return volman.DriverUnavailableError{SafeError: safeErr}
+		case http.StatusTooManyRequests:
+			return volman.DriverBusyError{SafeError: safeErr}
+		}
+		return safeErr
+	}
+	return errors.New(errorResponse.Err)
+}
+
+func newRequestId() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,251 @@
+package volhttp_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/volhttp"
+	"code.cloudfoundry.org/volman/volmanfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	ginkgomon "github.com/tedsuo/ifrit/ginkgomon_v2"
+)
+
+var _ = Describe("RemoteClient", func() {
+	var (
+		logger       *lagertest.TestLogger
+		serverLogger *lagertest.TestLogger
+		fakeManager  *volmanfakes.FakeManager
+		server       *httptest.Server
+		client       volman.Manager
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("volhttp-client")
+		serverLogger = lagertest.NewTestLogger("volhttp-server")
+		fakeManager = new(volmanfakes.FakeManager)
+
+		handler, err := volhttp.NewHandler(serverLogger, fakeManager)
+		Expect(err).NotTo(HaveOccurred())
+		server = httptest.NewServer(handler)
+
+		client, err = volhttp.NewRemoteClient(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	logData := func(sink *lagertest.TestLogger, message string) lager.Data {
+		for _, log := range sink.Logs() {
+			if log.Message == message {
+				return log.Data
+			}
+		}
+		Fail("no log message " + message)
+		return nil
+	}
+
+	Describe("ListDrivers", func() {
+		It("returns the drivers of the remote manager", func() {
+			fakeManager.ListDriversReturns(volman.ListDriversResponse{Drivers: []volman.InfoResponse{{Name: "some-driver"}}}, nil)
+
+			response, err := client.ListDrivers(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Drivers).To(ConsistOf(volman.InfoResponse{Name: "some-driver"}))
+		})
+
+		It("passes the filter to the remote manager", func() {
+			fakeManager.ListDriversMatchingReturns(volman.ListDriversResponse{Drivers: []volman.InfoResponse{{Name: "nfsdriver"}}}, nil)
+
+			response, err := client.ListDriversMatching(context.Background(), logger, volman.DriverFilter{NamePrefix: "nfs"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Drivers).To(ConsistOf(volman.InfoResponse{Name: "nfsdriver"}))
+
+			_, _, filter := fakeManager.ListDriversMatchingArgsForCall(0)
+			Expect(filter).To(Equal(volman.DriverFilter{NamePrefix: "nfs"}))
+		})
+
+		It("returns remote errors", func() {
+			fakeManager.ListDriversReturns(volman.ListDriversResponse{}, errors.New("badness"))
+
+			_, err := client.ListDrivers(context.Background(), logger)
+			Expect(err).To(MatchError("badness"))
+			Expect(err).NotTo(BeAssignableToTypeOf(volman.SafeError{}))
+		})
+	})
+
+	Describe("Mount", func() {
+		It("mounts through the remote manager", func() {
+			fakeManager.MountReturns(volman.MountResponse{Path: "/some/path"}, nil)
+
+			response, err := client.Mount(context.Background(), logger, "some-driver", "some-volume", "some-container", map[string]interface{}{"source": "some-source"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Path).To(Equal("/some/path"))
+
+			_, _, driverId, volumeId, containerId, config := fakeManager.MountArgsForCall(0)
+			Expect(driverId).To(Equal("some-driver"))
+			Expect(volumeId).To(Equal("some-volume"))
+			Expect(containerId).To(Equal("some-container"))
+			Expect(config).To(Equal(map[string]interface{}{"source": "some-source"}))
+		})
+
+		It("abandons the call when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := client.Mount(ctx, logger, "some-driver", "some-volume", "some-container", nil)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(fakeManager.MountCallCount()).To(Equal(0))
+		})
+
+		It("preserves SafeErrors", func() {
+			fakeManager.MountReturns(volman.MountResponse{}, volman.SafeError{SafeDescription: "safe-badness"})
+
+			_, err := client.Mount(context.Background(), logger, "some-driver", "some-volume", "some-container", nil)
+			Expect(err).To(Equal(volman.SafeError{SafeDescription: "safe-badness"}))
+		})
+
+		It("preserves the errors of drivers that are unavailable or busy", func() {
+			unavailable := volman.DriverUnavailableError{SafeError: volman.SafeError{SafeDescription: "unavailable"}}
+			fakeManager.MountReturns(volman.MountResponse{}, unavailable)
+			_, err := client.Mount(context.Background(), logger, "some-driver", "some-volume", "some-container", nil)
+			Expect(err).To(Equal(unavailable))
+
+			busy := volman.DriverBusyError{SafeError: volman.SafeError{SafeDescription: "busy"}}
+			fakeManager.MountReturns(volman.MountResponse{}, busy)
+			_, err = client.Mount(context.Background(), logger, "some-driver", "some-volume", "some-container", nil)
+			Expect(err).To(Equal(busy))
+
+			var safeErr volman.SafeError
+			Expect(errors.As(err, &safeErr)).To(BeTrue())
+		})
+
+		It("sends the request id and session it logs under to the server", func() {
+			_, err := client.Mount(context.Background(), logger, "some-driver", "some-volume", "some-container", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			requestId := logData(logger, "volhttp-client.remote-mount.end")["request-id"]
+			Expect(requestId).NotTo(BeEmpty())
+
+			serverData := logData(serverLogger, "volhttp-server.server.handle-mount.start")
+			Expect(serverData["request-id"]).To(Equal(requestId))
+			Expect(serverData["remote-session"]).To(Equal("volhttp-client.remote-mount"))
+		})
+	})
+
+	Describe("Unmount", func() {
+		It("unmounts through the remote manager", func() {
+			Expect(client.Unmount(context.Background(), logger, "some-driver", "some-volume", "some-container")).To(Succeed())
+
+			_, _, driverId, volumeId, containerId := fakeManager.UnmountArgsForCall(0)
+			Expect(driverId).To(Equal("some-driver"))
+			Expect(volumeId).To(Equal("some-volume"))
+			Expect(containerId).To(Equal("some-container"))
+		})
+
+		It("preserves SafeErrors", func() {
+			fakeManager.UnmountReturns(volman.SafeError{SafeDescription: "safe-badness"})
+
+			err := client.Unmount(context.Background(), logger, "some-driver", "some-volume", "some-container")
+			Expect(err).To(Equal(volman.SafeError{SafeDescription: "safe-badness"}))
+		})
+	})
+
+	Describe("ListMounts", func() {
+		It("lists the mounts of the remote manager", func() {
+			mounts := []volman.MountInfo{{DriverId: "some-driver", VolumeId: "some-volume", ContainerId: "some-container", Path: "/some/path"}}
+			fakeManager.ListMountsReturns(volman.ListMountsResponse{Mounts: mounts}, nil)
+
+			response, err := client.ListMounts(context.Background(), logger, "some-driver", "", "some-container")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Mounts).To(Equal(mounts))
+
+			_, _, driverId, volumeId, containerId := fakeManager.ListMountsArgsForCall(0)
+			Expect(driverId).To(Equal("some-driver"))
+			Expect(volumeId).To(BeEmpty())
+			Expect(containerId).To(Equal("some-container"))
+		})
+	})
+
+	Describe("GetMount", func() {
+		It("gets the mount from the remote manager", func() {
+			fakeManager.GetMountReturns(volman.MountInfo{DriverId: "some-driver", VolumeId: "some-volume", Path: "/some/path"}, nil)
+
+			mount, err := client.GetMount(context.Background(), logger, "some-driver", "some-volume", "some-container")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mount.Path).To(Equal("/some/path"))
+		})
+
+		It("returns ErrMountNotFound when the mount is not found", func() {
+			fakeManager.GetMountReturns(volman.MountInfo{}, volman.ErrMountNotFound)
+
+			_, err := client.GetMount(context.Background(), logger, "some-driver", "some-volume", "some-container")
+			Expect(err).To(MatchError(volman.ErrMountNotFound))
+		})
+	})
+
+	Context("when the server does not speak volhttp", func() {
+		BeforeEach(func() {
+			server.Close()
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte("bad gateway"))
+			}))
+
+			var err error
+			client, err = volhttp.NewRemoteClient(strings.TrimPrefix(server.URL, "http://"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the status and body", func() {
+			_, err := client.ListDrivers(context.Background(), logger)
+			Expect(err).To(MatchError(ContainSubstring("502")))
+			Expect(err).To(MatchError(ContainSubstring("bad gateway")))
+		})
+	})
+
+	Context("when the server listens on a unix socket", func() {
+		var (
+			socketDir string
+			process   ifrit.Process
+		)
+
+		BeforeEach(func() {
+			var err error
+			socketDir, err = os.MkdirTemp("", "volhttp")
+			Expect(err).NotTo(HaveOccurred())
+			address := "unix://" + filepath.Join(socketDir, "volman.sock")
+
+			runner, err := volhttp.NewServer(serverLogger, fakeManager, address)
+			Expect(err).NotTo(HaveOccurred())
+			process = ginkgomon.Invoke(runner)
+
+			client, err = volhttp.NewRemoteClient(address)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			ginkgomon.Kill(process)
+			os.RemoveAll(socketDir)
+		})
+
+		It("talks to the server over the socket", func() {
+			fakeManager.ListDriversReturns(volman.ListDriversResponse{Drivers: []volman.InfoResponse{{Name: "some-driver"}}}, nil)
+
+			response, err := client.ListDrivers(context.Background(), logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Drivers).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,19 @@
+package volhttp
+
+import "github.com/tedsuo/rata"
+
+const (
+	ListDriversRoute = "drivers"
+	MountRoute       = "mount"
+	UnmountRoute     = "unmount"
+	ListMountsRoute  = "mounts"
+	GetMountRoute    = "get-mount"
+)
+
+var Routes = rata.Routes{
+	{Path: "/drivers", Method: "GET", Name: ListDriversRoute},
+	{Path: "/mount", Method: "POST", Name: MountRoute},
+	{Path: "/unmount", Method: "POST", Name: UnmountRoute},
+	{Path: "/mounts", Method: "GET", Name: ListMountsRoute},
+	{Path: "/mount", Method: "GET", Name: GetMountRoute},
+}
@@ -0,0 +1,299 @@
+package volhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+	"github.com/tedsuo/rata"
+)
+
+const unixScheme = "unix://"
+
+// ErrorResponse is the body of every unsuccessful response. SafeDescription is only set
+// when the manager returned a volman.SafeError, which may be shown to end users.
+type ErrorResponse struct {
+	Err             string `json:"err"`
+	SafeDescription string `json:"safeDescription,omitempty"`
+}
+
+// NewServer returns a runner serving the manager on listenAddress, which is either a
+// TCP host:port or a unix socket path prefixed with unix://. The server has no
+// authentication, so TCP addresses must be loopback addresses, and an address without
+// a host binds to 127.0.0.1.
+func NewServer(logger lager.Logger, manager volman.Manager, listenAddress string) (ifrit.Runner, error) {
+	logger = logger.Session("new-server", lager.Data{"listen-address": listenAddress})
+
+	handler, err := NewHandler(logger, manager)
+	if err != nil {
+		logger.Error("failed-creating-handler", err)
+		return nil, err
+	}
+
+	if strings.HasPrefix(listenAddress, unixScheme) {
+		socketPath := strings.TrimPrefix(listenAddress, unixScheme)
+		if err := removeStaleSocket(socketPath); err != nil {
+			logger.Error("failed-removing-stale-socket", err)
+			return nil, err
+		}
+		return http_server.NewUnixServer(socketPath, handler), nil
+	}
+
+	listenAddress, err = loopbackAddress(listenAddress)
+	if err != nil {
+		logger.Error("invalid-listen-address", err)
+		return nil, err
+	}
+	return http_server.New(listenAddress, handler), nil
+}
+
+// loopbackAddress returns listenAddress with 127.0.0.1 as its host when it has none, and
+// fails if its host is not a loopback address.
+func loopbackAddress(listenAddress string) (string, error) {
+	host, port, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case host == "":
+		host = "127.0.0.1"
+	case host == "localhost":
+	default:
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return "", fmt.Errorf("refusing to serve on non-loopback address %s without authentication, listen on a unix socket instead", listenAddress)
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func NewHandler(logger lager.Logger, manager volman.Manager) (http.Handler, error) {
+	logger = logger.Session("server")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	handlers := rata.Handlers{
+		ListDriversRoute: newListDriversHandler(logger, manager),
+		MountRoute:       newMountHandler(logger, manager),
+		UnmountRoute:     newUnmountHandler(logger, manager),
+		ListMountsRoute:  newListMountsHandler(logger, manager),
+		GetMountRoute:    newGetMountHandler(logger, manager),
+	}
+
+	return rata.NewRouter(Routes, handlers)
+}
+
+func newListDriversHandler(logger lager.Logger, manager volman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := requestLogger(logger, w, req, "handle-list-drivers")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		query := req.URL.Query()
+		filter := volman.DriverFilter{NamePrefix: query.Get("namePrefix"), Transport: query.Get("transport")}
+
+		var response volman.ListDriversResponse
+		var err error
+		if filter == (volman.DriverFilter{}) {
+			response, err = manager.ListDrivers(req.Context(), logger)
+		} else {
+			response, err = manager.ListDriversMatching(req.Context(), logger, filter)
+		}
+		if err != nil {
+			writeError(logger, w, err)
+			return
+		}
+
+		writeJSONResponse(logger, w, http.StatusOK, response)
+	}
+}
+
+func newMountHandler(logger lager.Logger, manager volman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := requestLogger(logger, w, req, "handle-mount")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		var mountRequest volman.MountRequest
+		if err := json.NewDecoder(req.Body).Decode(&mountRequest); err != nil {
+			logger.Error("failed-parsing-mount-request", err)
+			writeJSONResponse(logger, w, http.StatusBadRequest, ErrorResponse{Err: err.Error()})
+			return
+		}
+
+		if mountRequest.DriverId == "" || mountRequest.VolumeId == "" {
+			err := errors.New("driverId and volumeId are required")
+			logger.Error("invalid-mount-request", err)
+			writeJSONResponse(logger, w, http.StatusBadRequest, ErrorResponse{Err: err.Error()})
+			return
+		}
+
+		response, err := manager.Mount(req.Context(), logger, mountRequest.DriverId, mountRequest.VolumeId, mountRequest.ContainerId, mountRequest.Config)
+		if err != nil {
+			writeError(logger, w, err)
+			return
+		}
+
+		writeJSONResponse(logger, w, http.StatusOK, response)
+	}
+}
+
+func newUnmountHandler(logger lager.Logger, manager volman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := requestLogger(logger, w, req, "handle-unmount")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		var unmountRequest volman.UnmountRequest
+		if err := json.NewDecoder(req.Body).Decode(&unmountRequest); err != nil {
+			logger.Error("failed-parsing-unmount-request", err)
+			writeJSONResponse(logger, w, http.StatusBadRequest, ErrorResponse{Err: err.Error()})
+			return
+		}
+
+		if unmountRequest.DriverId == "" || unmountRequest.VolumeId == "" {
+			err := errors.New("driverId and volumeId are required")
+			logger.Error("invalid-unmount-request", err)
+			writeJSONResponse(logger, w, http.StatusBadRequest, ErrorResponse{Err: err.Error()})
+			return
+		}
+
+		err := manager.Unmount(req.Context(), logger, unmountRequest.DriverId, unmountRequest.VolumeId, unmountRequest.ContainerId)
+		if err != nil {
+			writeError(logger, w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func newListMountsHandler(logger lager.Logger, manager volman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := requestLogger(logger, w, req, "handle-list-mounts")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		query := req.URL.Query()
+		response, err := manager.ListMounts(req.Context(), logger, query.Get("driverId"), query.Get("volumeId"), query.Get("containerId"))
+		if err != nil {
+			writeError(logger, w, err)
+			return
+		}
+
+		writeJSONResponse(logger, w, http.StatusOK, response)
+	}
+}
+
+func newGetMountHandler(logger lager.Logger, manager volman.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := requestLogger(logger, w, req, "handle-get-mount")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		query := req.URL.Query()
+		if query.Get("driverId") == "" || query.Get("volumeId") == "" {
+			err := errors.New("driverId and volumeId are required")
+			logger.Error("invalid-get-mount-request", err)
+			writeJSONResponse(logger, w, http.StatusBadRequest, ErrorResponse{Err: err.Error()})
+			return
+		}
+
+		response, err := manager.GetMount(req.Context(), logger, query.Get("driverId"), query.Get("volumeId"), query.Get("containerId"))
+		if errors.Is(err, volman.ErrMountNotFound) {
+			writeJSONResponse(logger, w, http.StatusNotFound, ErrorResponse{Err: err.Error()})
+			return
+		}
+		if err != nil {
+			writeError(logger, w, err)
+			return
+		}
+
+		writeJSONResponse(logger, w, http.StatusOK, response)
+	}
+}
+
+// requestLogger starts a session for handling req, tagged with the caller's request id
+// and session name when the caller sent them. The request id is echoed in the response.
+func requestLogger(logger lager.Logger, w http.ResponseWriter, req *http.Request, session string) lager.Logger {
+	requestId := req.Header.Get(RequestIdHeader)
+	if requestId == "" {
+		requestId = newRequestId()
+	}
+	w.Header().Set(RequestIdHeader, requestId)
+
+	data := lager.Data{"request-id": requestId}
+	if remoteSession := req.Header.Get(SessionHeader); remoteSession != "" {
+		data["remote-session"] = remoteSession
+	}
+	return logger.Session(session, data)
+}
+
+// writeError responds with the error of the manager. Errors meant for the user, and
+// requests for drivers that are not registered, are client errors, except for drivers
+// that are unavailable or busy for now, which the client may try again later.
+func writeError(logger lager.Logger, w http.ResponseWriter, err error) {
+	response := ErrorResponse{Err: err.Error()}
+	statusCode := http.StatusInternalServerError
+
+	var safeErr volman.SafeError
+	var driverNotFoundErr volman.DriverNotFoundError
+	var unavailableErr volman.DriverUnavailableError
+	var busyErr volman.DriverBusyError
+	switch {
+	case errors.As(err, &unavailableErr):
+		response.SafeDescription = unavailableErr.SafeDescription
+		statusCode = http.StatusServiceUnavailable
+	case errors.As(err, &busyErr):
+		response.SafeDescription = busyErr.SafeDescription
+		statusCode = http.StatusTooManyRequests
+	case errors.As(err, &safeErr):
+		response.SafeDescription = safeErr.SafeDescription
+		statusCode = http.StatusUnprocessableEntity
+	case errors.As(err, &driverNotFoundErr):
+		statusCode = http.StatusBadRequest
+	}
+
+	writeJSONResponse(logger, w, statusCode, response)
+}
+
+func writeJSONResponse(logger lager.Logger, w http.ResponseWriter, statusCode int, jsonObj interface{}) {
+	jsonBytes, err := json.Marshal(jsonObj)
+	if err != nil {
+		logger.Error("failed-marshalling-response", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(jsonBytes); err != nil {
+		logger.Error("failed-writing-response", err)
+	}
+}
+
+// removeStaleSocket deletes a unix socket left behind by a previous process, so that
+// the server can bind to the same path again.
+func removeStaleSocket(socketPath string) error {
+	info, err := os.Lstat(socketPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return errors.New("refusing to replace non-socket file " + socketPath)
+	}
+	return os.Remove(socketPath)
+}
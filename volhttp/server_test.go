@@ -0,0 +1,338 @@
+package volhttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/volhttp"
+	"code.cloudfoundry.org/volman/volmanfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	ginkgomon "github.com/tedsuo/ifrit/ginkgomon_v2"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		logger      *lagertest.TestLogger
+		fakeManager *volmanfakes.FakeManager
+		handler     http.Handler
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("volhttp-server")
+		fakeManager = new(volmanfakes.FakeManager)
+
+		var err error
+		handler, err = volhttp.NewHandler(logger, fakeManager)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	serve := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		var reader *bytes.Reader
+		switch b := body.(type) {
+		case nil:
+			reader = bytes.NewReader(nil)
+		case string:
+			reader = bytes.NewReader([]byte(b))
+		default:
+			payload, err := json.Marshal(b)
+			Expect(err).NotTo(HaveOccurred())
+			reader = bytes.NewReader(payload)
+		}
+
+		req, err := http.NewRequest(method, path, reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	decodeError := func(recorder *httptest.ResponseRecorder) volhttp.ErrorResponse {
+		var response volhttp.ErrorResponse
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		return response
+	}
+
+	Describe("ListDrivers", func() {
+		It("returns the drivers", func() {
+			fakeManager.ListDriversReturns(volman.ListDriversResponse{Drivers: []volman.InfoResponse{{Name: "some-driver"}}}, nil)
+
+			recorder := serve("GET", "/drivers", nil)
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response volman.ListDriversResponse
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.Drivers).To(ConsistOf(volman.InfoResponse{Name: "some-driver"}))
+		})
+
+		It("filters the drivers when asked to", func() {
+			recorder := serve("GET", "/drivers?namePrefix=nfs&transport=unix", nil)
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			Expect(fakeManager.ListDriversCallCount()).To(Equal(0))
+			_, _, filter := fakeManager.ListDriversMatchingArgsForCall(0)
+			Expect(filter).To(Equal(volman.DriverFilter{NamePrefix: "nfs", Transport: "unix"}))
+		})
+
+		It("returns a 500 when the manager fails", func() {
+			fakeManager.ListDriversReturns(volman.ListDriversResponse{}, errors.New("badness"))
+
+			recorder := serve("GET", "/drivers", nil)
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(decodeError(recorder)).To(Equal(volhttp.ErrorResponse{Err: "badness"}))
+		})
+	})
+
+	Describe("Mount", func() {
+		It("mounts the volume and returns its path", func() {
+			fakeManager.MountReturns(volman.MountResponse{Path: "/some/path"}, nil)
+
+			recorder := serve("POST", "/mount", volman.MountRequest{
+				DriverId:    "some-driver",
+				VolumeId:    "some-volume",
+				ContainerId: "some-container",
+				Config:      map[string]interface{}{"source": "some-source"},
+			})
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response volman.MountResponse
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.Path).To(Equal("/some/path"))
+
+			Expect(fakeManager.MountCallCount()).To(Equal(1))
+			_, _, driverId, volumeId, containerId, config := fakeManager.MountArgsForCall(0)
+			Expect(driverId).To(Equal("some-driver"))
+			Expect(volumeId).To(Equal("some-volume"))
+			Expect(containerId).To(Equal("some-container"))
+			Expect(config).To(Equal(map[string]interface{}{"source": "some-source"}))
+		})
+
+		It("passes the safe description of a SafeError through", func() {
+			fakeManager.MountReturns(volman.MountResponse{}, volman.SafeError{SafeDescription: "safe-badness"})
+
+			recorder := serve("POST", "/mount", volman.MountRequest{DriverId: "some-driver", VolumeId: "some-volume"})
+			Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeError(recorder)).To(Equal(volhttp.ErrorResponse{Err: "safe-badness", SafeDescription: "safe-badness"}))
+		})
+
+		It("finds a SafeError that is wrapped", func() {
+			fakeManager.MountReturns(volman.MountResponse{}, fmt.Errorf("mounting: %w", volman.SafeError{SafeDescription: "safe-badness"}))
+
+			recorder := serve("POST", "/mount", volman.MountRequest{DriverId: "some-driver", VolumeId: "some-volume"})
+			Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(decodeError(recorder).SafeDescription).To(Equal("safe-badness"))
+		})
+
+		It("returns a 503 when the driver is unavailable for now", func() {
+			fakeManager.MountReturns(volman.MountResponse{}, volman.DriverUnavailableError{SafeError: volman.SafeError{SafeDescription: "unavailable"}})
+
+			recorder := serve("POST", "/mount", volman.MountRequest{DriverId: "some-driver", VolumeId: "some-volume"})
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(decodeError(recorder).SafeDescription).To(Equal("unavailable"))
+		})
+
+		It("returns a 429 when the driver has too many mounts in progress", func() {
+			fakeManager.MountReturns(volman.MountResponse{}, volman.DriverBusyError{SafeError: volman.SafeError{SafeDescription: "busy"}})
+
+			recorder := serve("POST", "/mount", volman.MountRequest{DriverId: "some-driver", VolumeId: "some-volume"})
+			Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(decodeError(recorder).SafeDescription).To(Equal("busy"))
+		})
+
+		It("returns a 400 when the driver is not registered", func() {
+			fakeManager.MountReturns(volman.MountResponse{}, volman.DriverNotFoundError{DriverId: "unknown-driver"})
+
+			recorder := serve("POST", "/mount", volman.MountRequest{DriverId: "unknown-driver", VolumeId: "some-volume"})
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(decodeError(recorder).Err).To(Equal("Plugin 'unknown-driver' not found in list of known plugins"))
+		})
+
+		It("returns a 400 when the body is not json", func() {
+			recorder := serve("POST", "/mount", "not json")
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeManager.MountCallCount()).To(Equal(0))
+		})
+
+		It("returns a 400 when the volume is not specified", func() {
+			recorder := serve("POST", "/mount", volman.MountRequest{DriverId: "some-driver"})
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(decodeError(recorder).Err).To(ContainSubstring("required"))
+			Expect(fakeManager.MountCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Unmount", func() {
+		It("unmounts the volume", func() {
+			recorder := serve("POST", "/unmount", volman.UnmountRequest{DriverId: "some-driver", VolumeId: "some-volume", ContainerId: "some-container"})
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			Expect(fakeManager.UnmountCallCount()).To(Equal(1))
+			_, _, driverId, volumeId, containerId := fakeManager.UnmountArgsForCall(0)
+			Expect(driverId).To(Equal("some-driver"))
+			Expect(volumeId).To(Equal("some-volume"))
+			Expect(containerId).To(Equal("some-container"))
+		})
+
+		It("returns a 500 when the manager fails", func() {
+			fakeManager.UnmountReturns(errors.New("badness"))
+
+			recorder := serve("POST", "/unmount", volman.UnmountRequest{DriverId: "some-driver", VolumeId: "some-volume"})
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(decodeError(recorder)).To(Equal(volhttp.ErrorResponse{Err: "badness"}))
+		})
+
+		It("returns a 400 when the driver is not specified", func() {
+			recorder := serve("POST", "/unmount", volman.UnmountRequest{VolumeId: "some-volume"})
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeManager.UnmountCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("ListMounts", func() {
+		It("passes the ids in the query to the manager", func() {
+			fakeManager.ListMountsReturns(volman.ListMountsResponse{Mounts: []volman.MountInfo{{DriverId: "some-driver", VolumeId: "some-volume", ContainerId: "some-container"}}}, nil)
+
+			recorder := serve("GET", "/mounts?containerId=some-container", nil)
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response volman.ListMountsResponse
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.Mounts).To(HaveLen(1))
+
+			_, _, driverId, volumeId, containerId := fakeManager.ListMountsArgsForCall(0)
+			Expect(driverId).To(BeEmpty())
+			Expect(volumeId).To(BeEmpty())
+			Expect(containerId).To(Equal("some-container"))
+		})
+	})
+
+	Describe("GetMount", func() {
+		It("returns the mount", func() {
+			fakeManager.GetMountReturns(volman.MountInfo{DriverId: "some-driver", VolumeId: "some-volume", ContainerId: "some-container", Path: "/some/path"}, nil)
+
+			recorder := serve("GET", "/mount?driverId=some-driver&volumeId=some-volume&containerId=some-container", nil)
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var response volman.MountInfo
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.Path).To(Equal("/some/path"))
+		})
+
+		It("returns a 404 when the mount is not found", func() {
+			fakeManager.GetMountReturns(volman.MountInfo{}, volman.ErrMountNotFound)
+
+			recorder := serve("GET", "/mount?driverId=some-driver&volumeId=some-volume", nil)
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("returns a 400 when the volume is not specified", func() {
+			recorder := serve("GET", "/mount?driverId=some-driver", nil)
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeManager.GetMountCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("NewServer", func() {
+		var (
+			socketDir  string
+			socketPath string
+			process    ifrit.Process
+		)
+
+		BeforeEach(func() {
+			var err error
+			socketDir, err = os.MkdirTemp("", "volhttp")
+			Expect(err).NotTo(HaveOccurred())
+			socketPath = filepath.Join(socketDir, "volman.sock")
+
+			fakeManager.ListDriversReturns(volman.ListDriversResponse{Drivers: []volman.InfoResponse{{Name: "some-driver"}}}, nil)
+		})
+
+		AfterEach(func() {
+			if process != nil {
+				ginkgomon.Kill(process)
+			}
+			os.RemoveAll(socketDir)
+		})
+
+		unixClient := func() *http.Client {
+			return &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+					},
+				},
+			}
+		}
+
+		It("serves on a unix socket", func() {
+			runner, err := volhttp.NewServer(logger, fakeManager, "unix://"+socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			process = ginkgomon.Invoke(runner)
+
+			resp, err := unixClient().Get("http://volman/drivers")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("replaces a stale socket", func() {
+			listener, err := net.Listen("unix", socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			listener.(*net.UnixListener).SetUnlinkOnClose(false)
+			Expect(listener.Close()).To(Succeed())
+
+			runner, err := volhttp.NewServer(logger, fakeManager, "unix://"+socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			process = ginkgomon.Invoke(runner)
+
+			resp, err := unixClient().Get("http://volman/drivers")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("serves on loopback when the address has no host", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			port := listener.Addr().(*net.TCPAddr).Port
+			Expect(listener.Close()).To(Succeed())
+
+			runner, err := volhttp.NewServer(logger, fakeManager, fmt.Sprintf(":%d", port))
+			Expect(err).NotTo(HaveOccurred())
+			process = ginkgomon.Invoke(runner)
+
+			resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/drivers", port))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("refuses to serve on a TCP address that is not loopback", func() {
+			_, err := volhttp.NewServer(logger, fakeManager, "0.0.0.0:8750")
+			Expect(err).To(MatchError(ContainSubstring("non-loopback address")))
+
+			_, err = volhttp.NewServer(logger, fakeManager, "volman.example.com:8750")
+			Expect(err).To(MatchError(ContainSubstring("non-loopback address")))
+		})
+
+		It("refuses to replace a file that is not a socket", func() {
+			Expect(os.WriteFile(socketPath, []byte("precious"), 0600)).To(Succeed())
+
+			_, err := volhttp.NewServer(logger, fakeManager, "unix://"+socketPath)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,306 @@
+package vollocal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	loggingclient "code.cloudfoundry.org/diego-logging-client"
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+)
+
+const (
+	volmanCircuitOpenedCounter     = "VolmanCircuitBreakerOpened"
+	volmanCircuitHalfOpenedCounter = "VolmanCircuitBreakerHalfOpened"
+	volmanCircuitClosedCounter     = "VolmanCircuitBreakerClosed"
+)
+
+// CircuitBreakerPolicy opens a driver's circuit after FailureThreshold consecutive failed
+// calls. Calls then fail fast until OpenDuration has passed, after which a single trial
+// call is let through to decide whether to close the circuit again. A FailureThreshold of
+// 0 disables the breaker.
+type CircuitBreakerPolicy struct {
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+type circuitState string
+
+const (
+	circuitClosed   circuitState = "closed"
+	circuitOpen     circuitState = "open"
+	circuitHalfOpen circuitState = "half-open"
+)
+
+// CircuitBreakerRegistry wraps the plugins of a registry in a circuit breaker per driver.
+// Breakers outlive re-discovery, so a driver that is re-registered keeps its state. When
+// the wrapped registry reports its changes, the breakers of drivers that are removed are
+// forgotten, so that a driver registered again starts afresh.
+type CircuitBreakerRegistry struct {
+	volman.PluginRegistry
+
+	metronClient loggingclient.IngressClient
+	clock        clock.Clock
+	config       PerDriver[CircuitBreakerPolicy]
+
+	mutex    sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func NewCircuitBreakerRegistry(registry volman.PluginRegistry, metronClient loggingclient.IngressClient, clock clock.Clock, config PerDriver[CircuitBreakerPolicy]) *CircuitBreakerRegistry {
+	breakers := &CircuitBreakerRegistry{
+		PluginRegistry: registry,
+		metronClient:   metronClient,
+		clock:          clock,
+		config:         config,
+		breakers:       map[string]*circuitBreaker{},
+	}
+	subscribeTo(registry, func(event volman.RegistryEvent) {
+		if event.Type == volman.RegistryEventRemoved {
+			breakers.forget(event.DriverId)
+		}
+	})
+	return breakers
+}
+
+func (r *CircuitBreakerRegistry) Plugin(id string) (volman.Plugin, bool) {
+	plugin, found := r.PluginRegistry.Plugin(id)
+	if !found {
+		return nil, false
+	}
+	return r.wrap(id, plugin), true
+}
+
+func (r *CircuitBreakerRegistry) Plugins() map[string]volman.Plugin {
+	plugins := map[string]volman.Plugin{}
+	for id, plugin := range r.PluginRegistry.Plugins() {
+		plugins[id] = r.wrap(id, plugin)
+	}
+	return plugins
+}
+
+// AllowActivation reports whether discovery should activate the driver. It is false
+// while the driver's circuit is open and not yet due to be tried again.
+func (r *CircuitBreakerRegistry) AllowActivation(driverId string) bool {
+	r.mutex.Lock()
+	breaker, found := r.breakers[driverId]
+	r.mutex.Unlock()
+
+	return !found || !breaker.failingFast()
+}
+
+// Subscribe passes subscriptions on to the wrapped registry, if it reports its changes.
+func (r *CircuitBreakerRegistry) Subscribe(handle func(volman.RegistryEvent)) func() {
+	return subscribeTo(r.PluginRegistry, handle)
+}
+
+// Draining returns a draining driver of the wrapped registry, if it drains them.
+func (r *CircuitBreakerRegistry) Draining(id string) (volman.Plugin, bool) {
+	plugin, found := drainingPluginOf(r.PluginRegistry, id)
+	if !found {
+		return nil, false
+	}
+	return r.wrap(id, plugin), true
+}
+
+// Drain marks a driver of the wrapped registry for removal, if it drains them.
+func (r *CircuitBreakerRegistry) Drain(id string) bool {
+	if draining, ok := r.PluginRegistry.(volman.DrainingRegistry); ok {
+		return draining.Drain(id)
+	}
+	return false
+}
+
+// Drained forgets a draining driver of the wrapped registry.
+func (r *CircuitBreakerRegistry) Drained(id string) {
+	if draining, ok := r.PluginRegistry.(volman.DrainingRegistry); ok {
+		draining.Drained(id)
+	}
+	r.forget(id)
+}
+
+// forget drops the breaker of a driver, unless the driver is registered or draining. It
+// may be again by the time a removal is reported.
+func (r *CircuitBreakerRegistry) forget(id string) {
+	if _, found := r.PluginRegistry.Plugin(id); found {
+		return
+	}
+	if _, found := drainingPluginOf(r.PluginRegistry, id); found {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.breakers, id)
+}
+
+func (r *CircuitBreakerRegistry) wrap(id string, plugin volman.Plugin) volman.Plugin {
+	policy := r.config.For(id)
+	if policy.FailureThreshold <= 0 {
+		return plugin
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	breaker, found := r.breakers[id]
+	if !found {
+		breaker = &circuitBreaker{
+			driverId:     id,
+			policy:       policy,
+			metronClient: r.metronClient,
+			clock:        r.clock,
+			state:        circuitClosed,
+		}
+		r.breakers[id] = breaker
+	}
+	return &circuitBreakerPlugin{Plugin: plugin, breaker: breaker}
+}
+
+type circuitBreaker struct {
+	driverId     string
+	policy       CircuitBreakerPolicy
+	metronClient loggingclient.IngressClient
+	clock        clock.Clock
+
+	mutex         sync.Mutex
+	state         circuitState
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+// allow reports whether a call may go to the driver, half-opening an open circuit once
+// its OpenDuration has passed. Only one trial call at a time is let through half-open.
+func (b *circuitBreaker) allow(logger lager.Logger) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == circuitOpen {
+		if b.clock.Since(b.openedAt) < b.policy.OpenDuration {
+			return false
+		}
+		b.transition(logger, circuitHalfOpen)
+	}
+
+	if b.state == circuitHalfOpen {
+		if b.trialInFlight {
+			return false
+		}
+		b.trialInFlight = true
+	}
+	return true
+}
+
+// record counts the outcome of a call that allow let through. Calls cancelled by the
+// caller and errors meant for the user say nothing about the driver's health.
+func (b *circuitBreaker) record(ctx context.Context, logger lager.Logger, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == circuitOpen {
+		return
+	}
+	if b.state == circuitHalfOpen {
+		b.trialInFlight = false
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) || isSafeError(err) {
+		return
+	}
+
+	if err == nil {
+		b.failures = 0
+		if b.state != circuitClosed {
+			b.transition(logger, circuitClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.policy.FailureThreshold {
+		b.openedAt = b.clock.Now()
+		b.transition(logger, circuitOpen)
+	}
+}
+
+func (b *circuitBreaker) failingFast() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.state == circuitOpen && b.clock.Since(b.openedAt) < b.policy.OpenDuration
+}
+
+func (b *circuitBreaker) transition(logger lager.Logger, state circuitState) {
+	logger.Info("circuit-state-changed", lager.Data{"driverId": b.driverId, "from": b.state, "to": state, "consecutive-failures": b.failures})
+	b.state = state
+
+	counter := map[circuitState]string{
+		circuitOpen:     volmanCircuitOpenedCounter,
+		circuitHalfOpen: volmanCircuitHalfOpenedCounter,
+		circuitClosed:   volmanCircuitClosedCounter,
+	}[state]
+	if err := b.metronClient.IncrementCounter(counter); err != nil {
+		logger.Debug("failed-emitting-circuit-state-metric", lager.Data{"error": err})
+	}
+}
+
+func (b *circuitBreaker) unavailable(logger lager.Logger) error {
+	logger.Info("circuit-open", lager.Data{"driverId": b.driverId})
+	return volman.DriverUnavailableError{SafeError: volman.SafeError{SafeDescription: fmt.Sprintf("volume service %s is currently unavailable, please try again later", b.driverId)}}
+}
+
+func isSafeError(err error) bool {
+	var safeErr volman.SafeError
+	var driverSafeErr dockerdriver.SafeError
+	return errors.As(err, &safeErr) || errors.As(err, &driverSafeErr)
+}
+
+type circuitBreakerPlugin struct {
+	volman.Plugin
+	breaker *circuitBreaker
+}
+
+func (p *circuitBreakerPlugin) ListVolumes(ctx context.Context, logger lager.Logger) ([]string, error) {
+	if !p.breaker.allow(logger) {
+		return nil, p.breaker.unavailable(logger)
+	}
+	volumes, err := p.Plugin.ListVolumes(ctx, logger)
+	p.breaker.record(ctx, logger, err)
+	return volumes, err
+}
+
+func (p *circuitBreakerPlugin) Mount(ctx context.Context, logger lager.Logger, volumeId string, config map[string]interface{}) (volman.MountResponse, error) {
+	if !p.breaker.allow(logger) {
+		return volman.MountResponse{}, p.breaker.unavailable(logger)
+	}
+	response, err := p.Plugin.Mount(ctx, logger, volumeId, config)
+	p.breaker.record(ctx, logger, err)
+	return response, err
+}
+
+func (p *circuitBreakerPlugin) Unmount(ctx context.Context, logger lager.Logger, volumeId string) error {
+	if !p.breaker.allow(logger) {
+		return p.breaker.unavailable(logger)
+	}
+	err := p.Plugin.Unmount(ctx, logger, volumeId)
+	p.breaker.record(ctx, logger, err)
+	return err
+}
+
+// Health reports a driver whose circuit is open as unreachable without contacting it.
+func (p *circuitBreakerPlugin) Health(ctx context.Context, logger lager.Logger) volman.PluginHealth {
+	if p.breaker.failingFast() {
+		return volman.PluginHealth{Reachable: false}
+	}
+	if reporter, ok := p.Plugin.(volman.HealthReporter); ok {
+		return reporter.Health(ctx, logger)
+	}
+	return volman.PluginHealth{Reachable: true}
+}
@@ -0,0 +1,204 @@
+package vollocal_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	mfakes "code.cloudfoundry.org/diego-logging-client/testhelpers"
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/vollocal"
+	"code.cloudfoundry.org/volman/volmanfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Circuit breakers", func() {
+	var (
+		logger           *lagertest.TestLogger
+		fakePlugin       *volmanfakes.FakePlugin
+		fakeMetronClient *mfakes.FakeIngressClient
+		fakeClock        *fakeclock.FakeClock
+		config           vollocal.PerDriver[vollocal.CircuitBreakerPolicy]
+		registry         volman.PluginRegistry
+		breakers         *vollocal.CircuitBreakerRegistry
+		plugin           volman.Plugin
+		driverErr        error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("circuit-breakers")
+		fakeMetronClient = new(mfakes.FakeIngressClient)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakePlugin = new(volmanfakes.FakePlugin)
+		driverErr = errors.New("connection refused")
+
+		config = vollocal.PerDriver[vollocal.CircuitBreakerPolicy]{Defaults: vollocal.CircuitBreakerPolicy{FailureThreshold: 3, OpenDuration: time.Minute}}
+	})
+
+	JustBeforeEach(func() {
+		registry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{"some-driver": fakePlugin})
+		breakers = vollocal.NewCircuitBreakerRegistry(registry, fakeMetronClient, fakeClock, config)
+
+		var found bool
+		plugin, found = breakers.Plugin("some-driver")
+		Expect(found).To(BeTrue())
+	})
+
+	mount := func() error {
+		_, err := plugin.Mount(context.Background(), logger, "some-volume", nil)
+		return err
+	}
+
+	failRepeatedly := func(times int) {
+		fakePlugin.MountReturns(volman.MountResponse{}, driverErr)
+		for i := 0; i < times; i++ {
+			Expect(mount()).To(Equal(driverErr))
+		}
+	}
+
+	It("passes calls through while the driver is healthy", func() {
+		fakePlugin.MountReturns(volman.MountResponse{Path: "/var/vcap/data/some-volume"}, nil)
+
+		response, err := plugin.Mount(context.Background(), logger, "some-volume", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(response.Path).To(Equal("/var/vcap/data/some-volume"))
+		Expect(plugin.Unmount(context.Background(), logger, "some-volume")).To(Succeed())
+		Expect(fakePlugin.UnmountCallCount()).To(Equal(1))
+	})
+
+	Context("after the threshold of consecutive failures", func() {
+		JustBeforeEach(func() {
+			failRepeatedly(3)
+		})
+
+		It("fails fast with a safe error", func() {
+			err := mount()
+			Expect(err).To(Equal(volman.DriverUnavailableError{SafeError: volman.SafeError{SafeDescription: "volume service some-driver is currently unavailable, please try again later"}}))
+			Expect(plugin.Unmount(context.Background(), logger, "some-volume")).To(BeAssignableToTypeOf(volman.DriverUnavailableError{}))
+			Expect(fakePlugin.MountCallCount()).To(Equal(3))
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+		})
+
+		It("forgets the breaker once the driver is removed, so that it starts afresh when registered again", func() {
+			registry.Set(map[string]volman.Plugin{})
+			Eventually(func() bool { return breakers.AllowActivation("some-driver") }).Should(BeTrue())
+
+			registry.Set(map[string]volman.Plugin{"some-driver": fakePlugin})
+			plugin, _ = breakers.Plugin("some-driver")
+			Expect(mount()).To(Equal(driverErr))
+			Expect(fakePlugin.MountCallCount()).To(Equal(4))
+		})
+
+		It("logs and counts the circuit opening", func() {
+			Expect(countedMetric(fakeMetronClient, "VolmanCircuitBreakerOpened")).To(Equal(1))
+			Expect(logger.Buffer()).To(gbytes.Say("circuit-state-changed.*\"to\":\"open\""))
+		})
+
+		It("keeps the breaker for the driver across lookups", func() {
+			plugin, _ = breakers.Plugin("some-driver")
+			Expect(mount()).To(BeAssignableToTypeOf(volman.DriverUnavailableError{}))
+			Expect(breakers.Plugins()["some-driver"].Mount(context.Background(), logger, "some-volume", nil)).Error().To(BeAssignableToTypeOf(volman.DriverUnavailableError{}))
+		})
+
+		It("holds back activation and reports the driver unreachable", func() {
+			Expect(breakers.AllowActivation("some-driver")).To(BeFalse())
+			Expect(breakers.AllowActivation("other-driver")).To(BeTrue())
+
+			health := plugin.(volman.HealthReporter).Health(context.Background(), logger)
+			Expect(health.Reachable).To(BeFalse())
+		})
+
+		Context("once the open duration has passed", func() {
+			JustBeforeEach(func() {
+				fakeClock.Increment(time.Minute)
+			})
+
+			It("allows activation again", func() {
+				Expect(breakers.AllowActivation("some-driver")).To(BeTrue())
+			})
+
+			It("closes the circuit when the trial call succeeds", func() {
+				fakePlugin.MountReturns(volman.MountResponse{}, nil)
+				Expect(mount()).To(Succeed())
+				Expect(mount()).To(Succeed())
+
+				Expect(countedMetric(fakeMetronClient, "VolmanCircuitBreakerHalfOpened")).To(Equal(1))
+				Expect(countedMetric(fakeMetronClient, "VolmanCircuitBreakerClosed")).To(Equal(1))
+				Expect(fakePlugin.MountCallCount()).To(Equal(5))
+			})
+
+			It("opens the circuit again when the trial call fails", func() {
+				Expect(mount()).To(Equal(driverErr))
+				Expect(mount()).To(BeAssignableToTypeOf(volman.DriverUnavailableError{}))
+
+				Expect(countedMetric(fakeMetronClient, "VolmanCircuitBreakerOpened")).To(Equal(2))
+				Expect(fakePlugin.MountCallCount()).To(Equal(4))
+			})
+
+			It("lets only one trial call through at a time", func() {
+				release := make(chan struct{})
+				fakePlugin.MountStub = func(context.Context, lager.Logger, string, map[string]interface{}) (volman.MountResponse, error) {
+					<-release
+					return volman.MountResponse{}, nil
+				}
+
+				trialDone := make(chan error)
+				go func() {
+					trialDone <- mount()
+				}()
+				Eventually(fakePlugin.MountCallCount).Should(Equal(4))
+
+				Expect(mount()).To(BeAssignableToTypeOf(volman.DriverUnavailableError{}))
+				close(release)
+				Eventually(trialDone).Should(Receive(BeNil()))
+			})
+		})
+	})
+
+	It("resets the count of consecutive failures on success", func() {
+		failRepeatedly(2)
+		fakePlugin.MountReturns(volman.MountResponse{}, nil)
+		Expect(mount()).To(Succeed())
+		failRepeatedly(2)
+
+		Expect(countedMetric(fakeMetronClient, "VolmanCircuitBreakerOpened")).To(Equal(0))
+	})
+
+	It("does not count errors meant for the user", func() {
+		fakePlugin.MountReturns(volman.MountResponse{}, dockerdriver.SafeError{SafeDescription: "bad share"})
+		for i := 0; i < 5; i++ {
+			Expect(mount()).To(HaveOccurred())
+		}
+
+		Expect(fakePlugin.MountCallCount()).To(Equal(5))
+		Expect(countedMetric(fakeMetronClient, "VolmanCircuitBreakerOpened")).To(Equal(0))
+	})
+
+	It("does not count calls cancelled by the caller", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		fakePlugin.MountReturns(volman.MountResponse{}, context.Canceled)
+		for i := 0; i < 5; i++ {
+			_, err := plugin.Mount(ctx, logger, "some-volume", nil)
+			Expect(err).To(HaveOccurred())
+		}
+
+		Expect(countedMetric(fakeMetronClient, "VolmanCircuitBreakerOpened")).To(Equal(0))
+	})
+
+	Context("when a driver has the breaker disabled", func() {
+		BeforeEach(func() {
+			config.Drivers = map[string]vollocal.CircuitBreakerPolicy{"some-driver": {}}
+		})
+
+		It("returns the plugin unwrapped", func() {
+			Expect(plugin).To(BeIdenticalTo(fakePlugin))
+		})
+	})
+})
@@ -1,7 +1,12 @@
 package vollocal
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/tedsuo/ifrit"
@@ -26,67 +31,298 @@ const (
 )
 
 var (
+	pluginDurationsMutex   sync.Mutex
 	pluginMountDurations   = map[string]string{}
 	pluginUnmountDurations = map[string]string{}
 )
 
 type DriverConfig struct {
-	DriverPaths  []string
-	SyncInterval time.Duration
+	DriverPaths     []string
+	SyncInterval    time.Duration
+	MountLedgerPath string
+
+	// DiscoverySources are where drivers are discovered from, in order, with drivers of
+	// later sources winning over those of earlier ones. Only DriverPaths are discovered
+	// when there are none. DiscovererFactories add kinds of source, or replace the
+	// factories of the default ones.
+	DiscoverySources    []DiscoverySource
+	DiscovererFactories map[string]DiscovererFactory
+
+	// WatchDriverPaths picks drivers up as soon as their spec files appear, change or
+	// disappear, listing the driver paths every DriverPollInterval where they cannot be
+	// watched. The full rediscovery every SyncInterval is kept as a safety net.
+	WatchDriverPaths   bool
+	DriverPollInterval time.Duration
+
+	// DiscoveryStaleAfter bounds how long the drivers of a driver path that cannot be
+	// discovered stay registered as they were last seen. Zero keeps them for as long as
+	// the failure lasts.
+	DiscoveryStaleAfter time.Duration
+
+	// LiveContainers, when set, restricts the start-up purge to mounts that no live
+	// container holds. PurgeDryRun only reports those mounts instead of unmounting them.
+	// Only mounts recorded in the ledger are purged, so this needs MountLedgerPath.
+	LiveContainers LiveContainers
+	PurgeDryRun    bool
+
+	// ReapInterval enables a periodic reaper of orphaned mounts when non-zero. Orphans
+	// are only unmounted once they have been seen for longer than ReapGracePeriod. Like
+	// the selective purge, it needs LiveContainers and MountLedgerPath.
+	ReapInterval    time.Duration
+	ReapGracePeriod time.Duration
+
+	// Timeouts bounds mount, unmount and activate calls to drivers, with optional
+	// per-driver overrides. Drivers may also declare their own in their .json spec.
+	Timeouts volman.TimeoutConfig
+
+	// Retry controls how transient mount and unmount failures, such as a driver
+	// restarting, are retried.
+	Retry PerDriver[RetryPolicy]
+
+	// CircuitBreaker makes calls to a driver that keeps failing fail fast for a while,
+	// and stops discovery re-activating it in the meantime.
+	CircuitBreaker PerDriver[CircuitBreakerPolicy]
+
+	// Concurrency limits how many mounts are sent to a driver at once, queueing the rest
+	// in arrival order. Mounts are unlimited by default.
+	Concurrency PerDriver[ConcurrencyLimit]
+
+	// MountpointPolicy restricts where drivers may mount volumes. By default mountpoints
+	// must be under /var/vcap/data, and mounts returning any other are failed.
+	MountpointPolicy volman.MountpointPolicy
+
+	// MountVerification checks that the paths drivers return are really mounts, of
+	// the expected filesystem type, reading the mount table with MountInfoReader. That
+	// reads /proc/self/mountinfo when nil. Verification is off by default.
+	MountVerification PerDriver[MountVerificationPolicy]
+	MountInfoReader   MountInfoReader
+
+	// SensitiveKeys are redacted from volman's logs in addition to
+	// volman.DefaultSensitiveKeys, such as driver specific credential options.
+	SensitiveKeys []string
+
+	// DrainTimeout is how long a driver that disappeared, or was marked for removal, is
+	// kept draining. Volumes mounted through it can still be unmounted until then, while
+	// new mounts are refused. Zero forgets such drivers straight away.
+	DrainTimeout time.Duration
+
+	// RemountOnConfigChange makes a repeated mount for a container with a different
+	// config unmount and mount the volume again, instead of rejecting the mount.
+	RemountOnConfigChange bool
 }
 
 func NewDriverConfig() DriverConfig {
 	return DriverConfig{
-		SyncInterval: time.Second * 30,
+		SyncInterval:        time.Second * 30,
+		WatchDriverPaths:    true,
+		DriverPollInterval:  time.Second * 2,
+		DiscoveryStaleAfter: time.Minute * 5,
+		ReapGracePeriod:     time.Minute * 5,
+		DrainTimeout:        time.Minute * 10,
+		Timeouts: volman.TimeoutConfig{
+			Defaults: volman.OperationTimeouts{
+				Mount:    time.Minute * 2,
+				Unmount:  time.Minute * 2,
+				Activate: time.Second * 10,
+			},
+		},
+		Retry: PerDriver[RetryPolicy]{
+			Defaults: RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond * 500,
+				MaxBackoff:     time.Second * 5,
+			},
+		},
+		CircuitBreaker: PerDriver[CircuitBreakerPolicy]{
+			Defaults: CircuitBreakerPolicy{
+				FailureThreshold: 5,
+				OpenDuration:     time.Second * 30,
+			},
+		},
+		MountpointPolicy: volman.DefaultMountpointPolicy(),
 	}
 }
 
+// PerDriver holds the default setting of a driver call policy and per-driver settings,
+// which replace the default entirely for their driver.
+type PerDriver[T any] struct {
+	Defaults T
+	Drivers  map[string]T
+}
+
+func (c PerDriver[T]) For(driverId string) T {
+	if setting, ok := c.Drivers[driverId]; ok {
+		return setting
+	}
+	return c.Defaults
+}
+
+// VolumeHolders reports which containers currently have a volume mounted through volman.
+// Managers returned by this package implement it, which is mostly useful when debugging
+// shared volumes whose driver does not use unique volume ids.
+type VolumeHolders interface {
+	Holders(driverId string, volumeId string) []string
+}
+
+// DriverDrainer marks drivers for removal. Managers returned by NewServer implement it.
+type DriverDrainer interface {
+	Drain(logger lager.Logger, driverId string) bool
+}
+
 type localClient struct {
 	pluginRegistry volman.PluginRegistry
 	metronClient   loggingclient.IngressClient
 	clock          clock.Clock
+	mountLedger    MountLedger
+	config         DriverConfig
+
+	limitersMutex sync.Mutex
+	limiters      map[string]*mountLimiter
+
+	volumeLocks *volumeLocks
 }
 
-func NewServer(logger lager.Logger, metronClient loggingclient.IngressClient, config DriverConfig) (volman.Manager, ifrit.Runner) {
+// NewServer returns a manager of the drivers discovered from config, and the runner that
+// keeps them discovered. It fails if config cannot be honoured, such as when a discovery
+// source cannot be built, rather than run with part of it left out.
+func NewServer(logger lager.Logger, metronClient loggingclient.IngressClient, config DriverConfig) (volman.Manager, ifrit.Runner, error) {
+	logger = volman.NewRedactingLogger(logger, config.SensitiveKeys...)
+	if err := config.validate(); err != nil {
+		logger.Error("invalid-driver-config", err)
+		return nil, nil, err
+	}
+
 	clock := clock.NewClock()
-	registry := NewPluginRegistry()
+	registry := NewPluginRegistryWithDrainTimeout(clock, config.DrainTimeout)
+	ledger := NewMountLedger(logger, config.MountLedgerPath)
+	breakers := NewCircuitBreakerRegistry(registry, metronClient, clock, config.CircuitBreaker)
+
+	env := DiscovererEnv{
+		Logger:           logger,
+		Registry:         registry,
+		MetronClient:     metronClient,
+		Timeouts:         config.Timeouts,
+		Gate:             breakers,
+		MountpointPolicy: config.MountpointPolicy,
+	}
+	discoverers, err := NewDiscoverers(env, config.discoverySources(), config.DiscovererFactories)
+	if err != nil {
+		logger.Error("failed-creating-discoverers", err)
+		return nil, nil, err
+	}
+
+	var watcher volman.DriverWatcher
+	if driverPaths := config.watchedDriverPaths(); config.WatchDriverPaths && len(driverPaths) > 0 {
+		watcher = voldiscoverers.NewDriverWatcher(driverPaths, clock, config.DriverPollInterval)
+	}
+	syncer := NewSyncerWithStaleAfter(logger, registry, discoverers, config.SyncInterval, clock, watcher, config.DiscoveryStaleAfter)
+	purger := NewMountPurgerWithLedger(logger, registry, ledger, config.LiveContainers, config.PurgeDryRun)
+
+	client := NewLocalClientWithConfig(logger, breakers, metronClient, clock, ledger, config)
 
-	dockerDiscoverer := voldiscoverers.NewDockerDriverDiscoverer(logger, registry, config.DriverPaths)
+	members := grouper.Members{grouper.Member{Name: "volman-syncer", Runner: syncer.Runner()}, grouper.Member{Name: "volman-purger", Runner: purger.Runner()}}
+	if config.ReapInterval > 0 {
+		reaper := NewMountReaperWithManager(logger, breakers, ledger, config.LiveContainers, metronClient, clock, config.ReapInterval, config.ReapGracePeriod, client)
+		members = append(members, grouper.Member{Name: "volman-reaper", Runner: reaper.Runner()})
+	}
 
-	syncer := NewSyncer(logger, registry, []volman.Discoverer{dockerDiscoverer}, config.SyncInterval, clock)
-	purger := NewMountPurger(logger, registry)
+	grouper := grouper.NewOrdered(os.Kill, members)
 
-	grouper := grouper.NewOrdered(os.Kill, grouper.Members{grouper.Member{Name: "volman-syncer", Runner: syncer.Runner()}, grouper.Member{Name: "volman-purger", Runner: purger.Runner()}})
+	return client, grouper, nil
+}
 
-	return NewLocalClient(logger, registry, metronClient, clock), grouper
+// validate rejects configs that would have volman unmount volumes still in use. Orphaned
+// mounts are told apart from the ledger, which only survives restarts in a file.
+func (c DriverConfig) validate() error {
+	if c.LiveContainers != nil && c.MountLedgerPath == "" {
+		return errors.New("purging orphaned mounts needs a mount ledger that survives restarts, but MountLedgerPath is not set")
+	}
+	if c.ReapInterval > 0 && (c.LiveContainers == nil || c.MountLedgerPath == "") {
+		return errors.New("reaping orphaned mounts needs LiveContainers and a mount ledger that survives restarts in MountLedgerPath")
+	}
+	return nil
 }
 
 func NewLocalClient(logger lager.Logger, registry volman.PluginRegistry, metronClient loggingclient.IngressClient, clock clock.Clock) volman.Manager {
+	return NewLocalClientWithMountLedger(logger, registry, metronClient, clock, NewMountLedger(logger, ""))
+}
+
+func NewLocalClientWithMountLedger(logger lager.Logger, registry volman.PluginRegistry, metronClient loggingclient.IngressClient, clock clock.Clock, ledger MountLedger) volman.Manager {
+	return NewLocalClientWithConfig(logger, registry, metronClient, clock, ledger, NewDriverConfig())
+}
+
+// NewLocalClientWithConfig returns a client that applies the driver call policies in
+// config, such as timeouts. Only those policies are read from config. Circuit breakers
+// are applied by passing a registry wrapped with NewCircuitBreakerRegistry instead.
+func NewLocalClientWithConfig(logger lager.Logger, registry volman.PluginRegistry, metronClient loggingclient.IngressClient, clock clock.Clock, ledger MountLedger, config DriverConfig) volman.Manager {
 	return &localClient{
 		pluginRegistry: registry,
 		metronClient:   metronClient,
 		clock:          clock,
+		mountLedger:    ledger,
+		config:         config,
+		limiters:       map[string]*mountLimiter{},
+		volumeLocks:    newVolumeLocks(),
 	}
 }
 
-func (client *localClient) ListDrivers(logger lager.Logger) (volman.ListDriversResponse, error) {
-	logger = logger.Session("list-drivers")
+// redacting wraps the caller's logger so that secrets in mount configs, and anything
+// else matching the configured sensitive keys, never reach the logs.
+func (client *localClient) redacting(logger lager.Logger) lager.Logger {
+	return volman.NewRedactingLogger(logger, client.config.SensitiveKeys...)
+}
+
+func (client *localClient) ListDrivers(ctx context.Context, logger lager.Logger) (volman.ListDriversResponse, error) {
+	return client.ListDriversMatching(ctx, logger, volman.DriverFilter{})
+}
+
+func (client *localClient) ListDriversMatching(ctx context.Context, logger lager.Logger, filter volman.DriverFilter) (volman.ListDriversResponse, error) {
+	logger = client.redacting(logger).Session("list-drivers", lager.Data{"filter": filter})
 	logger.Info("start")
 	defer logger.Info("end")
 
 	var infoResponses []volman.InfoResponse
 	plugins := client.pluginRegistry.Plugins()
 
-	for name := range plugins {
-		infoResponses = append(infoResponses, volman.InfoResponse{Name: name})
+	var names []string
+	for name, plugin := range plugins {
+		if filter.Matches(name, plugin.GetPluginSpec()) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		infoResponses = append(infoResponses, driverInfo(ctx, logger, name, plugins[name]))
 	}
 
 	logger.Debug("listing-drivers", lager.Data{"drivers": infoResponses})
 	return volman.ListDriversResponse{Drivers: infoResponses}, nil
 }
 
-func (client *localClient) Mount(logger lager.Logger, pluginId string, volumeId string, containerId string, config map[string]interface{}) (volman.MountResponse, error) {
-	logger = logger.Session("mount")
+// driverInfo describes a registered plugin. Plugins that cannot report on their health
+// are assumed reachable, since only activated plugins make it into the registry.
+func driverInfo(ctx context.Context, logger lager.Logger, name string, plugin volman.Plugin) volman.InfoResponse {
+	spec := plugin.GetPluginSpec()
+	info := volman.InfoResponse{
+		Name:            name,
+		Address:         spec.Address,
+		Transport:       spec.Transport(),
+		UniqueVolumeIds: spec.UniqueVolumeIds,
+		Reachable:       true,
+	}
+
+	if reporter, ok := plugin.(volman.HealthReporter); ok {
+		health := reporter.Health(ctx, logger)
+		info.Scope = health.Scope
+		info.LastActivation = health.LastActivation
+		info.Reachable = health.Reachable
+	}
+	return info
+}
+
+func (client *localClient) Mount(ctx context.Context, logger lager.Logger, pluginId string, volumeId string, containerId string, config map[string]interface{}) (volman.MountResponse, error) {
+	logger = client.redacting(logger).Session("mount")
 	logger.Info("start")
 	defer logger.Info("end")
 
@@ -100,7 +336,16 @@ func (client *localClient) Mount(logger lager.Logger, pluginId string, volumeId
 
 	plugin, found := client.pluginRegistry.Plugin(pluginId)
 	if !found {
-		err := errors.New("Plugin '" + pluginId + "' not found in list of known plugins")
+		if _, draining := drainingPluginOf(client.pluginRegistry, pluginId); draining {
+			err := volman.SafeError{SafeDescription: fmt.Sprintf("volume service %s is being removed and no longer accepts new mounts", pluginId)}
+			logger.Error("mount-refused-driver-draining", err)
+			if metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter); metricErr != nil {
+				logger.Debug("failed-emitting-mount-error-metric", lager.Data{"error": metricErr})
+			}
+			return volman.MountResponse{}, err
+		}
+
+		err := volman.DriverNotFoundError{DriverId: pluginId}
 		logger.Error("mount-plugin-lookup-error", err)
 		metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter)
 		if metricErr != nil {
@@ -110,39 +355,211 @@ func (client *localClient) Mount(logger lager.Logger, pluginId string, volumeId
 		return volman.MountResponse{}, err
 	}
 
-	if plugin.GetPluginSpec().UniqueVolumeIds {
+	if err := validateMountConfig(plugin.GetPluginSpec(), pluginId, config); err != nil {
+		logger.Error("invalid-mount-config", err)
+		if metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter); metricErr != nil {
+			logger.Debug("failed-emitting-mount-error-metric", lager.Data{"error": metricErr})
+		}
+		return volman.MountResponse{}, err
+	}
+
+	driverVolumeId := volumeId
+	uniqueVolumeIds := plugin.GetPluginSpec().UniqueVolumeIds
+	if uniqueVolumeIds {
 		logger.Debug("generating-unique-volume-id")
 		uniqueVolId := dockerdriverutils.NewVolumeId(volumeId, containerId)
-		volumeId = uniqueVolId.GetUniqueId()
+		driverVolumeId = uniqueVolId.GetUniqueId()
+	}
+
+	unlock, err := client.volumeLocks.lock(ctx, logger, pluginId, driverVolumeId)
+	if err != nil {
+		return volman.MountResponse{}, err
+	}
+	defer unlock()
+
+	existing, found := client.mountLedger.Get(pluginId, volumeId, containerId)
+	if found {
+		if err := client.checkRecordedMount(logger, pluginId, existing); err != nil {
+			if !uniqueVolumeIds && len(client.sharedVolumeHolders(pluginId, driverVolumeId, containerId)) > 0 {
+				return volman.MountResponse{}, client.sharedMountLost(logger, volumeId)
+			}
+			client.removeMountRecord(logger, pluginId, volumeId, containerId)
+			found = false
+		}
+	}
+	if found {
+		if existing.ConfigHash == hashConfig(config) {
+			logger.Info("reusing-existing-mount", lager.Data{"volumeId": volumeId, "containerId": containerId, "path": existing.Path})
+			return volman.MountResponse{Path: existing.Path}, nil
+		}
+
+		if !client.config.RemountOnConfigChange {
+			err := volman.SafeError{SafeDescription: fmt.Sprintf("volume %s is already mounted for this container with a different configuration", volumeId)}
+			logger.Error("mount-config-differs", err, lager.Data{"volumeId": volumeId, "containerId": containerId})
+			if metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter); metricErr != nil {
+				logger.Debug("failed-emitting-mount-error-metric", lager.Data{"error": metricErr})
+			}
+			return volman.MountResponse{}, err
+		}
+
+		if !uniqueVolumeIds && len(client.sharedVolumeHolders(pluginId, driverVolumeId, containerId)) > 0 {
+			err := volman.SafeError{SafeDescription: fmt.Sprintf("volume %s is shared with other containers and cannot be remounted with a different configuration", volumeId)}
+			logger.Error("remount-refused-shared-mount", err, lager.Data{"volumeId": volumeId, "containerId": containerId})
+			if metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter); metricErr != nil {
+				logger.Debug("failed-emitting-mount-error-metric", lager.Data{"error": metricErr})
+			}
+			return volman.MountResponse{}, err
+		}
+
+		logger.Info("remounting-with-new-config", lager.Data{"volumeId": volumeId, "containerId": containerId})
+		if err := client.unmount(ctx, logger, plugin, pluginId, volumeId, containerId, driverVolumeId, uniqueVolumeIds); err != nil {
+			return volman.MountResponse{}, err
+		}
 	}
 
-	mountResponse, err := plugin.Mount(logger, volumeId, config)
+	if !uniqueVolumeIds {
+		holders := client.sharedVolumeHolders(pluginId, driverVolumeId, containerId)
+		if len(holders) > 0 {
+			if err := client.checkRecordedMount(logger, pluginId, holders[0]); err != nil {
+				return volman.MountResponse{}, client.sharedMountLost(logger, volumeId)
+			}
+
+			logger.Info("reusing-shared-mount", lager.Data{"volumeId": volumeId, "holders": len(holders)})
+			if holders[0].ConfigHash != hashConfig(config) {
+				logger.Info("shared-mount-config-differs", lager.Data{"volumeId": volumeId})
+			}
+
+			client.recordMount(logger, pluginId, volumeId, containerId, driverVolumeId, config, holders[0].Path)
+			return volman.MountResponse{Path: holders[0].Path}, nil
+		}
+	}
+
+	release, err := client.acquireMountSlot(ctx, logger, pluginId)
+	if err != nil {
+		return volman.MountResponse{}, err
+	}
+	defer release()
+
+	timeout := client.config.Timeouts.For(pluginId, plugin.GetPluginSpec()).Mount
+	var mountResponse volman.MountResponse
+	err = client.withRetries(ctx, logger, pluginId, volman.OperationMount, volmanMountRetriesCounter, func() error {
+		var err error
+		mountResponse, err = callWithTimeout(ctx, pluginId, volman.OperationMount, timeout, func(ctx context.Context) (volman.MountResponse, error) {
+			return plugin.Mount(ctx, logger, driverVolumeId, config)
+		})
+		return err
+	})
 
 	if err != nil {
 		metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter)
 		if metricErr != nil {
 			logger.Debug("failed-emitting-mount-error-metric", lager.Data{"error": err})
 		}
+		if isTimeout(err) {
+			logger.Error("mount-timed-out", err)
+			if metricErr := client.metronClient.IncrementCounter(volmanMountTimeoutsCounter); metricErr != nil {
+				logger.Debug("failed-emitting-mount-timeout-metric", lager.Data{"error": metricErr})
+			}
+		}
 		if dockerdriverSafeErr, ok := err.(dockerdriver.SafeError); ok {
 			return volman.MountResponse{}, volman.SafeError{SafeDescription: dockerdriverSafeErr.SafeDescription}
 		}
 		return volman.MountResponse{}, err
 	}
 
+	if err := client.verifyMount(logger, pluginId, volumeId, mountResponse.Path); err != nil {
+		logger.Error("mount-verification-failed", err, lager.Data{"path": mountResponse.Path})
+		if metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter); metricErr != nil {
+			logger.Debug("failed-emitting-mount-error-metric", lager.Data{"error": metricErr})
+		}
+		client.discardUnverifiedMount(ctx, logger, plugin, pluginId, driverVolumeId)
+		return volman.MountResponse{}, err
+	}
+
+	client.recordMount(logger, pluginId, volumeId, containerId, driverVolumeId, config, mountResponse.Path)
+
 	return mountResponse, nil
 }
 
+// validateMountConfig checks config against the schema the driver publishes, if any, so
+// that mistakes in mount options are reported to the user rather than by the driver.
+func validateMountConfig(spec volman.PluginSpec, pluginId string, config map[string]interface{}) error {
+	if spec.ConfigSchema == nil {
+		return nil
+	}
+
+	var validationErr volman.ConfigValidationError
+	if err := spec.ConfigSchema.Validate(config); !errors.As(err, &validationErr) {
+		return err
+	}
+
+	var problems []string
+	for _, violation := range validationErr.Violations {
+		problems = append(problems, violation.Key+" "+violation.Reason)
+	}
+	return volman.SafeError{SafeDescription: fmt.Sprintf("invalid mount config for volume service %s: %s", pluginId, strings.Join(problems, ", "))}
+}
+
+func (client *localClient) recordMount(logger lager.Logger, pluginId string, volumeId string, containerId string, driverVolumeId string, config map[string]interface{}, path string) {
+	err := client.mountLedger.Add(logger, MountRecord{
+		DriverId:       pluginId,
+		VolumeId:       volumeId,
+		ContainerId:    containerId,
+		DriverVolumeId: driverVolumeId,
+		ConfigHash:     hashConfig(config),
+		Path:           path,
+		MountedAt:      client.clock.Now(),
+	})
+	if err != nil {
+		logger.Error("failed-recording-mount", err)
+	}
+}
+
+// sharedVolumeHolders returns the ledger records of other containers that hold the
+// driver volume mounted, oldest first.
+func (client *localClient) sharedVolumeHolders(pluginId string, driverVolumeId string, containerId string) []MountRecord {
+	var holders []MountRecord
+	for _, record := range client.mountLedger.Records() {
+		if record.DriverId == pluginId && record.DriverVolumeId == driverVolumeId && record.ContainerId != containerId {
+			holders = append(holders, record)
+		}
+	}
+
+	sort.SliceStable(holders, func(i, j int) bool {
+		return holders[i].MountedAt.Before(holders[j].MountedAt)
+	})
+	return holders
+}
+
+// Subscribe reports changes to the drivers of the client's registry, if the registry
+// reports them. Managers returned by NewServer always do.
+func (client *localClient) Subscribe(handle func(volman.RegistryEvent)) func() {
+	return subscribeTo(client.pluginRegistry, handle)
+}
+
+func (client *localClient) Holders(driverId string, volumeId string) []string {
+	var containerIds []string
+	for _, record := range client.mountLedger.Records() {
+		if record.DriverId == driverId && record.VolumeId == volumeId {
+			containerIds = append(containerIds, record.ContainerId)
+		}
+	}
+	return containerIds
+}
+
 func sendMountDurationMetrics(logger lager.Logger, metronClient loggingclient.IngressClient, duration time.Duration, pluginId string) {
 	err := metronClient.SendDuration(volmanMountDuration, duration)
 	if err != nil {
 		logger.Error("failed-to-send-volman-mount-duration-metric", err)
 	}
 
+	pluginDurationsMutex.Lock()
 	m, ok := pluginMountDurations[pluginId]
 	if !ok {
 		m = "VolmanMountDurationFor" + pluginId
 		pluginMountDurations[pluginId] = m
 	}
+	pluginDurationsMutex.Unlock()
 	err = metronClient.SendDuration(m, duration)
 	if err != nil {
 		logger.Error("failed-to-send-volman-mount-duration-metric", err)
@@ -155,19 +572,21 @@ func sendUnmountDurationMetrics(logger lager.Logger, metronClient loggingclient.
 		logger.Error("failed-to-send-volman-unmount-duration-metric", err)
 	}
 
+	pluginDurationsMutex.Lock()
 	m, ok := pluginUnmountDurations[pluginId]
 	if !ok {
 		m = "VolmanUnmountDurationFor" + pluginId
 		pluginUnmountDurations[pluginId] = m
 	}
+	pluginDurationsMutex.Unlock()
 	err = metronClient.SendDuration(m, duration)
 	if err != nil {
 		logger.Error("failed-to-send-volman-unmount-duration-metric", err)
 	}
 }
 
-func (client *localClient) Unmount(logger lager.Logger, pluginId string, volumeId string, containerId string) error {
-	logger = logger.Session("unmount")
+func (client *localClient) Unmount(ctx context.Context, logger lager.Logger, pluginId string, volumeId string, containerId string) error {
+	logger = client.redacting(logger).Session("unmount")
 	logger.Info("start")
 	defer logger.Info("end")
 	logger.Debug("unmounting-volume", lager.Data{"volumeName": volumeId})
@@ -178,9 +597,21 @@ func (client *localClient) Unmount(logger lager.Logger, pluginId string, volumeI
 		sendUnmountDurationMetrics(logger, client.metronClient, time.Since(unmountStart), pluginId)
 	}()
 
+	// volumes mounted through a driver that is being removed are still unmounted
+	// through it, at its last known address
 	plugin, found := client.pluginRegistry.Plugin(pluginId)
+	draining := false
+	if !found {
+		if _, mounted := client.mountLedger.Get(pluginId, volumeId, containerId); mounted {
+			plugin, draining = drainingPluginOf(client.pluginRegistry, pluginId)
+			found = draining
+		}
+		if draining {
+			logger.Info("unmounting-from-draining-driver", lager.Data{"pluginId": pluginId, "address": plugin.GetPluginSpec().Address})
+		}
+	}
 	if !found {
-		err := errors.New("Plugin '" + pluginId + "' not found in list of known plugins")
+		err := volman.DriverNotFoundError{DriverId: pluginId}
 		logger.Error("mount-plugin-lookup-error", err)
 		metricErr := client.metronClient.IncrementCounter(volmanUnmountErrorsCounter)
 		if metricErr != nil {
@@ -189,18 +620,84 @@ func (client *localClient) Unmount(logger lager.Logger, pluginId string, volumeI
 		return err
 	}
 
-	if plugin.GetPluginSpec().UniqueVolumeIds {
+	driverVolumeId := volumeId
+	uniqueVolumeIds := plugin.GetPluginSpec().UniqueVolumeIds
+	if uniqueVolumeIds {
 		logger.Debug("generating-unique-volume-id")
 		uniqueVolId := dockerdriverutils.NewVolumeId(volumeId, containerId)
-		volumeId = uniqueVolId.GetUniqueId()
+		driverVolumeId = uniqueVolId.GetUniqueId()
 	}
 
-	err := plugin.Unmount(logger, volumeId)
+	unlock, err := client.volumeLocks.lock(ctx, logger, pluginId, driverVolumeId)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = client.unmount(ctx, logger, plugin, pluginId, volumeId, containerId, driverVolumeId, uniqueVolumeIds)
+	if err == nil && draining {
+		client.finishDraining(logger, pluginId)
+	}
+	return err
+}
+
+// Drain marks a driver for removal. New mounts through it are refused straight away,
+// while the volumes already mounted through it can still be unmounted until it drains.
+func (client *localClient) Drain(logger lager.Logger, driverId string) bool {
+	logger = client.redacting(logger).Session("drain", lager.Data{"driverId": driverId})
+
+	draining, ok := client.pluginRegistry.(volman.DrainingRegistry)
+	if !ok || !draining.Drain(driverId) {
+		return false
+	}
+
+	logger.Info("driver-draining")
+	client.finishDraining(logger, driverId)
+	return true
+}
+
+// finishDraining forgets a draining driver once volman has nothing mounted through it.
+func (client *localClient) finishDraining(logger lager.Logger, driverId string) {
+	for _, record := range client.mountLedger.Records() {
+		if record.DriverId == driverId {
+			return
+		}
+	}
+
+	if draining, ok := client.pluginRegistry.(volman.DrainingRegistry); ok {
+		logger.Info("driver-drained", lager.Data{"driverId": driverId})
+		draining.Drained(driverId)
+	}
+}
+
+// unmount releases the container's hold on the driver volume, unmounting it from the
+// driver once no other container holds it. The caller must hold the volume's lock.
+func (client *localClient) unmount(ctx context.Context, logger lager.Logger, plugin volman.Plugin, pluginId string, volumeId string, containerId string, driverVolumeId string, uniqueVolumeIds bool) error {
+	if !uniqueVolumeIds {
+		if holders := client.sharedVolumeHolders(pluginId, driverVolumeId, containerId); len(holders) > 0 {
+			logger.Info("releasing-shared-mount", lager.Data{"volumeId": volumeId, "remaining-holders": len(holders)})
+			client.removeMountRecord(logger, pluginId, volumeId, containerId)
+			return nil
+		}
+	}
+
+	timeout := client.config.Timeouts.For(pluginId, plugin.GetPluginSpec()).Unmount
+	err := client.withRetries(ctx, logger, pluginId, volman.OperationUnmount, volmanUnmountRetriesCounter, func() error {
+		_, err := callWithTimeout(ctx, pluginId, volman.OperationUnmount, timeout, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, plugin.Unmount(ctx, logger, driverVolumeId)
+		})
+		return err
+	})
 	if err != nil {
 		metricErr := client.metronClient.IncrementCounter(volmanUnmountErrorsCounter)
 		if metricErr != nil {
 			logger.Debug("failed-emitting-unmount-error-metric", lager.Data{"error": err})
 		}
+		if isTimeout(err) {
+			if metricErr := client.metronClient.IncrementCounter(volmanUnmountTimeoutsCounter); metricErr != nil {
+				logger.Debug("failed-emitting-unmount-timeout-metric", lager.Data{"error": metricErr})
+			}
+		}
 		logger.Error("unmount-failed", err)
 
 		if dockerdriverSafeErr, ok := err.(dockerdriver.SafeError); ok {
@@ -209,5 +706,59 @@ func (client *localClient) Unmount(logger lager.Logger, pluginId string, volumeI
 		return err
 	}
 
+	client.removeMountRecord(logger, pluginId, volumeId, containerId)
+
 	return nil
 }
+
+func (client *localClient) ListMounts(ctx context.Context, logger lager.Logger, pluginId string, volumeId string, containerId string) (volman.ListMountsResponse, error) {
+	logger = client.redacting(logger).Session("list-mounts", lager.Data{"pluginId": pluginId, "volumeId": volumeId, "containerId": containerId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	mounts := []volman.MountInfo{}
+	for _, record := range client.mountLedger.Records() {
+		if pluginId != "" && record.DriverId != pluginId {
+			continue
+		}
+		if volumeId != "" && record.VolumeId != volumeId {
+			continue
+		}
+		if containerId != "" && record.ContainerId != containerId {
+			continue
+		}
+		mounts = append(mounts, mountInfo(record))
+	}
+
+	return volman.ListMountsResponse{Mounts: mounts}, nil
+}
+
+func (client *localClient) GetMount(ctx context.Context, logger lager.Logger, pluginId string, volumeId string, containerId string) (volman.MountInfo, error) {
+	logger = client.redacting(logger).Session("get-mount", lager.Data{"pluginId": pluginId, "volumeId": volumeId, "containerId": containerId})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	record, found := client.mountLedger.Get(pluginId, volumeId, containerId)
+	if !found {
+		return volman.MountInfo{}, fmt.Errorf("volume '%s' of driver '%s' for container '%s': %w", volumeId, pluginId, containerId, volman.ErrMountNotFound)
+	}
+
+	return mountInfo(record), nil
+}
+
+func mountInfo(record MountRecord) volman.MountInfo {
+	return volman.MountInfo{
+		DriverId:    record.DriverId,
+		VolumeId:    record.VolumeId,
+		ContainerId: record.ContainerId,
+		Path:        record.Path,
+		MountedAt:   record.MountedAt,
+	}
+}
+
+func (client *localClient) removeMountRecord(logger lager.Logger, pluginId string, volumeId string, containerId string) {
+	err := client.mountLedger.Remove(logger, pluginId, volumeId, containerId)
+	if err != nil {
+		logger.Error("failed-removing-mount-record", err)
+	}
+}
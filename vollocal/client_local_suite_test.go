@@ -10,6 +10,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	mfakes "code.cloudfoundry.org/diego-logging-client/testhelpers"
 	"code.cloudfoundry.org/volman"
 	"github.com/onsi/gomega/gexec"
 	"github.com/tedsuo/ifrit"
@@ -29,6 +30,17 @@ var localDriverRunner *ginkgomon.Runner
 
 var tmpDriversPath string
 
+// countedMetric returns how many times the counter was incremented on metronClient.
+func countedMetric(metronClient *mfakes.FakeIngressClient, name string) int {
+	count := 0
+	for i := 0; i < metronClient.IncrementCounterCallCount(); i++ {
+		if metronClient.IncrementCounterArgsForCall(i) == name {
+			count++
+		}
+	}
+	return count
+}
+
 func TestDriver(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Volman Local Client Suite")
@@ -1,7 +1,9 @@
 package vollocal_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"sync"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"code.cloudfoundry.org/dockerdriver"
 	loggregator "code.cloudfoundry.org/go-loggregator/v9"
 	"code.cloudfoundry.org/volman/voldiscoverers"
+	"code.cloudfoundry.org/volman/voldocker"
 	"code.cloudfoundry.org/volman/vollocal"
 	"code.cloudfoundry.org/volman/volmanfakes"
 
@@ -54,6 +57,7 @@ var _ = Describe("Volman", func() {
 		dockerDriverDiscoverer volman.Discoverer
 		durationMetricMap      map[string]time.Duration
 		counterMetricMap       map[string]int
+		metricsMutex           sync.Mutex
 
 		process ifrit.Process
 
@@ -74,10 +78,14 @@ var _ = Describe("Volman", func() {
 
 		fakeMetronClient = new(mfakes.FakeIngressClient)
 		fakeMetronClient.SendDurationStub = func(name string, value time.Duration, opts ...loggregator.EmitGaugeOption) error {
+			metricsMutex.Lock()
+			defer metricsMutex.Unlock()
 			durationMetricMap[name] = value
 			return nil
 		}
 		fakeMetronClient.IncrementCounterStub = func(name string) error {
+			metricsMutex.Lock()
+			defer metricsMutex.Unlock()
 			value, ok := counterMetricMap[name]
 			if ok {
 				counterMetricMap[name] = value + 1
@@ -100,7 +108,7 @@ var _ = Describe("Volman", func() {
 		})
 
 		It("should report empty list of drivers", func() {
-			drivers, err := client.ListDrivers(logger)
+			drivers, err := client.ListDrivers(context.Background(), logger)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(len(drivers.Drivers)).To(Equal(0))
 		})
@@ -112,7 +120,7 @@ var _ = Describe("Volman", func() {
 			})
 
 			It("should report empty list of drivers", func() {
-				drivers, err := client.ListDrivers(logger)
+				drivers, err := client.ListDrivers(context.Background(), logger)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(drivers.Drivers)).To(Equal(0))
 			})
@@ -138,7 +146,7 @@ var _ = Describe("Volman", func() {
 			})
 
 			It("should report empty list of drivers", func() {
-				drivers, err := client.ListDrivers(logger)
+				drivers, err := client.ListDrivers(context.Background(), logger)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(drivers.Drivers)).To(Equal(0))
 			})
@@ -154,7 +162,7 @@ var _ = Describe("Volman", func() {
 				})
 
 				It("should report fakedriver", func() {
-					drivers, err := client.ListDrivers(logger)
+					drivers, err := client.ListDrivers(context.Background(), logger)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(len(drivers.Drivers)).ToNot(Equal(0))
 					Expect(drivers.Drivers[0].Name).To(Equal(fakeDriverId))
@@ -162,14 +170,112 @@ var _ = Describe("Volman", func() {
 
 			})
 		})
+
+		Context("when describing registered drivers", func() {
+			var healthyDriver, unreachableDriver *dockerdriverfakes.FakeDriver
+
+			BeforeEach(func() {
+				healthyDriver = new(dockerdriverfakes.FakeDriver)
+				healthyDriver.CapabilitiesReturns(dockerdriver.CapabilitiesResponse{Capabilities: dockerdriver.CapabilityInfo{Scope: "global"}})
+				unreachableDriver = new(dockerdriverfakes.FakeDriver)
+				unreachableDriver.ActivateReturns(dockerdriver.ActivateResponse{Err: "connection refused"})
+
+				healthyPlugin := voldocker.NewVolmanPluginWithDockerDriver(healthyDriver, volman.PluginSpec{Name: "healthy-driver", Address: "/var/vcap/data/voldrivers/healthy-driver.sock", UniqueVolumeIds: true})
+				healthyPlugin.(*voldocker.DockerDriverPlugin).Activated(time.Unix(84, 0))
+				healthyPlugin.(*voldocker.DockerDriverPlugin).SetScope("global")
+
+				unreachablePlugin := voldocker.NewVolmanPluginWithDockerDriver(unreachableDriver, volman.PluginSpec{Name: "unreachable-driver", Address: "https://127.0.0.1:9776"})
+				unreachablePlugin.(*voldocker.DockerDriverPlugin).Activated(time.Unix(42, 0))
+				unreachablePlugin.(*voldocker.DockerDriverPlugin).Unreachable()
+
+				driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{
+					"healthy-driver":     healthyPlugin,
+					"unreachable-driver": unreachablePlugin,
+				})
+				client = vollocal.NewLocalClient(logger, driverRegistry, fakeMetronClient, fakeClock)
+			})
+
+			AfterEach(func() {
+				ginkgomon.Kill(process)
+			})
+
+			driverNamed := func(drivers []volman.InfoResponse, name string) volman.InfoResponse {
+				for _, driver := range drivers {
+					if driver.Name == name {
+						return driver
+					}
+				}
+				Fail("no driver named " + name)
+				return volman.InfoResponse{}
+			}
+
+			It("reports the address, transport and capabilities of reachable drivers", func() {
+				drivers, err := client.ListDrivers(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+
+				info := driverNamed(drivers.Drivers, "healthy-driver")
+				Expect(info.Address).To(Equal("/var/vcap/data/voldrivers/healthy-driver.sock"))
+				Expect(info.Transport).To(Equal(volman.TransportUnix))
+				Expect(info.UniqueVolumeIds).To(BeTrue())
+				Expect(info.Scope).To(Equal("global"))
+				Expect(info.Reachable).To(BeTrue())
+				Expect(info.LastActivation).To(Equal(time.Unix(84, 0)))
+			})
+
+			It("reports what was recorded at activation without contacting the drivers", func() {
+				_, err := client.ListDrivers(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(healthyDriver.ActivateCallCount()).To(Equal(0))
+				Expect(healthyDriver.CapabilitiesCallCount()).To(Equal(0))
+				Expect(unreachableDriver.ActivateCallCount()).To(Equal(0))
+			})
+
+			It("lists the drivers in name order", func() {
+				for i := 0; i < 5; i++ {
+					drivers, err := client.ListDrivers(context.Background(), logger)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(drivers.Drivers).To(HaveLen(2))
+					Expect(drivers.Drivers[0].Name).To(Equal("healthy-driver"))
+					Expect(drivers.Drivers[1].Name).To(Equal("unreachable-driver"))
+				}
+			})
+
+			It("filters the drivers by name prefix", func() {
+				drivers, err := client.ListDriversMatching(context.Background(), logger, volman.DriverFilter{NamePrefix: "unreach"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers.Drivers).To(HaveLen(1))
+				Expect(drivers.Drivers[0].Name).To(Equal("unreachable-driver"))
+			})
+
+			It("filters the drivers by transport", func() {
+				drivers, err := client.ListDriversMatching(context.Background(), logger, volman.DriverFilter{Transport: volman.TransportUnix})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drivers.Drivers).To(HaveLen(1))
+				Expect(drivers.Drivers[0].Name).To(Equal("healthy-driver"))
+			})
+
+			It("reports unreachable drivers with their last successful activation", func() {
+				drivers, err := client.ListDrivers(context.Background(), logger)
+				Expect(err).NotTo(HaveOccurred())
+
+				info := driverNamed(drivers.Drivers, "unreachable-driver")
+				Expect(info.Transport).To(Equal(volman.TransportHTTPS))
+				Expect(info.Reachable).To(BeFalse())
+				Expect(info.Scope).To(BeEmpty())
+				Expect(info.LastActivation).To(Equal(time.Unix(42, 0)))
+			})
+		})
 	})
 
 	Describe("Mount and Unmount", func() {
 		var (
 			volumeId string
+			ledger   vollocal.MountLedger
 		)
 		BeforeEach(func() {
 			volumeId = "fake-volume"
+			ledger = vollocal.NewMountLedger(logger, "")
 		})
 		Context("when given a driver", func() {
 			var (
@@ -190,7 +296,7 @@ var _ = Describe("Volman", func() {
 				fakeDriver.ActivateReturns(dockerdriver.ActivateResponse{Implements: []string{"VolumeDriver"}})
 
 				dockerDriverDiscoverer = voldiscoverers.NewDockerDriverDiscovererWithDriverFactory(logger, driverRegistry, []string{defaultPluginsDirectory}, fakeDriverFactory)
-				client = vollocal.NewLocalClient(logger, driverRegistry, fakeMetronClient, fakeClock)
+				client = vollocal.NewLocalClientWithMountLedger(logger, driverRegistry, fakeMetronClient, fakeClock, ledger)
 
 			})
 
@@ -213,7 +319,7 @@ var _ = Describe("Volman", func() {
 				})
 
 				It("should be able to mount without warning", func() {
-					mountPath, err := client.Mount(logger, fakeDriverId, volumeId, "", map[string]interface{}{})
+					mountPath, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "", map[string]interface{}{})
 					Expect(err).NotTo(HaveOccurred())
 					Expect(mountPath).NotTo(Equal(""))
 					Expect(logger.Buffer()).NotTo(gbytes.Say("Invalid or dangerous mountpath"))
@@ -223,7 +329,7 @@ var _ = Describe("Volman", func() {
 					mountResponse := dockerdriver.MountResponse{Err: "an error"}
 					fakeDriver.MountReturns(mountResponse)
 
-					_, err := client.Mount(logger, fakeDriverId, volumeId, "", map[string]interface{}{})
+					_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "", map[string]interface{}{})
 					Expect(err).To(HaveOccurred())
 					_, isVolmanSafeError := err.(volman.SafeError)
 					Expect(isVolmanSafeError).To(Equal(false))
@@ -237,12 +343,46 @@ var _ = Describe("Volman", func() {
 					mountResponse := dockerdriver.MountResponse{Err: string(safeErrBytes[:])}
 					fakeDriver.MountReturns(mountResponse)
 
-					_, err = client.Mount(logger, fakeDriverId, volumeId, "", map[string]interface{}{})
+					_, err = client.Mount(context.Background(), logger, fakeDriverId, volumeId, "", map[string]interface{}{})
 					Expect(err).To(HaveOccurred())
 					_, isVolmanSafeError := err.(volman.SafeError)
 					Expect(isVolmanSafeError).To(Equal(true))
 				})
 
+				It("should record the mount in the ledger", func() {
+					_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "some-container-id", map[string]interface{}{"uid": "1000"})
+					Expect(err).NotTo(HaveOccurred())
+
+					record, found := ledger.Get(fakeDriverId, volumeId, "some-container-id")
+					Expect(found).To(BeTrue())
+					Expect(record.DriverVolumeId).To(Equal(volumeId))
+					Expect(record.Path).To(Equal("/var/vcap/data/mounts/" + volumeId))
+					Expect(record.ConfigHash).NotTo(BeEmpty())
+					Expect(record.MountedAt).To(Equal(fakeClock.Now()))
+				})
+
+				It("should not record the mount in the ledger if mount fails", func() {
+					fakeDriver.MountReturns(dockerdriver.MountResponse{Err: "an error"})
+
+					_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "some-container-id", map[string]interface{}{})
+					Expect(err).To(HaveOccurred())
+					Expect(ledger.Records()).To(BeEmpty())
+				})
+
+				Context("when the ledger cannot record the mount", func() {
+					BeforeEach(func() {
+						fakeLedger := new(volmanfakes.FakeMountLedger)
+						fakeLedger.AddReturns(fmt.Errorf("disk full"))
+						client = vollocal.NewLocalClientWithMountLedger(logger, driverRegistry, fakeMetronClient, fakeClock, fakeLedger)
+					})
+
+					It("should log the error and still succeed", func() {
+						_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "some-container-id", map[string]interface{}{})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(logger.TestSink.LogMessages()).To(ContainElement("client-test.mount.failed-recording-mount"))
+					})
+				})
+
 				Context("with bad mount path", func() {
 					var err error
 					BeforeEach(func() {
@@ -251,7 +391,7 @@ var _ = Describe("Volman", func() {
 					})
 
 					JustBeforeEach(func() {
-						_, err = client.Mount(logger, fakeDriverId, volumeId, "", map[string]interface{}{})
+						_, err = client.Mount(context.Background(), logger, fakeDriverId, volumeId, "", map[string]interface{}{})
 					})
 
 					It("should return a warning in the log", func() {
@@ -263,7 +403,7 @@ var _ = Describe("Volman", func() {
 				Context("with metrics", func() {
 					It("should emit mount time on successful mount", func() {
 
-						client.Mount(logger, fakeDriverId, volumeId, "", map[string]interface{}{"volume_id": volumeId})
+						client.Mount(context.Background(), logger, fakeDriverId, volumeId, "", map[string]interface{}{"volume_id": volumeId})
 
 						Eventually(durationMetricMap).Should(HaveKeyWithValue("VolmanMountDuration", Not(BeZero())))
 						Eventually(durationMetricMap).Should(HaveKeyWithValue(fmt.Sprintf("VolmanMountDurationFor%s", fakeDriverId), Not(BeZero())))
@@ -274,19 +414,138 @@ var _ = Describe("Volman", func() {
 						mountResponse := dockerdriver.MountResponse{Err: "an error"}
 						fakeDriver.MountReturns(mountResponse)
 
-						client.Mount(logger, fakeDriverId, volumeId, "", map[string]interface{}{"volume_id": volumeId})
+						client.Mount(context.Background(), logger, fakeDriverId, volumeId, "", map[string]interface{}{"volume_id": volumeId})
 						Expect(counterMetricMap).Should(HaveKeyWithValue("VolmanMountErrors", 1))
 					})
 				})
 
+				Context("when several containers mount the same volume", func() {
+					JustBeforeEach(func() {
+						_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-a", map[string]interface{}{})
+						Expect(err).NotTo(HaveOccurred())
+						_, err = client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-b", map[string]interface{}{})
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("should only mount the volume on the driver once", func() {
+						Expect(fakeDriver.MountCallCount()).To(Equal(1))
+
+						record, found := ledger.Get(fakeDriverId, volumeId, "container-b")
+						Expect(found).To(BeTrue())
+						Expect(record.Path).To(Equal("/var/vcap/data/mounts/" + volumeId))
+						Expect(fakeDriver.ListCallCount()).To(Equal(0))
+					})
+
+					It("should report both containers as holders", func() {
+						holders := client.(vollocal.VolumeHolders).Holders(fakeDriverId, volumeId)
+						Expect(holders).To(Equal([]string{"container-a", "container-b"}))
+					})
+
+					It("should only unmount the volume on the driver when the last container releases it", func() {
+						err := client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "container-a")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(fakeDriver.UnmountCallCount()).To(Equal(0))
+						Expect(client.(vollocal.VolumeHolders).Holders(fakeDriverId, volumeId)).To(Equal([]string{"container-b"}))
+
+						err = client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "container-b")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(fakeDriver.UnmountCallCount()).To(Equal(1))
+						Expect(client.(vollocal.VolumeHolders).Holders(fakeDriverId, volumeId)).To(BeEmpty())
+					})
+
+					It("should refuse to remount the volume with a new config while it is shared", func() {
+						config := vollocal.NewDriverConfig()
+						config.RemountOnConfigChange = true
+						client = vollocal.NewLocalClientWithConfig(logger, driverRegistry, fakeMetronClient, fakeClock, ledger, config)
+
+						_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-a", map[string]interface{}{"uid": "2000"})
+						Expect(err).To(Equal(volman.SafeError{SafeDescription: "volume fake-volume is shared with other containers and cannot be remounted with a different configuration"}))
+
+						Expect(fakeDriver.MountCallCount()).To(Equal(1))
+						Expect(fakeDriver.UnmountCallCount()).To(Equal(0))
+						Expect(client.(vollocal.VolumeHolders).Holders(fakeDriverId, volumeId)).To(Equal([]string{"container-a", "container-b"}))
+					})
+
+					It("should not unmount the volume on the driver for a container that does not hold it", func() {
+						err := client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "container-c")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(fakeDriver.UnmountCallCount()).To(Equal(0))
+						Expect(client.(vollocal.VolumeHolders).Holders(fakeDriverId, volumeId)).To(HaveLen(2))
+					})
+				})
+
+				Context("when the same container mounts the volume again", func() {
+					var firstRecord vollocal.MountRecord
+
+					JustBeforeEach(func() {
+						_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-a", map[string]interface{}{"uid": "1000"})
+						Expect(err).NotTo(HaveOccurred())
+
+						var found bool
+						firstRecord, found = ledger.Get(fakeDriverId, volumeId, "container-a")
+						Expect(found).To(BeTrue())
+					})
+
+					It("should return the existing mount without calling the driver", func() {
+						mountResponse, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-a", map[string]interface{}{"uid": "1000"})
+						Expect(err).NotTo(HaveOccurred())
+						Expect(mountResponse.Path).To(Equal("/var/vcap/data/mounts/" + volumeId))
+
+						Expect(fakeDriver.CreateCallCount()).To(Equal(1))
+						Expect(fakeDriver.MountCallCount()).To(Equal(1))
+						Expect(fakeDriver.ListCallCount()).To(Equal(0))
+						Expect(logger.TestSink.LogMessages()).To(ContainElement("client-test.mount.reusing-existing-mount"))
+					})
+
+					It("should reject a mount with a different config", func() {
+						_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-a", map[string]interface{}{"uid": "2000"})
+						Expect(err).To(Equal(volman.SafeError{SafeDescription: "volume fake-volume is already mounted for this container with a different configuration"}))
+
+						Expect(fakeDriver.MountCallCount()).To(Equal(1))
+						Expect(fakeDriver.UnmountCallCount()).To(Equal(0))
+						record, found := ledger.Get(fakeDriverId, volumeId, "container-a")
+						Expect(found).To(BeTrue())
+						Expect(record).To(Equal(firstRecord))
+					})
+
+					Context("when remounting on config changes is enabled", func() {
+						BeforeEach(func() {
+							config := vollocal.NewDriverConfig()
+							config.RemountOnConfigChange = true
+							client = vollocal.NewLocalClientWithConfig(logger, driverRegistry, fakeMetronClient, fakeClock, ledger, config)
+						})
+
+						It("should unmount and mount the volume with the new config", func() {
+							_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-a", map[string]interface{}{"uid": "2000"})
+							Expect(err).NotTo(HaveOccurred())
+
+							Expect(fakeDriver.UnmountCallCount()).To(Equal(1))
+							Expect(fakeDriver.MountCallCount()).To(Equal(2))
+
+							record, found := ledger.Get(fakeDriverId, volumeId, "container-a")
+							Expect(found).To(BeTrue())
+							Expect(record.ConfigHash).NotTo(Equal(firstRecord.ConfigHash))
+						})
+					})
+				})
+
 				Context("when UniqueVolumeIds is set", func() {
 					BeforeEach(func() {
 						driverSpecExtension = "json"
 						driverSpecContents = []byte(`{"Addr":"http://0.0.0.0:8080","UniqueVolumeIds": true}`)
 					})
 
+					It("should mount the volume on the driver for each container", func() {
+						_, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-a", map[string]interface{}{})
+						Expect(err).NotTo(HaveOccurred())
+						_, err = client.Mount(context.Background(), logger, fakeDriverId, volumeId, "container-b", map[string]interface{}{})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeDriver.MountCallCount()).To(Equal(2))
+					})
+
 					It("should append the container ID to the volume ID passed to the plugin's Mount() call", func() {
-						mountResponse, err := client.Mount(logger, fakeDriverId, volumeId, "some-container-id", map[string]interface{}{})
+						mountResponse, err := client.Mount(context.Background(), logger, fakeDriverId, volumeId, "some-container-id", map[string]interface{}{})
 						Expect(err).NotTo(HaveOccurred())
 						Expect(mountResponse.Path).To(Equal("/var/vcap/data/mounts/" + volumeId))
 
@@ -300,15 +559,35 @@ var _ = Describe("Volman", func() {
 
 			Context("umount", func() {
 				It("should be able to unmount", func() {
-					err := client.Unmount(logger, fakeDriverId, volumeId, "")
+					err := client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "")
 					Expect(err).NotTo(HaveOccurred())
 					Expect(fakeDriver.UnmountCallCount()).To(Equal(1))
 					Expect(fakeDriver.RemoveCallCount()).To(Equal(0))
 				})
 
+				Context("when the mount is recorded in the ledger", func() {
+					BeforeEach(func() {
+						err := ledger.Add(logger, vollocal.MountRecord{DriverId: fakeDriverId, VolumeId: volumeId, ContainerId: "some-container-id"})
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("should remove the mount from the ledger", func() {
+						err := client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "some-container-id")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(ledger.Records()).To(BeEmpty())
+					})
+
+					It("should keep the mount in the ledger when driver unmount fails", func() {
+						fakeDriver.UnmountReturns(dockerdriver.ErrorResponse{Err: "unmount failure"})
+						err := client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "some-container-id")
+						Expect(err).To(HaveOccurred())
+						Expect(ledger.Records()).To(HaveLen(1))
+					})
+				})
+
 				It("should not be able to unmount when driver unmount fails", func() {
 					fakeDriver.UnmountReturns(dockerdriver.ErrorResponse{Err: "unmount failure"})
-					err := client.Unmount(logger, fakeDriverId, volumeId, "")
+					err := client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "")
 					Expect(err).To(HaveOccurred())
 
 					_, isVolmanSafeError := err.(volman.SafeError)
@@ -322,7 +601,7 @@ var _ = Describe("Volman", func() {
 					unmountResponse := dockerdriver.ErrorResponse{Err: string(safeErrBytes[:])}
 					fakeDriver.UnmountReturns(unmountResponse)
 
-					err = client.Unmount(logger, fakeDriverId, volumeId, "")
+					err = client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "")
 					Expect(err).To(HaveOccurred())
 					_, isVolmanSafeError := err.(volman.SafeError)
 					Expect(isVolmanSafeError).To(Equal(true))
@@ -330,7 +609,7 @@ var _ = Describe("Volman", func() {
 
 				Context("with metrics", func() {
 					It("should emit unmount time on successful unmount", func() {
-						client.Unmount(logger, fakeDriverId, volumeId, "")
+						client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "")
 
 						Eventually(durationMetricMap).Should(HaveKeyWithValue("VolmanUnmountDuration", Not(BeZero())))
 						Eventually(durationMetricMap).Should(HaveKeyWithValue(fmt.Sprintf("VolmanUnmountDurationFor%s", fakeDriverId), Not(BeZero())))
@@ -339,7 +618,7 @@ var _ = Describe("Volman", func() {
 					It("should increment error count on unmount failure", func() {
 						fakeDriver.UnmountReturns(dockerdriver.ErrorResponse{Err: "unmount failure"})
 
-						client.Unmount(logger, fakeDriverId, volumeId, "")
+						client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "")
 						Expect(counterMetricMap).Should(HaveKeyWithValue("VolmanUnmountErrors", 1))
 					})
 				})
@@ -351,7 +630,7 @@ var _ = Describe("Volman", func() {
 					})
 
 					It("should append the container ID to the volume ID passed to the plugin's Unmount() call", func() {
-						err := client.Unmount(logger, fakeDriverId, volumeId, "some-container-id")
+						err := client.Unmount(context.Background(), logger, fakeDriverId, volumeId, "some-container-id")
 						Expect(err).NotTo(HaveOccurred())
 
 						Expect(fakeDriver.UnmountCallCount()).To(Equal(1))
@@ -368,12 +647,12 @@ var _ = Describe("Volman", func() {
 				})
 
 				It("should not be able to mount", func() {
-					_, err := client.Mount(logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
+					_, err := client.Mount(context.Background(), logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
 					Expect(err).To(HaveOccurred())
 				})
 
 				It("should not be able to unmount", func() {
-					err := client.Unmount(logger, fakeDriverId, "fake-volume", "")
+					err := client.Unmount(context.Background(), logger, fakeDriverId, "fake-volume", "")
 					Expect(err).To(HaveOccurred())
 				})
 			})
@@ -384,12 +663,12 @@ var _ = Describe("Volman", func() {
 				})
 
 				It("should not be able to mount", func() {
-					_, err := client.Mount(logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
+					_, err := client.Mount(context.Background(), logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
 					Expect(err).To(HaveOccurred())
 				})
 
 				It("should not be able to unmount", func() {
-					err := client.Unmount(logger, fakeDriverId, "fake-volume", "")
+					err := client.Unmount(context.Background(), logger, fakeDriverId, "fake-volume", "")
 					Expect(err).To(HaveOccurred())
 				})
 			})
@@ -428,7 +707,7 @@ var _ = Describe("Volman", func() {
 			})
 
 			It("should not be able to mount", func() {
-				_, err := client.Mount(logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
 				Expect(err).To(HaveOccurred())
 			})
 
@@ -457,10 +736,921 @@ var _ = Describe("Volman", func() {
 			})
 
 			It("should not be able to mount", func() {
-				_, err := client.Mount(logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "fake-volume", "", map[string]interface{}{})
+				Expect(err).To(HaveOccurred())
+			})
+
+		})
+	})
+
+	Describe("Driver timeouts", func() {
+		var (
+			fakePlugin *volmanfakes.FakePlugin
+			config     vollocal.DriverConfig
+			wedged     chan struct{}
+		)
+
+		BeforeEach(func() {
+			wedged = make(chan struct{})
+
+			fakePlugin = new(volmanfakes.FakePlugin)
+			fakePlugin.MountStub = func(ctx context.Context, _ lager.Logger, _ string, _ map[string]interface{}) (volman.MountResponse, error) {
+				<-ctx.Done()
+				return volman.MountResponse{}, ctx.Err()
+			}
+			unwedged := wedged
+			fakePlugin.UnmountStub = func(context.Context, lager.Logger, string) error {
+				<-unwedged
+				return nil
+			}
+
+			config = vollocal.NewDriverConfig()
+			config.Timeouts.Defaults = volman.OperationTimeouts{Mount: 10 * time.Millisecond, Unmount: 10 * time.Millisecond}
+		})
+
+		AfterEach(func() {
+			close(wedged)
+		})
+
+		JustBeforeEach(func() {
+			driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+			client = vollocal.NewLocalClientWithConfig(logger, driverRegistry, fakeMetronClient, fakeClock, vollocal.NewMountLedger(logger, ""), config)
+		})
+
+		It("returns a TimeoutError when a mount does not complete in time", func() {
+			_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+			Expect(err).To(Equal(volman.TimeoutError{DriverId: fakeDriverId, Operation: volman.OperationMount, Timeout: 10 * time.Millisecond}))
+			Expect(counterMetricMap).To(HaveKeyWithValue("VolmanMountTimeouts", 1))
+			Expect(counterMetricMap).To(HaveKeyWithValue("VolmanMountErrors", 1))
+		})
+
+		It("abandons an unmount whose driver ignores the context", func() {
+			err := client.Unmount(context.Background(), logger, fakeDriverId, "some-volume", "some-container")
+
+			var timeoutErr volman.TimeoutError
+			Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+			Expect(timeoutErr.Operation).To(Equal(volman.OperationUnmount))
+			Expect(counterMetricMap).To(HaveKeyWithValue("VolmanUnmountTimeouts", 1))
+		})
+
+		It("returns the caller's error when the caller's context ends first", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := client.Mount(ctx, logger, fakeDriverId, "some-volume", "some-container", nil)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(counterMetricMap).NotTo(HaveKey("VolmanMountTimeouts"))
+		})
+
+		It("does not time out calls that complete in time", func() {
+			fakePlugin.MountReturns(volman.MountResponse{Path: "/var/vcap/data/some-volume"}, nil)
+			fakePlugin.MountStub = nil
+
+			response, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Path).To(Equal("/var/vcap/data/some-volume"))
+		})
+
+		Context("when the driver declares a longer timeout in its spec", func() {
+			BeforeEach(func() {
+				fakePlugin.GetPluginSpecReturns(volman.PluginSpec{Timeouts: &volman.OperationTimeouts{Mount: time.Hour}})
+			})
+
+			It("waits for the driver", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+
+				_, err := client.Mount(ctx, logger, fakeDriverId, "some-volume", "some-container", nil)
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+			})
+
+			Context("and volman overrides it", func() {
+				BeforeEach(func() {
+					config.Timeouts.Drivers = map[string]volman.OperationTimeouts{fakeDriverId: {Mount: 20 * time.Millisecond}}
+				})
+
+				It("uses the volman override", func() {
+					_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+					Expect(err).To(Equal(volman.TimeoutError{DriverId: fakeDriverId, Operation: volman.OperationMount, Timeout: 20 * time.Millisecond}))
+				})
+			})
+		})
+
+		Context("when timeouts are disabled", func() {
+			BeforeEach(func() {
+				config.Timeouts = volman.TimeoutConfig{}
+			})
+
+			It("leaves the call bounded only by the caller", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				defer cancel()
+
+				_, err := client.Mount(ctx, logger, fakeDriverId, "some-volume", "some-container", nil)
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+			})
+		})
+	})
+
+	Describe("Driver retries", func() {
+		var (
+			fakePlugin   *volmanfakes.FakePlugin
+			config       vollocal.DriverConfig
+			transientErr error
+		)
+
+		BeforeEach(func() {
+			fakePlugin = new(volmanfakes.FakePlugin)
+			transientErr = errors.New("Post \"http://unix/VolumeDriver.Mount\": dial unix /var/vcap/data/voldrivers/some-driver.sock: connect: connection refused")
+
+			config = vollocal.NewDriverConfig()
+			config.Retry.Defaults = vollocal.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 4 * time.Second}
+		})
+
+		JustBeforeEach(func() {
+			driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+			client = vollocal.NewLocalClientWithConfig(logger, driverRegistry, fakeMetronClient, fakeClock, vollocal.NewMountLedger(logger, ""), config)
+		})
+
+		// advance keeps firing backoff timers until the call under test finishes.
+		advance := func(done chan struct{}) {
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Millisecond):
+					if fakeClock.WatcherCount() > 0 {
+						fakeClock.Increment(4 * time.Second)
+					}
+				}
+			}
+		}
+
+		mount := func(ctx context.Context) (volman.MountResponse, error) {
+			var (
+				response volman.MountResponse
+				err      error
+			)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				response, err = client.Mount(ctx, logger, fakeDriverId, "some-volume", "some-container", nil)
+				close(done)
+			}()
+			advance(done)
+			return response, err
+		}
+
+		Context("when the driver fails transiently and then recovers", func() {
+			BeforeEach(func() {
+				fakePlugin.MountReturnsOnCall(0, volman.MountResponse{}, transientErr)
+				fakePlugin.MountReturnsOnCall(1, volman.MountResponse{}, transientErr)
+				fakePlugin.MountReturnsOnCall(2, volman.MountResponse{Path: "/var/vcap/data/some-volume"}, nil)
+			})
+
+			It("retries the mount until it succeeds", func() {
+				response, err := mount(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.Path).To(Equal("/var/vcap/data/some-volume"))
+				Expect(fakePlugin.MountCallCount()).To(Equal(3))
+			})
+
+			It("logs and counts every retry", func() {
+				_, err := mount(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(counterMetricMap).To(HaveKeyWithValue("VolmanMountRetries", 2))
+				Expect(counterMetricMap).NotTo(HaveKey("VolmanMountErrors"))
+				Expect(logger.LogMessages()).To(ContainElement("client-test.mount.retrying-mount"))
+			})
+		})
+
+		It("gives up after the maximum number of attempts", func() {
+			fakePlugin.MountReturns(volman.MountResponse{}, transientErr)
+
+			_, err := mount(context.Background())
+			Expect(err).To(Equal(transientErr))
+			Expect(fakePlugin.MountCallCount()).To(Equal(3))
+			Expect(counterMetricMap).To(HaveKeyWithValue("VolmanMountErrors", 1))
+		})
+
+		It("never retries a safe error", func() {
+			fakePlugin.MountReturns(volman.MountResponse{}, dockerdriver.SafeError{SafeDescription: "connection refused by the share"})
+
+			_, err := mount(context.Background())
+			Expect(err).To(Equal(volman.SafeError{SafeDescription: "connection refused by the share"}))
+			Expect(fakePlugin.MountCallCount()).To(Equal(1))
+		})
+
+		It("does not retry errors that are not transient", func() {
+			fakePlugin.MountReturns(volman.MountResponse{}, errors.New("permission denied"))
+
+			_, err := mount(context.Background())
+			Expect(err).To(MatchError("permission denied"))
+			Expect(fakePlugin.MountCallCount()).To(Equal(1))
+		})
+
+		It("stops retrying once the caller's context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			driverErr := transientErr
+			fakePlugin.MountStub = func(context.Context, lager.Logger, string, map[string]interface{}) (volman.MountResponse, error) {
+				cancel()
+				return volman.MountResponse{}, driverErr
+			}
+
+			_, err := client.Mount(ctx, logger, fakeDriverId, "some-volume", "some-container", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(fakePlugin.MountCallCount()).To(Equal(1))
+			Expect(counterMetricMap["VolmanMountRetries"]).To(BeNumerically("<=", 1))
+		})
+
+		Context("when the driver has its own retry policy", func() {
+			BeforeEach(func() {
+				config.Retry.Drivers = map[string]vollocal.RetryPolicy{fakeDriverId: {MaxAttempts: 1}}
+				fakePlugin.MountReturns(volman.MountResponse{}, transientErr)
+			})
+
+			It("uses the driver's policy", func() {
+				_, err := mount(context.Background())
+				Expect(err).To(Equal(transientErr))
+				Expect(fakePlugin.MountCallCount()).To(Equal(1))
+			})
+		})
+
+		It("retries transient unmount failures", func() {
+			fakePlugin.MountReturns(volman.MountResponse{Path: "/var/vcap/data/some-volume"}, nil)
+			fakePlugin.UnmountReturnsOnCall(0, transientErr)
+			fakePlugin.UnmountReturnsOnCall(1, nil)
+
+			_, err := mount(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				err = client.Unmount(context.Background(), logger, fakeDriverId, "some-volume", "some-container")
+				close(done)
+			}()
+			advance(done)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(2))
+			Expect(counterMetricMap).To(HaveKeyWithValue("VolmanUnmountRetries", 1))
+		})
+	})
+
+	Describe("Circuit breakers", func() {
+		var fakePlugin *volmanfakes.FakePlugin
+
+		BeforeEach(func() {
+			fakePlugin = new(volmanfakes.FakePlugin)
+			fakePlugin.MountReturns(volman.MountResponse{}, errors.New("connection refused"))
+
+			breakers := vollocal.NewCircuitBreakerRegistry(
+				vollocal.NewPluginRegistryWith(map[string]volman.Plugin{fakeDriverId: fakePlugin}),
+				fakeMetronClient,
+				fakeClock,
+				vollocal.PerDriver[vollocal.CircuitBreakerPolicy]{Defaults: vollocal.CircuitBreakerPolicy{FailureThreshold: 3, OpenDuration: time.Minute}},
+			)
+			config := vollocal.NewDriverConfig()
+			config.Retry = vollocal.PerDriver[vollocal.RetryPolicy]{}
+			client = vollocal.NewLocalClientWithConfig(logger, breakers, fakeMetronClient, fakeClock, vollocal.NewMountLedger(logger, ""), config)
+		})
+
+		It("returns a safe error to the caller of Mount once the circuit is open", func() {
+			for i := 0; i < 3; i++ {
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+				Expect(err).To(MatchError("connection refused"))
+			}
+
+			_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+			Expect(err).To(Equal(volman.DriverUnavailableError{SafeError: volman.SafeError{SafeDescription: "volume service " + fakeDriverId + " is currently unavailable, please try again later"}}))
+			Expect(fakePlugin.MountCallCount()).To(Equal(3))
+		})
+	})
+
+	Describe("Mount concurrency limits", func() {
+		var (
+			fakePlugin *volmanfakes.FakePlugin
+			config     vollocal.DriverConfig
+
+			release chan struct{}
+			mounted *mountOrder
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			mounted = &mountOrder{}
+
+			released, order := release, mounted
+			fakePlugin = new(volmanfakes.FakePlugin)
+			fakePlugin.MountStub = func(_ context.Context, _ lager.Logger, volumeId string, _ map[string]interface{}) (volman.MountResponse, error) {
+				order.add(volumeId)
+				<-released
+				return volman.MountResponse{Path: "/var/vcap/data/" + volumeId}, nil
+			}
+
+			config = vollocal.NewDriverConfig()
+			config.Concurrency.Defaults = vollocal.ConcurrencyLimit{MaxInFlight: 1, MaxQueued: 2}
+		})
+
+		JustBeforeEach(func() {
+			driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+			client = vollocal.NewLocalClientWithConfig(logger, driverRegistry, fakeMetronClient, fakeClock, vollocal.NewMountLedger(logger, ""), config)
+		})
+
+		mountedVolumes := func() []string {
+			return mounted.volumes()
+		}
+
+		mountInBackground := func(ctx context.Context, volumeId string) chan error {
+			result := make(chan error, 1)
+			go func() {
+				_, err := client.Mount(ctx, logger, fakeDriverId, volumeId, "container-"+volumeId, nil)
+				result <- err
+			}()
+			return result
+		}
+
+		It("holds back mounts beyond the limit and runs them in arrival order", func() {
+			first := mountInBackground(context.Background(), "volume-1")
+			Eventually(mountedVolumes).Should(Equal([]string{"volume-1"}))
+
+			second := mountInBackground(context.Background(), "volume-2")
+			Eventually(logger).Should(gbytes.Say(`mount-queued.*"position":1`))
+			third := mountInBackground(context.Background(), "volume-3")
+			Eventually(logger).Should(gbytes.Say(`mount-queued.*"position":2`))
+
+			Consistently(mountedVolumes).Should(Equal([]string{"volume-1"}))
+
+			close(release)
+			Eventually(first).Should(Receive(BeNil()))
+			Eventually(second).Should(Receive(BeNil()))
+			Eventually(third).Should(Receive(BeNil()))
+			Expect(mountedVolumes()).To(Equal([]string{"volume-1", "volume-2", "volume-3"}))
+		})
+
+		It("rejects mounts with a clear error when the queue is full", func() {
+			mountInBackground(context.Background(), "volume-1")
+			Eventually(mountedVolumes).Should(HaveLen(1))
+			mountInBackground(context.Background(), "volume-2")
+			mountInBackground(context.Background(), "volume-3")
+			Eventually(logger).Should(gbytes.Say(`mount-queued.*"position":2`))
+
+			_, err := client.Mount(context.Background(), logger, fakeDriverId, "volume-4", "container-4", nil)
+			Expect(err).To(Equal(volman.DriverBusyError{SafeError: volman.SafeError{SafeDescription: "volume service " + fakeDriverId + " has too many mounts in progress, please try again later"}}))
+			Expect(fakeMetronClient.IncrementCounterArgsForCall(fakeMetronClient.IncrementCounterCallCount() - 1)).To(Equal("VolmanMountsRejected"))
+
+			close(release)
+		})
+
+		It("records how long each mount waited in the queue", func() {
+			first := mountInBackground(context.Background(), "volume-1")
+			Eventually(mountedVolumes).Should(HaveLen(1))
+			second := mountInBackground(context.Background(), "volume-2")
+			Eventually(logger).Should(gbytes.Say("mount-queued"))
+
+			fakeClock.Increment(3 * time.Second)
+			close(release)
+			Eventually(first).Should(Receive(BeNil()))
+			Eventually(second).Should(Receive(BeNil()))
+
+			var waits []time.Duration
+			for i := 0; i < fakeMetronClient.SendDurationCallCount(); i++ {
+				name, duration, _ := fakeMetronClient.SendDurationArgsForCall(i)
+				if name == "VolmanMountQueueWaitDuration" {
+					waits = append(waits, duration)
+				}
+			}
+			Expect(waits).To(ConsistOf(time.Duration(0), 3*time.Second))
+		})
+
+		It("gives up the place in the queue when the caller's context ends", func() {
+			mountInBackground(context.Background(), "volume-1")
+			Eventually(mountedVolumes).Should(HaveLen(1))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			abandoned := mountInBackground(ctx, "volume-2")
+			Eventually(logger).Should(gbytes.Say("mount-queued"))
+			cancel()
+			Eventually(abandoned).Should(Receive(MatchError(context.Canceled)))
+
+			third := mountInBackground(context.Background(), "volume-3")
+			close(release)
+			Eventually(third).Should(Receive(BeNil()))
+			Expect(mountedVolumes()).To(Equal([]string{"volume-1", "volume-3"}))
+		})
+
+		Context("when a driver has its own limit", func() {
+			BeforeEach(func() {
+				config.Concurrency.Drivers = map[string]vollocal.ConcurrencyLimit{fakeDriverId: {}}
+			})
+
+			It("applies it instead of the default", func() {
+				mountInBackground(context.Background(), "volume-1")
+				mountInBackground(context.Background(), "volume-2")
+				mountInBackground(context.Background(), "volume-3")
+				mountInBackground(context.Background(), "volume-4")
+
+				Eventually(mountedVolumes).Should(HaveLen(4))
+				close(release)
+			})
+		})
+	})
+
+	Describe("Per-volume serialization", func() {
+		var (
+			fakePlugin *volmanfakes.FakePlugin
+
+			release chan struct{}
+			events  *driverEvents
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			events = &driverEvents{}
+
+			released, recorded := release, events
+			fakePlugin = new(volmanfakes.FakePlugin)
+			fakePlugin.MountStub = func(_ context.Context, _ lager.Logger, volumeId string, _ map[string]interface{}) (volman.MountResponse, error) {
+				recorded.add("mount-started " + volumeId)
+				<-released
+				recorded.add("mount-finished " + volumeId)
+				return volman.MountResponse{Path: "/var/vcap/data/" + volumeId}, nil
+			}
+			fakePlugin.UnmountStub = func(_ context.Context, _ lager.Logger, volumeId string) error {
+				recorded.add("unmount " + volumeId)
+				return nil
+			}
+		})
+
+		JustBeforeEach(func() {
+			driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+			client = vollocal.NewLocalClient(logger, driverRegistry, fakeMetronClient, fakeClock)
+		})
+
+		inBackground := func(operation func() error) chan error {
+			result := make(chan error, 1)
+			go func() {
+				result <- operation()
+			}()
+			return result
+		}
+
+		mount := func(ctx context.Context, volumeId string, containerId string) func() error {
+			return func() error {
+				_, err := client.Mount(ctx, logger, fakeDriverId, volumeId, containerId, nil)
+				return err
+			}
+		}
+
+		unmount := func(ctx context.Context, volumeId string, containerId string) func() error {
+			return func() error {
+				return client.Unmount(ctx, logger, fakeDriverId, volumeId, containerId)
+			}
+		}
+
+		It("holds an unmount back until a mount of the same volume has finished", func() {
+			mounted := inBackground(mount(context.Background(), "some-volume", "old-container"))
+			Eventually(events.list).Should(Equal([]string{"mount-started some-volume"}))
+
+			unmounted := inBackground(unmount(context.Background(), "some-volume", "old-container"))
+			Eventually(logger).Should(gbytes.Say("waiting-for-volume"))
+			Consistently(events.list).Should(HaveLen(1))
+
+			close(release)
+			Eventually(mounted).Should(Receive(BeNil()))
+			Eventually(unmounted).Should(Receive(BeNil()))
+			Expect(events.list()).To(Equal([]string{
+				"mount-started some-volume",
+				"mount-finished some-volume",
+				"unmount some-volume",
+			}))
+		})
+
+		It("lets operations on unrelated volumes run in parallel", func() {
+			first := inBackground(mount(context.Background(), "volume-1", "container-1"))
+			second := inBackground(mount(context.Background(), "volume-2", "container-2"))
+
+			Eventually(events.list).Should(ConsistOf("mount-started volume-1", "mount-started volume-2"))
+
+			close(release)
+			Eventually(first).Should(Receive(BeNil()))
+			Eventually(second).Should(Receive(BeNil()))
+		})
+
+		It("serializes containers sharing a volume, so that only the first mounts it", func() {
+			first := inBackground(mount(context.Background(), "some-volume", "container-1"))
+			Eventually(events.list).Should(HaveLen(1))
+			second := inBackground(mount(context.Background(), "some-volume", "container-2"))
+			Eventually(logger).Should(gbytes.Say("waiting-for-volume"))
+
+			close(release)
+			Eventually(first).Should(Receive(BeNil()))
+			Eventually(second).Should(Receive(BeNil()))
+			Expect(fakePlugin.MountCallCount()).To(Equal(1))
+		})
+
+		It("gives up waiting when the caller's context ends", func() {
+			inBackground(mount(context.Background(), "some-volume", "old-container"))
+			Eventually(events.list).Should(HaveLen(1))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			unmounted := inBackground(unmount(ctx, "some-volume", "old-container"))
+			Eventually(logger).Should(gbytes.Say("waiting-for-volume"))
+			cancel()
+
+			Eventually(unmounted).Should(Receive(MatchError(context.Canceled)))
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			close(release)
+		})
+
+		Context("when the driver uses unique volume ids", func() {
+			BeforeEach(func() {
+				fakePlugin.GetPluginSpecReturns(volman.PluginSpec{UniqueVolumeIds: true})
+			})
+
+			It("lets containers mount the same volume in parallel", func() {
+				first := inBackground(mount(context.Background(), "some-volume", "container-1"))
+				second := inBackground(mount(context.Background(), "some-volume", "container-2"))
+
+				Eventually(events.list).Should(ConsistOf("mount-started some-volume_container-1", "mount-started some-volume_container-2"))
+
+				close(release)
+				Eventually(first).Should(Receive(BeNil()))
+				Eventually(second).Should(Receive(BeNil()))
+			})
+
+			It("still serializes operations for the same container", func() {
+				mounted := inBackground(mount(context.Background(), "some-volume", "container-1"))
+				Eventually(events.list).Should(HaveLen(1))
+				unmounted := inBackground(unmount(context.Background(), "some-volume", "container-1"))
+				Eventually(logger).Should(gbytes.Say("waiting-for-volume"))
+
+				close(release)
+				Eventually(mounted).Should(Receive(BeNil()))
+				Eventually(unmounted).Should(Receive(BeNil()))
+				Expect(events.list()).To(Equal([]string{
+					"mount-started some-volume_container-1",
+					"mount-finished some-volume_container-1",
+					"unmount some-volume_container-1",
+				}))
+			})
+		})
+	})
+
+	Describe("Mount config validation", func() {
+		var fakePlugin *volmanfakes.FakePlugin
+
+		BeforeEach(func() {
+			forbidden := false
+			fakePlugin = new(volmanfakes.FakePlugin)
+			fakePlugin.GetPluginSpecReturns(volman.PluginSpec{ConfigSchema: &volman.ConfigSchema{
+				Type:                 "object",
+				Properties:           map[string]*volman.ConfigSchema{"uid": {Type: "integer"}, "gid": {Type: "integer"}},
+				AdditionalProperties: &forbidden,
+			}})
+			fakePlugin.MountReturns(volman.MountResponse{Path: "/var/vcap/data/some-volume"}, nil)
+
+			driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+			client = vollocal.NewLocalClient(logger, driverRegistry, fakeMetronClient, fakeClock)
+		})
+
+		It("mounts configs that match the driver's schema", func() {
+			_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", map[string]interface{}{"uid": float64(1000)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakePlugin.MountCallCount()).To(Equal(1))
+		})
+
+		It("rejects other configs with a safe error naming the offending keys", func() {
+			_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", map[string]interface{}{"uid": "1000", "gdi": float64(1000)})
+			Expect(err).To(Equal(volman.SafeError{SafeDescription: "invalid mount config for volume service " + fakeDriverId + ": gdi unknown option, uid expected integer"}))
+
+			Expect(fakePlugin.MountCallCount()).To(Equal(0))
+			Expect(counterMetricMap).To(HaveKeyWithValue("VolmanMountErrors", 1))
+		})
+	})
+
+	Describe("Mount verification", func() {
+		var (
+			fakePlugin    *volmanfakes.FakePlugin
+			fakeMountInfo *volmanfakes.FakeMountInfoReader
+			ledger        vollocal.MountLedger
+			config        vollocal.DriverConfig
+			mountpoint    string
+			mountResponse volman.MountResponse
+			err           error
+		)
+
+		BeforeEach(func() {
+			fakeMountInfo = new(volmanfakes.FakeMountInfoReader)
+			fakeMountInfo.MountInfoReturns([]byte(sampleMountInfo), nil)
+			ledger = vollocal.NewMountLedger(logger, "")
+			mountpoint = "/var/vcap/data/volumes/nfs/some-volume"
+
+			fakePlugin = new(volmanfakes.FakePlugin)
+
+			config = vollocal.NewDriverConfig()
+			config.MountVerification.Defaults = vollocal.MountVerificationPolicy{Enabled: true, FilesystemTypes: []string{"nfs", "nfs4"}}
+			config.MountInfoReader = fakeMountInfo
+		})
+
+		JustBeforeEach(func() {
+			fakePlugin.MountReturns(volman.MountResponse{Path: mountpoint}, nil)
+			driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+			client = vollocal.NewLocalClientWithConfig(logger, driverRegistry, fakeMetronClient, fakeClock, ledger, config)
+			mountResponse, err = client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+		})
+
+		It("returns mounts of the expected filesystem type", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mountResponse.Path).To(Equal(mountpoint))
+			Expect(fakeMountInfo.MountInfoCallCount()).To(Equal(1))
+		})
+
+		Context("when the container mounts the volume again", func() {
+			It("checks the recorded mount against the mount table rather than asking the driver", func() {
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakePlugin.MountCallCount()).To(Equal(1))
+				Expect(fakePlugin.ListVolumesCallCount()).To(Equal(0))
+				Expect(fakeMountInfo.MountInfoCallCount()).To(Equal(2))
+			})
+
+			It("mounts the volume again once it is no longer in the mount table", func() {
+				fakeMountInfo.MountInfoReturns([]byte(""), nil)
+
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
 				Expect(err).To(HaveOccurred())
+				Expect(fakePlugin.MountCallCount()).To(Equal(2))
+				Expect(logger).To(gbytes.Say("recorded-mount-lost"))
 			})
 
+			It("fails the mount, keeping the records, when the volume is shared with other containers", func() {
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "other-container", nil)
+				Expect(err).NotTo(HaveOccurred())
+				fakeMountInfo.MountInfoReturns([]byte(""), nil)
+
+				_, err = client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", nil)
+				Expect(err).To(Equal(volman.SafeError{SafeDescription: "volume some-volume is shared with other containers and is no longer mounted"}))
+				Expect(fakePlugin.MountCallCount()).To(Equal(1))
+				Expect(ledger.Records()).To(HaveLen(2))
+			})
+		})
+
+		Context("when another container mounts the volume", func() {
+			It("fails the mount, keeping the records, once the shared mount is no longer in the mount table", func() {
+				fakeMountInfo.MountInfoReturns([]byte(""), nil)
+
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "other-container", nil)
+				Expect(err).To(Equal(volman.SafeError{SafeDescription: "volume some-volume is shared with other containers and is no longer mounted"}))
+				Expect(fakePlugin.MountCallCount()).To(Equal(1))
+				Expect(fakePlugin.ListVolumesCallCount()).To(Equal(0))
+				Expect(ledger.Records()).To(HaveLen(1))
+			})
+		})
+
+		Context("when the driver returns a plain directory", func() {
+			BeforeEach(func() {
+				mountpoint = "/var/vcap/data/volumes/nfs/not-mounted"
+			})
+
+			It("fails the mount with a safe error", func() {
+				Expect(err).To(MatchError(volman.SafeError{SafeDescription: "volume service " + fakeDriverId + " did not mount volume some-volume: /var/vcap/data/volumes/nfs/not-mounted is not a mount point"}))
+				Expect(logger).To(gbytes.Say("mount-verification-failed"))
+				Expect(counterMetricMap).To(HaveKeyWithValue("VolmanMountErrors", 1))
+			})
+
+			It("asks the driver to unmount the volume and does not record it", func() {
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(1))
+				_, _, volumeId := fakePlugin.UnmountArgsForCall(0)
+				Expect(volumeId).To(Equal("some-volume"))
+				Expect(ledger.Records()).To(BeEmpty())
+			})
+		})
+
+		Context("when the driver mounts an unexpected filesystem type", func() {
+			BeforeEach(func() {
+				mountpoint = "/var/vcap/data/volumes/smb/with space"
+			})
+
+			It("fails the mount", func() {
+				Expect(err).To(MatchError("volume service " + fakeDriverId + " mounted volume some-volume as cifs, expected nfs or nfs4"))
+			})
+
+			Context("and the driver's own policy accepts it", func() {
+				BeforeEach(func() {
+					config.MountVerification.Drivers = map[string]vollocal.MountVerificationPolicy{fakeDriverId: {Enabled: true}}
+				})
+
+				It("succeeds", func() {
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+		})
+
+		Context("when the mount table cannot be read", func() {
+			BeforeEach(func() {
+				fakeMountInfo.MountInfoReturns(nil, errors.New("permission denied"))
+			})
+
+			It("fails the mount", func() {
+				Expect(err).To(MatchError(ContainSubstring("could not be verified")))
+				Expect(logger).To(gbytes.Say("failed-reading-mountinfo"))
+			})
+		})
+
+		Context("when verification is disabled", func() {
+			BeforeEach(func() {
+				config.MountVerification = vollocal.PerDriver[vollocal.MountVerificationPolicy]{}
+				mountpoint = "/var/vcap/data/volumes/nfs/not-mounted"
+			})
+
+			It("trusts the driver", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeMountInfo.MountInfoCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Draining drivers", func() {
+		var (
+			fakePlugin *volmanfakes.FakePlugin
+			ledger     vollocal.MountLedger
+		)
+
+		BeforeEach(func() {
+			fakePlugin = new(volmanfakes.FakePlugin)
+			fakePlugin.GetPluginSpecReturns(volman.PluginSpec{Name: fakeDriverId, Address: "/var/vcap/data/voldrivers/" + fakeDriverId + ".sock"})
+			fakePlugin.MountReturns(volman.MountResponse{Path: "/var/vcap/data/some-volume"}, nil)
+
+			driverRegistry = vollocal.NewPluginRegistryWithDrainTimeout(fakeClock, time.Minute)
+			driverRegistry.Set(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+
+			ledger = vollocal.NewMountLedger(logger, "")
+			client = vollocal.NewLocalClientWithMountLedger(logger, driverRegistry, fakeMetronClient, fakeClock, ledger)
+
+			_, err := client.Mount(context.Background(), logger, fakeDriverId, "some-volume", "some-container", map[string]interface{}{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when the driver disappears", func() {
+			BeforeEach(func() {
+				driverRegistry.Set(map[string]volman.Plugin{})
+			})
+
+			It("refuses new mounts with a safe error", func() {
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "other-volume", "some-container", map[string]interface{}{})
+				Expect(err).To(Equal(volman.SafeError{SafeDescription: "volume service " + fakeDriverId + " is being removed and no longer accepts new mounts"}))
+				Expect(fakePlugin.MountCallCount()).To(Equal(1))
+			})
+
+			It("unmounts the volumes it mounted through the last known driver", func() {
+				err := client.Unmount(context.Background(), logger, fakeDriverId, "some-volume", "some-container")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(1))
+				Expect(logger).To(gbytes.Say("unmounting-from-draining-driver"))
+				Expect(ledger.Records()).To(BeEmpty())
+			})
+
+			It("forgets the driver once it has drained", func() {
+				Expect(client.Unmount(context.Background(), logger, fakeDriverId, "some-volume", "some-container")).To(Succeed())
+				Expect(logger).To(gbytes.Say("driver-drained"))
+
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "other-volume", "some-container", map[string]interface{}{})
+				Expect(err).To(MatchError(ContainSubstring("not found in list of known plugins")))
+			})
+
+			It("does not unmount volumes it did not mount", func() {
+				err := client.Unmount(context.Background(), logger, fakeDriverId, "other-volume", "some-container")
+				Expect(err).To(MatchError(ContainSubstring("not found in list of known plugins")))
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			})
+
+			It("stops routing unmounts to the driver after the drain timeout", func() {
+				fakeClock.Increment(time.Minute)
+
+				err := client.Unmount(context.Background(), logger, fakeDriverId, "some-volume", "some-container")
+				Expect(err).To(MatchError(ContainSubstring("not found in list of known plugins")))
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the driver is marked for removal", func() {
+			BeforeEach(func() {
+				Expect(client.(vollocal.DriverDrainer).Drain(logger, fakeDriverId)).To(BeTrue())
+			})
+
+			It("keeps it out of the registry while it is still discovered", func() {
+				driverRegistry.Set(map[string]volman.Plugin{fakeDriverId: fakePlugin})
+
+				_, err := client.Mount(context.Background(), logger, fakeDriverId, "other-volume", "some-container", map[string]interface{}{})
+				Expect(err).To(BeAssignableToTypeOf(volman.SafeError{}))
+
+				Expect(client.Unmount(context.Background(), logger, fakeDriverId, "some-volume", "some-container")).To(Succeed())
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(1))
+			})
+
+			It("reports unknown drivers as not drained", func() {
+				Expect(client.(vollocal.DriverDrainer).Drain(logger, "unknown-driver")).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("ListMounts and GetMount", func() {
+		var ledger vollocal.MountLedger
+
+		BeforeEach(func() {
+			ledger = vollocal.NewMountLedger(logger, "")
+			for _, record := range []vollocal.MountRecord{
+				{DriverId: "driver-a", VolumeId: "volume-1", ContainerId: "container-x", DriverVolumeId: "volume-1", Path: "/mnt/volume-1", MountedAt: time.Unix(1, 0)},
+				{DriverId: "driver-a", VolumeId: "volume-1", ContainerId: "container-y", DriverVolumeId: "volume-1", Path: "/mnt/volume-1", MountedAt: time.Unix(2, 0)},
+				{DriverId: "driver-b", VolumeId: "volume-2", ContainerId: "container-x", DriverVolumeId: "volume-2", Path: "/mnt/volume-2", MountedAt: time.Unix(3, 0)},
+			} {
+				Expect(ledger.Add(logger, record)).To(Succeed())
+			}
+
+			client = vollocal.NewLocalClientWithMountLedger(logger, driverRegistry, fakeMetronClient, fakeClock, ledger)
+		})
+
+		It("lists every mount when no ids are given", func() {
+			response, err := client.ListMounts(context.Background(), logger, "", "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Mounts).To(HaveLen(3))
+		})
+
+		It("lists the mounts of a container", func() {
+			response, err := client.ListMounts(context.Background(), logger, "", "", "container-x")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Mounts).To(Equal([]volman.MountInfo{
+				{DriverId: "driver-a", VolumeId: "volume-1", ContainerId: "container-x", Path: "/mnt/volume-1", MountedAt: time.Unix(1, 0)},
+				{DriverId: "driver-b", VolumeId: "volume-2", ContainerId: "container-x", Path: "/mnt/volume-2", MountedAt: time.Unix(3, 0)},
+			}))
+		})
+
+		It("lists the containers using a volume", func() {
+			response, err := client.ListMounts(context.Background(), logger, "driver-a", "volume-1", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Mounts).To(HaveLen(2))
+			Expect(response.Mounts[0].ContainerId).To(Equal("container-x"))
+			Expect(response.Mounts[1].ContainerId).To(Equal("container-y"))
+		})
+
+		It("returns an empty list when nothing matches", func() {
+			response, err := client.ListMounts(context.Background(), logger, "", "", "container-z")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Mounts).To(BeEmpty())
+		})
+
+		It("gets a single mount", func() {
+			mount, err := client.GetMount(context.Background(), logger, "driver-a", "volume-1", "container-y")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mount).To(Equal(volman.MountInfo{DriverId: "driver-a", VolumeId: "volume-1", ContainerId: "container-y", Path: "/mnt/volume-1", MountedAt: time.Unix(2, 0)}))
+		})
+
+		It("returns ErrMountNotFound for an unknown mount", func() {
+			_, err := client.GetMount(context.Background(), logger, "driver-b", "volume-2", "container-y")
+			Expect(err).To(MatchError(volman.ErrMountNotFound))
 		})
 	})
 })
+
+// mountOrder records the order in which the driver was asked to mount volumes.
+type mountOrder struct {
+	mutex sync.Mutex
+	ids   []string
+}
+
+func (o *mountOrder) add(volumeId string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.ids = append(o.ids, volumeId)
+}
+
+func (o *mountOrder) volumes() []string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return append([]string{}, o.ids...)
+}
+
+// driverEvents records the calls a fake driver sees, in order.
+type driverEvents struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (e *driverEvents) add(event string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *driverEvents) list() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]string{}, e.events...)
+}
+
+const sampleMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+97 22 8:1 /vcap/data /var/vcap/data rw,relatime shared:2 - ext4 /dev/sda3 rw
+412 97 0:52 / /var/vcap/data/volumes/nfs/some-volume rw,relatime shared:240 - nfs4 server:/export rw,vers=4.1
+413 97 0:53 / /var/vcap/data/volumes/smb/with\040space rw,relatime - cifs //server/share rw
+`
@@ -0,0 +1,129 @@
+package vollocal
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+)
+
+const (
+	volmanMountQueueWaitDuration = "VolmanMountQueueWaitDuration"
+	volmanMountsRejectedCounter  = "VolmanMountsRejected"
+)
+
+// ConcurrencyLimit caps how many mounts volman sends to a driver at once. Mounts beyond
+// MaxInFlight wait in a FIFO queue of at most MaxQueued mounts, and are rejected when it
+// is full. A MaxInFlight of 0 leaves mounts unlimited.
+type ConcurrencyLimit struct {
+	MaxInFlight int
+	MaxQueued   int
+}
+
+// errQueueFull is returned by acquire when a mount can neither run nor queue.
+var errQueueFull = errors.New("mount queue is full")
+
+// mountLimiter is a semaphore that hands free slots to its waiters in arrival order.
+type mountLimiter struct {
+	limit ConcurrencyLimit
+
+	mutex    sync.Mutex
+	inFlight int
+	waiters  *list.List
+}
+
+func newMountLimiter(limit ConcurrencyLimit) *mountLimiter {
+	return &mountLimiter{limit: limit, waiters: list.New()}
+}
+
+// acquire waits for a slot, giving up when ctx is done or straight away when the queue
+// is full. Every successful acquire must be paired with a release.
+func (l *mountLimiter) acquire(ctx context.Context, logger lager.Logger) error {
+	l.mutex.Lock()
+	if l.inFlight < l.limit.MaxInFlight && l.waiters.Len() == 0 {
+		l.inFlight++
+		l.mutex.Unlock()
+		return nil
+	}
+	if l.waiters.Len() >= l.limit.MaxQueued {
+		l.mutex.Unlock()
+		return errQueueFull
+	}
+	ready := make(chan struct{})
+	waiter := l.waiters.PushBack(ready)
+	logger.Info("mount-queued", lager.Data{"position": l.waiters.Len(), "in-flight": l.inFlight})
+	l.mutex.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		select {
+		case <-ready:
+			// the slot was handed over just as ctx ended, so pass it on
+			l.releaseLocked()
+		default:
+			l.waiters.Remove(waiter)
+		}
+		return ctx.Err()
+	}
+}
+
+func (l *mountLimiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.releaseLocked()
+}
+
+// releaseLocked hands the slot to the longest waiting mount, if there is one.
+func (l *mountLimiter) releaseLocked() {
+	if front := l.waiters.Front(); front != nil {
+		l.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+	l.inFlight--
+}
+
+// acquireMountSlot waits for the driver's concurrency limit to admit a mount, recording
+// how long it queued. The returned release func must be called once the mount is done.
+func (client *localClient) acquireMountSlot(ctx context.Context, logger lager.Logger, pluginId string) (func(), error) {
+	limit := client.config.Concurrency.For(pluginId)
+	if limit.MaxInFlight <= 0 {
+		return func() {}, nil
+	}
+
+	client.limitersMutex.Lock()
+	limiter, found := client.limiters[pluginId]
+	if !found {
+		limiter = newMountLimiter(limit)
+		client.limiters[pluginId] = limiter
+	}
+	client.limitersMutex.Unlock()
+
+	queuedAt := client.clock.Now()
+	err := limiter.acquire(ctx, logger)
+	if err == errQueueFull {
+		logger.Info("mount-queue-full", lager.Data{"pluginId": pluginId, "max-in-flight": limit.MaxInFlight, "max-queued": limit.MaxQueued})
+		if metricErr := client.metronClient.IncrementCounter(volmanMountsRejectedCounter); metricErr != nil {
+			logger.Debug("failed-emitting-mount-rejected-metric", lager.Data{"error": metricErr})
+		}
+		return nil, volman.DriverBusyError{SafeError: volman.SafeError{SafeDescription: fmt.Sprintf("volume service %s has too many mounts in progress, please try again later", pluginId)}}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wait := client.clock.Since(queuedAt)
+	logger.Debug("mount-slot-acquired", lager.Data{"pluginId": pluginId, "queue-wait": wait.String()})
+	if metricErr := client.metronClient.SendDuration(volmanMountQueueWaitDuration, wait); metricErr != nil {
+		logger.Error("failed-to-send-volman-mount-queue-wait-metric", metricErr)
+	}
+	return limiter.release, nil
+}
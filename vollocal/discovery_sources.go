@@ -0,0 +1,118 @@
+package vollocal
+
+import (
+	"errors"
+	"fmt"
+
+	loggingclient "code.cloudfoundry.org/diego-logging-client"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/voldiscoverers"
+)
+
+// The kinds of discovery source that volman knows how to discover drivers from.
+const (
+	DiscoverySourceDocker   = "docker"
+	DiscoverySourceStatic   = "static"
+	DiscoverySourceManifest = "manifest"
+)
+
+// DiscoverySource is a place that drivers are discovered from. Kind picks the factory
+// that builds its discoverer, and each kind only reads the fields it needs.
+type DiscoverySource struct {
+	Kind string
+
+	// DriverPaths are the directories of driver spec files of a docker source.
+	DriverPaths []string
+	// Drivers are the drivers of a static source.
+	Drivers []volman.PluginSpec
+	// ManifestPath is the JSON manifest of drivers of a manifest source.
+	ManifestPath string
+	// Options configure the discoverers of kinds added through DiscovererFactories.
+	Options map[string]interface{}
+}
+
+// DiscovererEnv is what discoverer factories share with the discoverers they build, so
+// that every discoverer registers drivers under the same policies.
+type DiscovererEnv struct {
+	Logger           lager.Logger
+	Registry         volman.PluginRegistry
+	MetronClient     loggingclient.IngressClient
+	Timeouts         volman.TimeoutConfig
+	Gate             voldiscoverers.ActivationGate
+	MountpointPolicy volman.MountpointPolicy
+}
+
+// DiscovererFactory builds the discoverer of a discovery source.
+type DiscovererFactory func(env DiscovererEnv, source DiscoverySource) (volman.Discoverer, error)
+
+// DefaultDiscovererFactories returns the factories of the discovery source kinds that
+// volman knows about.
+func DefaultDiscovererFactories() map[string]DiscovererFactory {
+	return map[string]DiscovererFactory{
+		DiscoverySourceDocker: func(env DiscovererEnv, source DiscoverySource) (volman.Discoverer, error) {
+			return voldiscoverers.NewDockerDriverDiscovererWithMountpointPolicy(env.Logger, env.Registry, source.DriverPaths, voldiscoverers.NewDockerDriverFactory(), env.MetronClient, env.Timeouts, env.Gate, env.MountpointPolicy), nil
+		},
+		DiscoverySourceStatic: func(env DiscovererEnv, source DiscoverySource) (volman.Discoverer, error) {
+			return voldiscoverers.NewStaticDriverDiscoverer(env.Logger, env.Registry, source.Drivers, voldiscoverers.NewSpecDriverFactory(), env.MetronClient, env.Timeouts, env.Gate, env.MountpointPolicy), nil
+		},
+		DiscoverySourceManifest: func(env DiscovererEnv, source DiscoverySource) (volman.Discoverer, error) {
+			if source.ManifestPath == "" {
+				return nil, errors.New("manifest discovery source has no manifest path")
+			}
+			return voldiscoverers.NewManifestDriverDiscoverer(env.Logger, env.Registry, source.ManifestPath, voldiscoverers.NewSpecDriverFactory(), env.MetronClient, env.Timeouts, env.Gate, env.MountpointPolicy), nil
+		},
+	}
+}
+
+// NewDiscoverers builds a discoverer for each source, in order, with the factory of its
+// kind. Factories override the default factory of their kind. Sources that cannot be
+// built are left out, and reported together in the error.
+func NewDiscoverers(env DiscovererEnv, sources []DiscoverySource, factories map[string]DiscovererFactory) ([]volman.Discoverer, error) {
+	allFactories := DefaultDiscovererFactories()
+	for kind, factory := range factories {
+		allFactories[kind] = factory
+	}
+
+	var discoverers []volman.Discoverer
+	var failures []volman.DiscoveryFailure
+	for i, source := range sources {
+		factory, found := allFactories[source.Kind]
+		if !found {
+			failures = append(failures, volman.DiscoveryFailure{Source: fmt.Sprintf("discovery source %d", i), Err: fmt.Errorf("unknown discovery source kind '%s'", source.Kind)})
+			continue
+		}
+
+		discoverer, err := factory(env, source)
+		if err != nil {
+			failures = append(failures, volman.DiscoveryFailure{Source: fmt.Sprintf("discovery source %d", i), Err: err})
+			continue
+		}
+		discoverers = append(discoverers, discoverer)
+	}
+
+	if len(failures) > 0 {
+		return discoverers, volman.DiscoveryError{Failures: failures}
+	}
+	return discoverers, nil
+}
+
+// discoverySources returns the configured discovery sources, or a docker source of the
+// driver paths when none are.
+func (c DriverConfig) discoverySources() []DiscoverySource {
+	if len(c.DiscoverySources) == 0 {
+		return []DiscoverySource{{Kind: DiscoverySourceDocker, DriverPaths: c.DriverPaths}}
+	}
+	return c.DiscoverySources
+}
+
+// watchedDriverPaths returns the driver paths of every docker source.
+func (c DriverConfig) watchedDriverPaths() []string {
+	var driverPaths []string
+	for _, source := range c.discoverySources() {
+		if source.Kind == DiscoverySourceDocker {
+			driverPaths = append(driverPaths, source.DriverPaths...)
+		}
+	}
+	return driverPaths
+}
@@ -0,0 +1,95 @@
+package vollocal_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/vollocal"
+	"code.cloudfoundry.org/volman/volmanfakes"
+)
+
+var _ = Describe("NewDiscoverers", func() {
+	var (
+		env              vollocal.DiscovererEnv
+		customDiscoverer *volmanfakes.FakeDiscoverer
+		customSources    []vollocal.DiscoverySource
+		factories        map[string]vollocal.DiscovererFactory
+	)
+
+	BeforeEach(func() {
+		env = vollocal.DiscovererEnv{
+			Logger:           lagertest.NewTestLogger("discovery-sources"),
+			Registry:         vollocal.NewPluginRegistry(),
+			MountpointPolicy: volman.WarnOnlyMountpointPolicy(),
+		}
+
+		customDiscoverer = new(volmanfakes.FakeDiscoverer)
+		customSources = nil
+		factories = map[string]vollocal.DiscovererFactory{
+			"consul": func(_ vollocal.DiscovererEnv, source vollocal.DiscoverySource) (volman.Discoverer, error) {
+				customSources = append(customSources, source)
+				return customDiscoverer, nil
+			},
+		}
+	})
+
+	It("builds a discoverer for each source, in order", func() {
+		sources := []vollocal.DiscoverySource{
+			{Kind: vollocal.DiscoverySourceDocker, DriverPaths: []string{defaultPluginsDirectory}},
+			{Kind: "consul", Options: map[string]interface{}{"address": "127.0.0.1:8500"}},
+			{Kind: vollocal.DiscoverySourceStatic, Drivers: []volman.PluginSpec{{Name: "some-driver", Address: "http://0.0.0.0:8080"}}},
+			{Kind: vollocal.DiscoverySourceManifest, ManifestPath: "/var/vcap/jobs/volman/config/drivers.json"},
+		}
+
+		discoverers, err := vollocal.NewDiscoverers(env, sources, factories)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(discoverers).To(HaveLen(4))
+		Expect(discoverers[1]).To(BeIdenticalTo(customDiscoverer))
+		Expect(customSources).To(Equal([]vollocal.DiscoverySource{sources[1]}))
+	})
+
+	It("lets factories replace the default ones", func() {
+		factories[vollocal.DiscoverySourceDocker] = factories["consul"]
+
+		discoverers, err := vollocal.NewDiscoverers(env, []vollocal.DiscoverySource{{Kind: vollocal.DiscoverySourceDocker}}, factories)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(discoverers).To(Equal([]volman.Discoverer{customDiscoverer}))
+	})
+
+	It("leaves out the sources that cannot be built, reporting them all", func() {
+		factories["broken"] = func(vollocal.DiscovererEnv, vollocal.DiscoverySource) (volman.Discoverer, error) {
+			return nil, errors.New("no address")
+		}
+		sources := []vollocal.DiscoverySource{
+			{Kind: "unknown"},
+			{Kind: "consul"},
+			{Kind: "broken"},
+			{Kind: vollocal.DiscoverySourceManifest},
+		}
+
+		discoverers, err := vollocal.NewDiscoverers(env, sources, factories)
+		Expect(discoverers).To(Equal([]volman.Discoverer{customDiscoverer}))
+
+		var discoveryErr volman.DiscoveryError
+		Expect(errors.As(err, &discoveryErr)).To(BeTrue())
+		Expect(discoveryErr.Failures).To(HaveLen(3))
+		Expect(discoveryErr.Failures[0].Err).To(MatchError("unknown discovery source kind 'unknown'"))
+		Expect(discoveryErr.Failures[1].Err).To(MatchError("no address"))
+		Expect(discoveryErr.Failures[2].Err).To(MatchError("manifest discovery source has no manifest path"))
+	})
+
+	It("fails NewServer rather than start without a source", func() {
+		config := vollocal.NewDriverConfig()
+		config.DiscoverySources = []vollocal.DiscoverySource{
+			{Kind: vollocal.DiscoverySourceDocker, DriverPaths: []string{defaultPluginsDirectory}},
+			{Kind: vollocal.DiscoverySourceManifest},
+		}
+
+		_, _, err := vollocal.NewServer(env.Logger, nil, config)
+		Expect(err).To(MatchError(ContainSubstring("manifest discovery source has no manifest path")))
+	})
+})
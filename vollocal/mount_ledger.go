@@ -0,0 +1,217 @@
+package vollocal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// MountRecord describes a single mount volman performed on behalf of a container.
+type MountRecord struct {
+	DriverId       string    `json:"driverId"`
+	VolumeId       string    `json:"volumeId"`
+	ContainerId    string    `json:"containerId"`
+	DriverVolumeId string    `json:"driverVolumeId"`
+	ConfigHash     string    `json:"configHash"`
+	Path           string    `json:"path"`
+	MountedAt      time.Time `json:"mountedAt"`
+}
+
+//go:generate counterfeiter -o ../volmanfakes/fake_mount_ledger.go . MountLedger
+
+// MountLedger keeps track of the mounts that are currently live so that volman can
+// tell legitimate mounts from leaked ones, including across restarts.
+type MountLedger interface {
+	Add(logger lager.Logger, record MountRecord) error
+	Remove(logger lager.Logger, driverId string, volumeId string, containerId string) error
+	Get(driverId string, volumeId string, containerId string) (MountRecord, bool)
+	Records() []MountRecord
+}
+
+type mountKey struct {
+	driverId    string
+	volumeId    string
+	containerId string
+}
+
+type mountLedger struct {
+	sync.RWMutex
+	path    string
+	records map[mountKey]MountRecord
+}
+
+// NewMountLedger returns a ledger persisted to the file at path, loading any records
+// already stored there. An empty path yields a ledger that is only kept in memory.
+func NewMountLedger(logger lager.Logger, path string) MountLedger {
+	logger = logger.Session("new-mount-ledger", lager.Data{"path": path})
+
+	ledger := &mountLedger{
+		path:    path,
+		records: map[mountKey]MountRecord{},
+	}
+
+	if path == "" {
+		return ledger
+	}
+
+	records, err := readMountRecords(path)
+	if err != nil {
+		logger.Error("failed-loading-mount-ledger", err)
+		return ledger
+	}
+
+	for _, record := range records {
+		ledger.records[keyFor(record.DriverId, record.VolumeId, record.ContainerId)] = record
+	}
+	logger.Info("loaded-mount-ledger", lager.Data{"mounts": len(records)})
+
+	return ledger
+}
+
+func (l *mountLedger) Add(logger lager.Logger, record MountRecord) error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.records[keyFor(record.DriverId, record.VolumeId, record.ContainerId)] = record
+	return l.persist(logger)
+}
+
+func (l *mountLedger) Remove(logger lager.Logger, driverId string, volumeId string, containerId string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	key := keyFor(driverId, volumeId, containerId)
+	if _, ok := l.records[key]; !ok {
+		return nil
+	}
+
+	delete(l.records, key)
+	return l.persist(logger)
+}
+
+func (l *mountLedger) Get(driverId string, volumeId string, containerId string) (MountRecord, bool) {
+	l.RLock()
+	defer l.RUnlock()
+
+	record, ok := l.records[keyFor(driverId, volumeId, containerId)]
+	return record, ok
+}
+
+func (l *mountLedger) Records() []MountRecord {
+	l.RLock()
+	defer l.RUnlock()
+
+	return l.sortedRecords()
+}
+
+func (l *mountLedger) sortedRecords() []MountRecord {
+	records := make([]MountRecord, 0, len(l.records))
+	for _, record := range l.records {
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DriverId != records[j].DriverId {
+			return records[i].DriverId < records[j].DriverId
+		}
+		if records[i].VolumeId != records[j].VolumeId {
+			return records[i].VolumeId < records[j].VolumeId
+		}
+		return records[i].ContainerId < records[j].ContainerId
+	})
+	return records
+}
+
+// persist writes the ledger to a temporary file next to the ledger file and renames it
+// into place, so a crash mid-write never leaves a truncated ledger behind. The in-memory
+// state is kept even when the write fails; the next successful write catches the file up.
+func (l *mountLedger) persist(logger lager.Logger) error {
+	if l.path == "" {
+		return nil
+	}
+
+	logger = logger.Session("persist-mount-ledger", lager.Data{"path": l.path})
+
+	contents, err := json.Marshal(l.sortedRecords())
+	if err != nil {
+		logger.Error("failed-marshalling-mount-ledger", err)
+		return err
+	}
+
+	dir := filepath.Dir(l.path)
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(l.path)+".tmp-*")
+	if err != nil {
+		logger.Error("failed-creating-temp-file", err)
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write(contents); err != nil {
+		tmpFile.Close()
+		logger.Error("failed-writing-temp-file", err)
+		return err
+	}
+
+	if err = tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		logger.Error("failed-syncing-temp-file", err)
+		return err
+	}
+
+	if err = tmpFile.Close(); err != nil {
+		logger.Error("failed-closing-temp-file", err)
+		return err
+	}
+
+	if err = os.Rename(tmpFile.Name(), l.path); err != nil {
+		logger.Error("failed-renaming-temp-file", err)
+		return err
+	}
+
+	if dirFile, err := os.Open(dir); err == nil {
+		dirFile.Sync()
+		dirFile.Close()
+	}
+
+	return nil
+}
+
+func readMountRecords(path string) ([]MountRecord, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []MountRecord
+	if err := json.Unmarshal(contents, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func keyFor(driverId string, volumeId string, containerId string) mountKey {
+	return mountKey{driverId: driverId, volumeId: volumeId, containerId: containerId}
+}
+
+// hashConfig returns a stable digest of a mount config so that two mounts can be
+// compared without keeping (possibly sensitive) config values in the ledger.
+func hashConfig(config map[string]interface{}) string {
+	contents, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
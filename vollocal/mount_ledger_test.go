@@ -0,0 +1,138 @@
+package vollocal_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman/vollocal"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MountLedger", func() {
+	var (
+		logger     *lagertest.TestLogger
+		ledgerDir  string
+		ledgerPath string
+		ledger     vollocal.MountLedger
+		record     vollocal.MountRecord
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("mount-ledger")
+
+		var err error
+		ledgerDir, err = os.MkdirTemp("", "mount-ledger")
+		Expect(err).NotTo(HaveOccurred())
+		ledgerPath = filepath.Join(ledgerDir, "mounts.json")
+
+		record = vollocal.MountRecord{
+			DriverId:       "some-driver",
+			VolumeId:       "some-volume",
+			ContainerId:    "some-container",
+			DriverVolumeId: "some-volume",
+			ConfigHash:     "some-hash",
+			Path:           "/var/vcap/data/mounts/some-volume",
+			MountedAt:      time.Unix(123, 0).UTC(),
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(ledgerDir)
+	})
+
+	JustBeforeEach(func() {
+		ledger = vollocal.NewMountLedger(logger, ledgerPath)
+	})
+
+	It("starts empty when there is no ledger file", func() {
+		Expect(ledger.Records()).To(BeEmpty())
+	})
+
+	Context("when a mount is added", func() {
+		JustBeforeEach(func() {
+			Expect(ledger.Add(logger, record)).To(Succeed())
+		})
+
+		It("returns the record", func() {
+			found, ok := ledger.Get("some-driver", "some-volume", "some-container")
+			Expect(ok).To(BeTrue())
+			Expect(found).To(Equal(record))
+			Expect(ledger.Records()).To(ConsistOf(record))
+		})
+
+		It("persists the record across ledger instances", func() {
+			reloaded := vollocal.NewMountLedger(logger, ledgerPath)
+			Expect(reloaded.Records()).To(ConsistOf(record))
+		})
+
+		It("does not leave temporary files behind", func() {
+			entries, err := os.ReadDir(ledgerDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal("mounts.json"))
+		})
+
+		Context("and then removed", func() {
+			JustBeforeEach(func() {
+				Expect(ledger.Remove(logger, "some-driver", "some-volume", "some-container")).To(Succeed())
+			})
+
+			It("no longer returns the record", func() {
+				_, ok := ledger.Get("some-driver", "some-volume", "some-container")
+				Expect(ok).To(BeFalse())
+				Expect(ledger.Records()).To(BeEmpty())
+			})
+
+			It("persists the removal", func() {
+				reloaded := vollocal.NewMountLedger(logger, ledgerPath)
+				Expect(reloaded.Records()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when removing a mount that is not recorded", func() {
+		It("succeeds", func() {
+			Expect(ledger.Remove(logger, "some-driver", "some-volume", "some-container")).To(Succeed())
+		})
+	})
+
+	Context("when the ledger file is corrupt", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(ledgerPath, []byte("not json"), 0600)).To(Succeed())
+		})
+
+		It("logs the error and starts empty", func() {
+			Expect(ledger.Records()).To(BeEmpty())
+			Expect(logger.TestSink.LogMessages()).To(ContainElement("mount-ledger.new-mount-ledger.failed-loading-mount-ledger"))
+		})
+	})
+
+	Context("when the ledger directory cannot be written", func() {
+		BeforeEach(func() {
+			ledgerPath = filepath.Join(ledgerDir, "does-not-exist", "mounts.json")
+		})
+
+		It("returns an error but keeps the record in memory", func() {
+			Expect(ledger.Add(logger, record)).NotTo(Succeed())
+			Expect(ledger.Records()).To(ConsistOf(record))
+		})
+	})
+
+	Context("when the ledger is not persisted", func() {
+		BeforeEach(func() {
+			ledgerPath = ""
+		})
+
+		It("keeps records in memory", func() {
+			Expect(ledger.Add(logger, record)).To(Succeed())
+			Expect(ledger.Records()).To(ConsistOf(record))
+
+			entries, err := os.ReadDir(ledgerDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+})
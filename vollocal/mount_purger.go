@@ -1,28 +1,66 @@
 package vollocal
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sort"
 
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/volman"
 	"github.com/tedsuo/ifrit"
 )
 
+//go:generate counterfeiter -o ../volmanfakes/fake_live_containers.go . LiveContainers
+
+// LiveContainers is supplied by the caller to tell the purger which containers are
+// still running on the cell, and therefore whose mounts must be left alone.
+type LiveContainers interface {
+	ContainerIds(logger lager.Logger) ([]string, error)
+}
+
+type LiveContainersFunc func(logger lager.Logger) ([]string, error)
+
+func (f LiveContainersFunc) ContainerIds(logger lager.Logger) ([]string, error) {
+	return f(logger)
+}
+
+// OrphanedMount is a volume reported by a driver that no live container is using.
+// ContainerIds lists the containers volman last mounted it for.
+type OrphanedMount struct {
+	DriverId       string   `json:"driverId"`
+	DriverVolumeId string   `json:"driverVolumeId"`
+	ContainerIds   []string `json:"containerIds"`
+}
+
 type MountPurger interface {
 	Runner() ifrit.Runner
-	PurgeMounts(logger lager.Logger) error
+	PurgeMounts(ctx context.Context, logger lager.Logger) error
+	PurgeOrphanedMounts(ctx context.Context, logger lager.Logger, liveContainers LiveContainers, dryRun bool) ([]OrphanedMount, error)
 }
 
 type mountPurger struct {
-	logger   lager.Logger
-	registry volman.PluginRegistry
+	logger         lager.Logger
+	registry       volman.PluginRegistry
+	ledger         MountLedger
+	liveContainers LiveContainers
+	dryRun         bool
 }
 
 func NewMountPurger(logger lager.Logger, registry volman.PluginRegistry) MountPurger {
+	return NewMountPurgerWithLedger(logger, registry, NewMountLedger(logger, ""), nil, false)
+}
+
+// NewMountPurgerWithLedger returns a purger that keeps the ledger in step with the mounts
+// it removes. When liveContainers is nil the runner purges every mount at start-up,
+// otherwise it only purges orphaned mounts and dryRun makes it report them instead.
+func NewMountPurgerWithLedger(logger lager.Logger, registry volman.PluginRegistry, ledger MountLedger, liveContainers LiveContainers, dryRun bool) MountPurger {
 	return &mountPurger{
-		logger,
-		registry,
+		logger:         logger,
+		registry:       registry,
+		ledger:         ledger,
+		liveContainers: liveContainers,
+		dryRun:         dryRun,
 	}
 }
 
@@ -32,7 +70,12 @@ func (p *mountPurger) Runner() ifrit.Runner {
 
 func (p *mountPurger) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 
-	if err := p.PurgeMounts(p.logger); err != nil {
+	ctx := context.Background()
+	if p.liveContainers != nil {
+		if _, err := p.PurgeOrphanedMounts(ctx, p.logger, p.liveContainers, p.dryRun); err != nil {
+			return err
+		}
+	} else if err := p.PurgeMounts(ctx, p.logger); err != nil {
 		return err
 	}
 
@@ -41,26 +84,141 @@ func (p *mountPurger) Run(signals <-chan os.Signal, ready chan<- struct{}) error
 	return nil
 }
 
-func (p *mountPurger) PurgeMounts(logger lager.Logger) error {
+func (p *mountPurger) PurgeMounts(ctx context.Context, logger lager.Logger) error {
 	logger = logger.Session("purge-mounts")
 	logger.Info("start")
 	defer logger.Info("end")
 
 	plugins := p.registry.Plugins()
 
-	for _, plugin := range plugins {
-		volumes, err := plugin.ListVolumes(logger)
+	for name, plugin := range plugins {
+		volumes, err := plugin.ListVolumes(ctx, logger)
 		if err != nil {
 			logger.Error("failed-listing-volume-mount", err)
 			continue
 		}
 
 		for _, volume := range volumes {
-			err = plugin.Unmount(logger, volume)
+			err = plugin.Unmount(ctx, logger, volume)
 			if err != nil {
 				logger.Error(fmt.Sprintf("failed-unmounting-volume-mount %s", volume), err)
+				continue
 			}
+
+			p.removeRecords(logger, recordsForDriverVolume(p.ledger, name, volume))
 		}
 	}
 	return nil
 }
+
+func (p *mountPurger) PurgeOrphanedMounts(ctx context.Context, logger lager.Logger, liveContainers LiveContainers, dryRun bool) ([]OrphanedMount, error) {
+	logger = logger.Session("purge-orphaned-mounts", lager.Data{"dry-run": dryRun})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	containerIds, err := liveContainers.ContainerIds(logger)
+	if err != nil {
+		logger.Error("failed-listing-live-containers", err)
+		return nil, err
+	}
+
+	live := liveContainerSet(containerIds)
+
+	orphans := findOrphanedMounts(ctx, logger, p.registry, p.ledger, live)
+
+	for _, orphan := range orphans {
+		if dryRun {
+			logger.Info("would-unmount-orphaned-volume", lager.Data{"driver": orphan.DriverId, "volume": orphan.DriverVolumeId, "containers": orphan.ContainerIds})
+			continue
+		}
+
+		plugin, found := p.registry.Plugin(orphan.DriverId)
+		if !found {
+			continue
+		}
+
+		logger.Info("unmounting-orphaned-volume", lager.Data{"driver": orphan.DriverId, "volume": orphan.DriverVolumeId, "containers": orphan.ContainerIds})
+		if err := plugin.Unmount(ctx, logger, orphan.DriverVolumeId); err != nil {
+			logger.Error(fmt.Sprintf("failed-unmounting-volume-mount %s", orphan.DriverVolumeId), err)
+			continue
+		}
+
+		p.removeRecords(logger, recordsForDriverVolume(p.ledger, orphan.DriverId, orphan.DriverVolumeId))
+	}
+
+	return orphans, nil
+}
+
+func (p *mountPurger) removeRecords(logger lager.Logger, records []MountRecord) {
+	for _, record := range records {
+		err := p.ledger.Remove(logger, record.DriverId, record.VolumeId, record.ContainerId)
+		if err != nil {
+			logger.Error("failed-removing-mount-record", err, lager.Data{"driver": record.DriverId, "volume": record.VolumeId, "container": record.ContainerId})
+		}
+	}
+}
+
+func liveContainerSet(containerIds []string) func(string) bool {
+	live := map[string]bool{}
+	for _, containerId := range containerIds {
+		live[containerId] = true
+	}
+	return func(containerId string) bool {
+		return live[containerId]
+	}
+}
+
+// findOrphanedMounts lists the volumes the registered drivers report as mounted that volman
+// mounted for containers none of which are live, in driver name order. Volumes the ledger
+// has no record of are left alone: they may have been mounted before a restart that lost
+// an in-memory ledger, for containers that are still running.
+func findOrphanedMounts(ctx context.Context, logger lager.Logger, registry volman.PluginRegistry, ledger MountLedger, live func(string) bool) []OrphanedMount {
+	plugins := registry.Plugins()
+
+	var names []string
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var orphans []OrphanedMount
+	for _, name := range names {
+		volumes, err := plugins[name].ListVolumes(ctx, logger)
+		if err != nil {
+			logger.Error("failed-listing-volume-mount", err, lager.Data{"driver": name})
+			continue
+		}
+
+		for _, volume := range volumes {
+			records := recordsForDriverVolume(ledger, name, volume)
+			if len(records) == 0 {
+				logger.Debug("skipping-unrecorded-volume", lager.Data{"driver": name, "volume": volume})
+				continue
+			}
+
+			inUse := false
+			var containerIds []string
+			for _, record := range records {
+				containerIds = append(containerIds, record.ContainerId)
+				if live(record.ContainerId) {
+					inUse = true
+				}
+			}
+
+			if !inUse {
+				orphans = append(orphans, OrphanedMount{DriverId: name, DriverVolumeId: volume, ContainerIds: containerIds})
+			}
+		}
+	}
+	return orphans
+}
+
+func recordsForDriverVolume(ledger MountLedger, driverId string, driverVolumeId string) []MountRecord {
+	var records []MountRecord
+	for _, record := range ledger.Records() {
+		if record.DriverId == driverId && record.DriverVolumeId == driverVolumeId {
+			records = append(records, record)
+		}
+	}
+	return records
+}
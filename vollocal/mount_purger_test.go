@@ -1,6 +1,9 @@
 package vollocal_test
 
 import (
+	"context"
+	"errors"
+
 	"code.cloudfoundry.org/volman/voldiscoverers"
 	"code.cloudfoundry.org/volman/vollocal"
 
@@ -47,11 +50,11 @@ var _ = Describe("MountPurger", func() {
 
 	JustBeforeEach(func() {
 		purger = vollocal.NewMountPurger(logger, driverRegistry)
-		err = purger.PurgeMounts(logger)
+		err = purger.PurgeMounts(context.Background(), logger)
 	})
 
 	It("should succeed when there are no drivers", func() {
-		//err := purger.PurgeMounts(logger)
+		//err := purger.PurgeMounts(context.Background(), logger)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -125,3 +128,157 @@ var _ = Describe("MountPurger", func() {
 		})
 	})
 })
+
+var _ = Describe("MountPurger with live containers", func() {
+	var (
+		logger *lagertest.TestLogger
+
+		driverRegistry volman.PluginRegistry
+		ledger         vollocal.MountLedger
+		fakePlugin     *volmanfakes.FakePlugin
+		liveContainers *volmanfakes.FakeLiveContainers
+		purger         vollocal.MountPurger
+
+		dryRun  bool
+		orphans []vollocal.OrphanedMount
+		err     error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("mount-purger")
+
+		fakePlugin = new(volmanfakes.FakePlugin)
+		fakePlugin.ListVolumesReturns([]string{"shared-volume", "orphaned-volume", "unknown-volume"}, nil)
+		driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{"some-driver": fakePlugin})
+
+		ledger = vollocal.NewMountLedger(logger, "")
+		for _, record := range []vollocal.MountRecord{
+			{DriverId: "some-driver", VolumeId: "shared-volume", DriverVolumeId: "shared-volume", ContainerId: "live-container"},
+			{DriverId: "some-driver", VolumeId: "shared-volume", DriverVolumeId: "shared-volume", ContainerId: "dead-container"},
+			{DriverId: "some-driver", VolumeId: "orphaned-volume", DriverVolumeId: "orphaned-volume", ContainerId: "dead-container"},
+		} {
+			Expect(ledger.Add(logger, record)).To(Succeed())
+		}
+
+		liveContainers = new(volmanfakes.FakeLiveContainers)
+		liveContainers.ContainerIdsReturns([]string{"live-container"}, nil)
+
+		dryRun = false
+	})
+
+	JustBeforeEach(func() {
+		purger = vollocal.NewMountPurgerWithLedger(logger, driverRegistry, ledger, liveContainers, dryRun)
+		orphans, err = purger.PurgeOrphanedMounts(context.Background(), logger, liveContainers, dryRun)
+	})
+
+	It("reports the volumes that no live container holds", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(orphans).To(Equal([]vollocal.OrphanedMount{
+			{DriverId: "some-driver", DriverVolumeId: "orphaned-volume", ContainerIds: []string{"dead-container"}},
+		}))
+	})
+
+	It("only unmounts the orphaned volumes", func() {
+		Expect(fakePlugin.UnmountCallCount()).To(Equal(1))
+		_, _, volume := fakePlugin.UnmountArgsForCall(0)
+		Expect(volume).To(Equal("orphaned-volume"))
+	})
+
+	Context("when the ledger has lost its records, such as after a restart", func() {
+		BeforeEach(func() {
+			ledger = vollocal.NewMountLedger(logger, "")
+		})
+
+		It("leaves every volume mounted", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(orphans).To(BeEmpty())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+		})
+	})
+
+	It("only drops the ledger records of the volumes it unmounts", func() {
+		Expect(ledger.Records()).To(ConsistOf(
+			vollocal.MountRecord{DriverId: "some-driver", VolumeId: "shared-volume", DriverVolumeId: "shared-volume", ContainerId: "live-container"},
+			vollocal.MountRecord{DriverId: "some-driver", VolumeId: "shared-volume", DriverVolumeId: "shared-volume", ContainerId: "dead-container"},
+		))
+	})
+
+	Context("when the driver cannot list its volumes", func() {
+		BeforeEach(func() {
+			fakePlugin.ListVolumesReturns(nil, errors.New("badness"))
+		})
+
+		It("keeps the ledger records of the driver", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			Expect(ledger.Records()).To(HaveLen(3))
+		})
+	})
+
+	It("is refused by NewServer unless the ledger survives restarts", func() {
+		config := vollocal.NewDriverConfig()
+		config.LiveContainers = liveContainers
+
+		_, _, err := vollocal.NewServer(logger, nil, config)
+		Expect(err).To(MatchError(ContainSubstring("MountLedgerPath is not set")))
+	})
+
+	Context("when the unmount fails", func() {
+		BeforeEach(func() {
+			fakePlugin.UnmountReturns(errors.New("badness"))
+		})
+
+		It("should log but not fail", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.TestSink.LogMessages()).To(ContainElement("mount-purger.purge-orphaned-mounts.failed-unmounting-volume-mount orphaned-volume"))
+		})
+
+		It("keeps the ledger record of the orphaned volume", func() {
+			Expect(ledger.Records()).To(ContainElement(
+				vollocal.MountRecord{DriverId: "some-driver", VolumeId: "orphaned-volume", DriverVolumeId: "orphaned-volume", ContainerId: "dead-container"},
+			))
+		})
+	})
+
+	Context("when running as a dry run", func() {
+		BeforeEach(func() {
+			dryRun = true
+		})
+
+		It("reports the orphaned volumes without unmounting them", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(orphans).To(HaveLen(1))
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			Expect(ledger.Records()).To(HaveLen(3))
+			Expect(logger.TestSink.LogMessages()).To(ContainElement("mount-purger.purge-orphaned-mounts.would-unmount-orphaned-volume"))
+		})
+	})
+
+	Context("when the live containers cannot be listed", func() {
+		BeforeEach(func() {
+			liveContainers.ContainerIdsReturns(nil, errors.New("badness"))
+		})
+
+		It("fails without unmounting anything", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			Expect(ledger.Records()).To(HaveLen(3))
+		})
+	})
+
+	Context("when run as a runner", func() {
+		var process ifrit.Process
+
+		JustBeforeEach(func() {
+			process = ginkgomon.Invoke(purger.Runner())
+		})
+
+		AfterEach(func() {
+			ginkgomon.Kill(process)
+		})
+
+		It("purges the orphaned volumes at start-up", func() {
+			Expect(liveContainers.ContainerIdsCallCount()).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,226 @@
+package vollocal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	loggingclient "code.cloudfoundry.org/diego-logging-client"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+	"github.com/tedsuo/ifrit"
+)
+
+const (
+	volmanReapedMountsCounter = "VolmanReapedMounts"
+	volmanReapErrorsCounter   = "VolmanReapErrors"
+)
+
+type orphanKey struct {
+	driverId       string
+	driverVolumeId string
+}
+
+// MountReaper periodically unmounts volumes that volman mounted for containers that are
+// all gone, once they have stayed orphaned for longer than the grace period. Volumes the
+// ledger has no record of are never reaped.
+type MountReaper struct {
+	logger         lager.Logger
+	registry       volman.PluginRegistry
+	ledger         MountLedger
+	liveContainers LiveContainers
+	metronClient   loggingclient.IngressClient
+	clock          clock.Clock
+	interval       time.Duration
+	gracePeriod    time.Duration
+
+	// manager unmounts orphans the way any other unmount is made, under the volume's
+	// lock, within the driver's timeouts and releasing shared mounts one holder at a time
+	manager volman.Manager
+
+	mutex     sync.Mutex
+	firstSeen map[orphanKey]time.Time
+	reaping   map[orphanKey]bool
+}
+
+// NewMountReaper returns a reaper. When liveContainers is nil every mount recorded in the
+// ledger is considered in use, so nothing is reaped.
+func NewMountReaper(logger lager.Logger, registry volman.PluginRegistry, ledger MountLedger, liveContainers LiveContainers, metronClient loggingclient.IngressClient, clock clock.Clock, interval time.Duration, gracePeriod time.Duration) *MountReaper {
+	manager := NewLocalClientWithMountLedger(logger, registry, metronClient, clock, ledger)
+	return NewMountReaperWithManager(logger, registry, ledger, liveContainers, metronClient, clock, interval, gracePeriod, manager)
+}
+
+// NewMountReaperWithManager returns a reaper that unmounts orphans through manager, which
+// should be the manager that mounts volumes so that reaping and mounting a volume never
+// overlap.
+func NewMountReaperWithManager(logger lager.Logger, registry volman.PluginRegistry, ledger MountLedger, liveContainers LiveContainers, metronClient loggingclient.IngressClient, clock clock.Clock, interval time.Duration, gracePeriod time.Duration, manager volman.Manager) *MountReaper {
+	return &MountReaper{
+		logger:         logger,
+		registry:       registry,
+		ledger:         ledger,
+		liveContainers: liveContainers,
+		metronClient:   metronClient,
+		clock:          clock,
+		interval:       interval,
+		gracePeriod:    gracePeriod,
+		manager:        manager,
+		firstSeen:      map[orphanKey]time.Time{},
+		reaping:        map[orphanKey]bool{},
+	}
+}
+
+func (r *MountReaper) Runner() ifrit.Runner {
+	return r
+}
+
+func (r *MountReaper) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := r.logger.Session("reap-mounts")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	timer := r.clock.NewTimer(r.interval)
+	defer timer.Stop()
+
+	close(ready)
+
+	for {
+		select {
+		case <-timer.C():
+			r.Reap(ctx, logger)
+			timer.Reset(r.interval)
+		case signal := <-signals:
+			logger.Info("signalled", lager.Data{"signal": signal.String()})
+			return nil
+		}
+	}
+}
+
+// Reap unmounts the orphaned volumes that have outlived the grace period and returns them.
+// Drivers are only called without holding the reaper's lock, so a reap stuck on a driver
+// does not hold up the next one, which skips the volumes still being reaped.
+func (r *MountReaper) Reap(ctx context.Context, logger lager.Logger) []OrphanedMount {
+	logger = logger.Session("reap")
+	logger.Debug("start")
+	defer logger.Debug("end")
+
+	live := func(string) bool { return true }
+	if r.liveContainers != nil {
+		containerIds, err := r.liveContainers.ContainerIds(logger)
+		if err != nil {
+			logger.Error("failed-listing-live-containers", err)
+			r.incrementCounter(logger, volmanReapErrorsCounter)
+			return nil
+		}
+		live = liveContainerSet(containerIds)
+	}
+
+	orphans := findOrphanedMounts(ctx, logger, r.registry, r.ledger, live)
+	due, firstSeen := r.dueOrphans(orphans)
+
+	var reaped []OrphanedMount
+	for _, orphan := range due {
+		key := orphanKey{driverId: orphan.DriverId, driverVolumeId: orphan.DriverVolumeId}
+
+		logger.Info("reaping-orphaned-volume", lager.Data{"driver": orphan.DriverId, "volume": orphan.DriverVolumeId, "containers": orphan.ContainerIds, "orphaned-since": firstSeen[key]})
+		err := r.unmountOrphan(ctx, logger, orphan)
+
+		r.mutex.Lock()
+		delete(r.reaping, key)
+		if err != nil {
+			r.firstSeen[key] = firstSeen[key]
+		}
+		r.mutex.Unlock()
+
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed-reaping-volume-mount %s", orphan.DriverVolumeId), err)
+			r.incrementCounter(logger, volmanReapErrorsCounter)
+			continue
+		}
+
+		r.incrementCounter(logger, volmanReapedMountsCounter)
+		reaped = append(reaped, orphan)
+	}
+
+	return reaped
+}
+
+// dueOrphans records when each orphan was first seen, forgetting volumes that are no
+// longer orphaned, and returns the orphans due to be reaped, marking them as being reaped.
+// An orphan is only due once it has been orphaned and every mount of it has been held
+// for longer than the grace period, so that a container that has just mounted a shared
+// volume does not lose it before it shows up as live.
+func (r *MountReaper) dueOrphans(orphans []OrphanedMount) ([]OrphanedMount, map[orphanKey]time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.clock.Now()
+	seen := map[orphanKey]time.Time{}
+	firstSeenOfDue := map[orphanKey]time.Time{}
+	var due []OrphanedMount
+	for _, orphan := range orphans {
+		key := orphanKey{driverId: orphan.DriverId, driverVolumeId: orphan.DriverVolumeId}
+		if r.reaping[key] {
+			continue
+		}
+
+		firstSeen, ok := r.firstSeen[key]
+		if !ok {
+			firstSeen = now
+		}
+
+		if now.Sub(firstSeen) < r.gracePeriod || r.recentlyMounted(orphan, now) {
+			seen[key] = firstSeen
+			continue
+		}
+
+		r.reaping[key] = true
+		firstSeenOfDue[key] = firstSeen
+		due = append(due, orphan)
+	}
+	r.firstSeen = seen
+
+	return due, firstSeenOfDue
+}
+
+// recentlyMounted reports whether any container volman mounted the volume for has held
+// it for less than the grace period.
+func (r *MountReaper) recentlyMounted(orphan OrphanedMount, now time.Time) bool {
+	for _, record := range recordsForDriverVolume(r.ledger, orphan.DriverId, orphan.DriverVolumeId) {
+		if now.Sub(record.MountedAt) < r.gracePeriod {
+			return true
+		}
+	}
+	return false
+}
+
+// unmountOrphan releases the hold of every container the volume was found orphaned by,
+// so that the last release unmounts it from the driver and the ledger loses its records.
+// Containers that mounted the volume since it was found keep their hold.
+func (r *MountReaper) unmountOrphan(ctx context.Context, logger lager.Logger, orphan OrphanedMount) error {
+	orphanedBy := map[string]bool{}
+	for _, containerId := range orphan.ContainerIds {
+		orphanedBy[containerId] = true
+	}
+
+	for _, record := range recordsForDriverVolume(r.ledger, orphan.DriverId, orphan.DriverVolumeId) {
+		if !orphanedBy[record.ContainerId] {
+			continue
+		}
+		if err := r.manager.Unmount(ctx, logger, record.DriverId, record.VolumeId, record.ContainerId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MountReaper) incrementCounter(logger lager.Logger, name string) {
+	if err := r.metronClient.IncrementCounter(name); err != nil {
+		logger.Debug("failed-emitting-metric", lager.Data{"metric": name, "error": err})
+	}
+}
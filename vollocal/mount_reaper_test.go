@@ -0,0 +1,255 @@
+package vollocal_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	mfakes "code.cloudfoundry.org/diego-logging-client/testhelpers"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/volman"
+	"code.cloudfoundry.org/volman/vollocal"
+	"code.cloudfoundry.org/volman/volmanfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	ginkgomon "github.com/tedsuo/ifrit/ginkgomon_v2"
+)
+
+var _ = Describe("MountReaper", func() {
+	var (
+		logger *lagertest.TestLogger
+
+		driverRegistry   volman.PluginRegistry
+		ledger           vollocal.MountLedger
+		fakePlugin       *volmanfakes.FakePlugin
+		liveContainers   vollocal.LiveContainers
+		fakeClock        *fakeclock.FakeClock
+		fakeMetronClient *mfakes.FakeIngressClient
+
+		interval    time.Duration
+		gracePeriod time.Duration
+
+		reaper *vollocal.MountReaper
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("mount-reaper")
+
+		fakePlugin = new(volmanfakes.FakePlugin)
+		fakePlugin.ListVolumesReturns([]string{"known-volume", "leaked-volume"}, nil)
+		driverRegistry = vollocal.NewPluginRegistryWith(map[string]volman.Plugin{"some-driver": fakePlugin})
+
+		ledger = vollocal.NewMountLedger(logger, "")
+		Expect(ledger.Add(logger, vollocal.MountRecord{DriverId: "some-driver", VolumeId: "known-volume", DriverVolumeId: "known-volume", ContainerId: "some-container"})).To(Succeed())
+		Expect(ledger.Add(logger, vollocal.MountRecord{DriverId: "some-driver", VolumeId: "leaked-volume", DriverVolumeId: "leaked-volume", ContainerId: "dead-container"})).To(Succeed())
+
+		liveContainers = vollocal.LiveContainersFunc(func(lager.Logger) ([]string, error) {
+			return []string{"some-container"}, nil
+		})
+		fakeClock = fakeclock.NewFakeClock(time.Unix(123, 456))
+		fakeMetronClient = new(mfakes.FakeIngressClient)
+
+		interval = time.Minute
+		gracePeriod = 5 * time.Minute
+	})
+
+	JustBeforeEach(func() {
+		reaper = vollocal.NewMountReaper(logger, driverRegistry, ledger, liveContainers, fakeMetronClient, fakeClock, interval, gracePeriod)
+	})
+
+	Describe("#Reap", func() {
+		It("does not reap a leaked volume until it outlives the grace period", func() {
+			Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+
+			fakeClock.Increment(gracePeriod - time.Second)
+			Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+
+			fakeClock.Increment(time.Second)
+			Expect(reaper.Reap(context.Background(), logger)).To(Equal([]vollocal.OrphanedMount{{DriverId: "some-driver", DriverVolumeId: "leaked-volume", ContainerIds: []string{"dead-container"}}}))
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(1))
+			_, _, volume := fakePlugin.UnmountArgsForCall(0)
+			Expect(volume).To(Equal("leaked-volume"))
+		})
+
+		It("emits a metric for each reaped volume", func() {
+			reaper.Reap(context.Background(), logger)
+			fakeClock.Increment(gracePeriod)
+			reaper.Reap(context.Background(), logger)
+
+			Expect(fakeMetronClient.IncrementCounterCallCount()).To(Equal(1))
+			Expect(fakeMetronClient.IncrementCounterArgsForCall(0)).To(Equal("VolmanReapedMounts"))
+		})
+
+		It("restarts the grace period when a volume stops being orphaned", func() {
+			reaper.Reap(context.Background(), logger)
+
+			fakePlugin.ListVolumesReturns([]string{"known-volume"}, nil)
+			fakeClock.Increment(gracePeriod)
+			reaper.Reap(context.Background(), logger)
+
+			fakePlugin.ListVolumesReturns([]string{"known-volume", "leaked-volume"}, nil)
+			Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+		})
+
+		It("does not reap a volume that a container has mounted within the grace period", func() {
+			reaper.Reap(context.Background(), logger)
+			fakeClock.Increment(gracePeriod)
+			newMount := vollocal.MountRecord{DriverId: "some-driver", VolumeId: "leaked-volume", DriverVolumeId: "leaked-volume", ContainerId: "new-container", MountedAt: fakeClock.Now()}
+			Expect(ledger.Add(logger, newMount)).To(Succeed())
+
+			Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+			Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			Expect(ledger.Records()).To(ContainElement(newMount))
+		})
+
+		Context("when unmounting fails", func() {
+			BeforeEach(func() {
+				fakePlugin.UnmountReturns(errors.New("badness"))
+			})
+
+			It("counts the error and tries again on the next pass", func() {
+				reaper.Reap(context.Background(), logger)
+				fakeClock.Increment(gracePeriod)
+				Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+
+				var counters []string
+				for i := 0; i < fakeMetronClient.IncrementCounterCallCount(); i++ {
+					counters = append(counters, fakeMetronClient.IncrementCounterArgsForCall(i))
+				}
+				Expect(counters).To(ContainElement("VolmanReapErrors"))
+				Expect(ledger.Records()).To(HaveLen(2))
+
+				reaper.Reap(context.Background(), logger)
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(2))
+			})
+		})
+
+		Context("when unmounting through the manager that mounts volumes", func() {
+			var fakeManager *volmanfakes.FakeManager
+
+			BeforeEach(func() {
+				Expect(ledger.Add(logger, vollocal.MountRecord{DriverId: "some-driver", VolumeId: "leaked-volume", DriverVolumeId: "leaked-volume", ContainerId: "other-dead-container"})).To(Succeed())
+				fakeManager = new(volmanfakes.FakeManager)
+			})
+
+			JustBeforeEach(func() {
+				reaper = vollocal.NewMountReaperWithManager(logger, driverRegistry, ledger, liveContainers, fakeMetronClient, fakeClock, interval, gracePeriod, fakeManager)
+			})
+
+			It("releases the volume for every container it was mounted for, never calling the driver itself", func() {
+				reaper.Reap(context.Background(), logger)
+				fakeClock.Increment(gracePeriod)
+				Expect(reaper.Reap(context.Background(), logger)).To(HaveLen(1))
+
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+				Expect(fakeManager.UnmountCallCount()).To(Equal(2))
+				var containers []string
+				for i := 0; i < fakeManager.UnmountCallCount(); i++ {
+					_, _, driverId, volumeId, containerId := fakeManager.UnmountArgsForCall(i)
+					Expect(driverId).To(Equal("some-driver"))
+					Expect(volumeId).To(Equal("leaked-volume"))
+					containers = append(containers, containerId)
+				}
+				Expect(containers).To(ConsistOf("dead-container", "other-dead-container"))
+			})
+
+			It("skips volumes that are still being reaped", func() {
+				reaper.Reap(context.Background(), logger)
+				fakeClock.Increment(gracePeriod)
+
+				unmounting := make(chan struct{})
+				release := make(chan struct{})
+				var firstCall sync.Once
+				fakeManager.UnmountStub = func(context.Context, lager.Logger, string, string, string) error {
+					firstCall.Do(func() {
+						close(unmounting)
+						<-release
+					})
+					return nil
+				}
+
+				done := make(chan []vollocal.OrphanedMount)
+				go func() { done <- reaper.Reap(context.Background(), logger) }()
+				Eventually(unmounting).Should(BeClosed())
+
+				Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+
+				close(release)
+				Eventually(done).Should(Receive(HaveLen(1)))
+			})
+		})
+
+		It("drops the records of the volumes it reaps", func() {
+			reaper.Reap(context.Background(), logger)
+			fakeClock.Increment(gracePeriod)
+			reaper.Reap(context.Background(), logger)
+
+			Expect(ledger.Records()).To(ConsistOf(
+				vollocal.MountRecord{DriverId: "some-driver", VolumeId: "known-volume", DriverVolumeId: "known-volume", ContainerId: "some-container"},
+			))
+		})
+
+		Context("when live containers are not known", func() {
+			BeforeEach(func() {
+				liveContainers = nil
+			})
+
+			It("considers every recorded volume in use", func() {
+				reaper.Reap(context.Background(), logger)
+				fakeClock.Increment(gracePeriod)
+				Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the ledger has no record of a volume", func() {
+			BeforeEach(func() {
+				ledger = vollocal.NewMountLedger(logger, "")
+			})
+
+			It("leaves it mounted", func() {
+				reaper.Reap(context.Background(), logger)
+				fakeClock.Increment(gracePeriod)
+				Expect(reaper.Reap(context.Background(), logger)).To(BeEmpty())
+				Expect(fakePlugin.UnmountCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	It("is refused by NewServer without live containers and a ledger that survives restarts", func() {
+		config := vollocal.NewDriverConfig()
+		config.ReapInterval = interval
+
+		_, _, err := vollocal.NewServer(logger, nil, config)
+		Expect(err).To(MatchError(ContainSubstring("reaping orphaned mounts needs LiveContainers")))
+	})
+
+	Describe("#Run", func() {
+		var process ifrit.Process
+
+		JustBeforeEach(func() {
+			process = ginkgomon.Invoke(reaper.Runner())
+		})
+
+		AfterEach(func() {
+			ginkgomon.Kill(process)
+		})
+
+		It("reaps on every interval", func() {
+			Expect(fakePlugin.ListVolumesCallCount()).To(Equal(0))
+
+			fakeClock.WaitForWatcherAndIncrement(interval)
+			Eventually(fakePlugin.ListVolumesCallCount).Should(Equal(1))
+
+			fakeClock.WaitForWatcherAndIncrement(gracePeriod)
+			Eventually(fakePlugin.UnmountCallCount).Should(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,194 @@
+package vollocal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volman"
+)
+
+//go:generate counterfeiter -o ../volmanfakes/fake_mount_info_reader.go . MountInfoReader
+
+// MountInfoReader returns the mount table that mounts are verified against, in the
+// format of /proc/self/mountinfo.
+type MountInfoReader interface {
+	MountInfo(logger lager.Logger) ([]byte, error)
+}
+
+type MountInfoReaderFunc func(logger lager.Logger) ([]byte, error)
+
+func (f MountInfoReaderFunc) MountInfo(logger lager.Logger) ([]byte, error) {
+	return f(logger)
+}
+
+// ProcMountInfoReader reads the mount table of the volman process itself.
+var ProcMountInfoReader = MountInfoReaderFunc(func(logger lager.Logger) ([]byte, error) {
+	return os.ReadFile("/proc/self/mountinfo")
+})
+
+// MountVerificationPolicy makes volman check, after a driver reports a volume mounted,
+// that the path it returned really is a mount point rather than a plain directory on the
+// cell's disk.
+type MountVerificationPolicy struct {
+	Enabled bool
+
+	// FilesystemTypes are the filesystem types the driver is expected to mount, such as
+	// "nfs4" or "cifs". Any type is accepted when empty.
+	FilesystemTypes []string
+}
+
+type mountInfoEntry struct {
+	mountpoint     string
+	filesystemType string
+}
+
+// verifyMount checks path against the mount table when the driver's policy asks for it.
+// Failing to read the mount table fails the verification too, since the mount cannot be
+// trusted then.
+func (client *localClient) verifyMount(logger lager.Logger, pluginId string, volumeId string, path string) error {
+	policy := client.config.MountVerification.For(pluginId)
+	if !policy.Enabled {
+		return nil
+	}
+
+	reader := client.config.MountInfoReader
+	if reader == nil {
+		reader = ProcMountInfoReader
+	}
+
+	mountInfo, err := reader.MountInfo(logger)
+	if err != nil {
+		logger.Error("failed-reading-mountinfo", err)
+		return volman.SafeError{SafeDescription: fmt.Sprintf("volume service %s mounted volume %s, but the mount could not be verified", pluginId, volumeId)}
+	}
+
+	mountpoint := filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(mountpoint); err == nil {
+		mountpoint = resolved
+	}
+
+	// the last entry for a path is the one that is visible, as later mounts cover earlier ones
+	var entry *mountInfoEntry
+	for _, candidate := range parseMountInfo(mountInfo) {
+		if candidate.mountpoint == mountpoint {
+			candidate := candidate
+			entry = &candidate
+		}
+	}
+
+	if entry == nil {
+		return volman.SafeError{SafeDescription: fmt.Sprintf("volume service %s did not mount volume %s: %s is not a mount point", pluginId, volumeId, path)}
+	}
+
+	if len(policy.FilesystemTypes) > 0 && !containsString(policy.FilesystemTypes, entry.filesystemType) {
+		return volman.SafeError{SafeDescription: fmt.Sprintf("volume service %s mounted volume %s as %s, expected %s", pluginId, volumeId, entry.filesystemType, strings.Join(policy.FilesystemTypes, " or "))}
+	}
+
+	logger.Debug("mount-verified", lager.Data{"path": path, "filesystemType": entry.filesystemType})
+	return nil
+}
+
+// checkRecordedMount checks a mount recorded in the ledger against the mount table before
+// it is handed out again, when the driver's verification policy asks for it, since the
+// mount may have been lost since it was recorded, for example when the driver restarted.
+// Recorded mounts are trusted otherwise, so that handing them out never calls the driver.
+func (client *localClient) checkRecordedMount(logger lager.Logger, pluginId string, record MountRecord) error {
+	if !client.config.MountVerification.For(pluginId).Enabled {
+		return nil
+	}
+
+	if err := client.verifyMount(logger, pluginId, record.VolumeId, record.Path); err != nil {
+		logger.Info("recorded-mount-lost", lager.Data{"volumeId": record.VolumeId, "path": record.Path, "error": err.Error()})
+		return err
+	}
+	return nil
+}
+
+// sharedMountLost fails the mount of a shared volume whose mount has been lost. The
+// records of the containers sharing it are left alone, as mounting the volume again
+// underneath them would let the first of them to unmount it take it from the others.
+func (client *localClient) sharedMountLost(logger lager.Logger, volumeId string) error {
+	err := volman.SafeError{SafeDescription: fmt.Sprintf("volume %s is shared with other containers and is no longer mounted", volumeId)}
+	logger.Error("shared-mount-lost", err, lager.Data{"volumeId": volumeId})
+	if metricErr := client.metronClient.IncrementCounter(volmanMountErrorsCounter); metricErr != nil {
+		logger.Debug("failed-emitting-mount-error-metric", lager.Data{"error": metricErr})
+	}
+	return err
+}
+
+// discardUnverifiedMount asks the driver to unmount a volume that failed verification,
+// as nothing records it as mounted.
+func (client *localClient) discardUnverifiedMount(ctx context.Context, logger lager.Logger, plugin volman.Plugin, pluginId string, driverVolumeId string) {
+	timeout := client.config.Timeouts.For(pluginId, plugin.GetPluginSpec()).Unmount
+	_, err := callWithTimeout(ctx, pluginId, volman.OperationUnmount, timeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, plugin.Unmount(ctx, logger, driverVolumeId)
+	})
+	if err != nil {
+		logger.Error("failed-unmounting-unverified-mount", err)
+	}
+}
+
+// parseMountInfo reads the mount point and filesystem type of every line of a
+// /proc/<pid>/mountinfo table, skipping lines it cannot make sense of.
+func parseMountInfo(mountInfo []byte) []mountInfoEntry {
+	var entries []mountInfoEntry
+
+	scanner := bufio.NewScanner(bytes.NewReader(mountInfo))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// the optional fields end with a lone "-", followed by the filesystem type
+		separator := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				separator = i
+				break
+			}
+		}
+		if len(fields) < 5 || separator < 0 || separator+1 >= len(fields) {
+			continue
+		}
+
+		entries = append(entries, mountInfoEntry{
+			mountpoint:     unescapeMountInfo(fields[4]),
+			filesystemType: fields[separator+1],
+		})
+	}
+	return entries
+}
+
+// unescapeMountInfo decodes the octal escapes, such as \040 for a space, that the kernel
+// uses for whitespace and backslashes in paths.
+func unescapeMountInfo(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var unescaped strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if code, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				unescaped.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		unescaped.WriteByte(field[i])
+	}
+	return unescaped.String()
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,29 @@
 package vollocal
 
 import (
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/volman"
 )
 
 type pluginRegistry struct {
 	sync.RWMutex
 	registryEntries map[string]volman.Plugin
+	subscriptions   map[*registrySubscription]struct{}
+
+	clock            clock.Clock
+	drainTimeout     time.Duration
+	draining         map[string]drainingPlugin
+	markedForRemoval map[string]bool
+}
+
+type drainingPlugin struct {
+	plugin volman.Plugin
+	since  time.Time
 }
 
 func NewPluginRegistry() volman.PluginRegistry {
@@ -23,6 +38,17 @@ func NewPluginRegistryWith(initialMap map[string]volman.Plugin) volman.PluginReg
 	}
 }
 
+// NewPluginRegistryWithDrainTimeout returns a registry that keeps drivers that are
+// removed from it draining for up to drainTimeout, or until they are reported drained.
+// Removed drivers are forgotten straight away when drainTimeout is not positive.
+func NewPluginRegistryWithDrainTimeout(clock clock.Clock, drainTimeout time.Duration) volman.PluginRegistry {
+	return &pluginRegistry{
+		registryEntries: map[string]volman.Plugin{},
+		clock:           clock,
+		drainTimeout:    drainTimeout,
+	}
+}
+
 func (d *pluginRegistry) Plugin(id string) (volman.Plugin, bool) {
 	d.RLock()
 	defer d.RUnlock()
@@ -41,11 +67,81 @@ func (d *pluginRegistry) Plugins() map[string]volman.Plugin {
 	return d.registryEntries
 }
 
+// Set replaces the registered drivers. Drivers marked for removal are left out for as
+// long as they are still found, and drivers that are left out start draining.
 func (d *pluginRegistry) Set(plugins map[string]volman.Plugin) {
 	d.Lock()
 	defer d.Unlock()
 
-	d.registryEntries = plugins
+	for driverId := range d.markedForRemoval {
+		if _, found := plugins[driverId]; found {
+			plugins = withoutPlugin(plugins, driverId)
+		} else {
+			delete(d.markedForRemoval, driverId)
+		}
+	}
+
+	d.replaceEntries(plugins)
+}
+
+// Draining implements volman.DrainingRegistry.
+func (d *pluginRegistry) Draining(id string) (volman.Plugin, bool) {
+	d.RLock()
+	defer d.RUnlock()
+
+	draining, found := d.draining[id]
+	if !found || d.drainExpired(draining) {
+		return nil, false
+	}
+	return draining.plugin, true
+}
+
+// Drain implements volman.DrainingRegistry.
+func (d *pluginRegistry) Drain(id string) bool {
+	d.Lock()
+	defer d.Unlock()
+
+	if !d.containsPlugin(id) {
+		return false
+	}
+
+	if d.markedForRemoval == nil {
+		d.markedForRemoval = map[string]bool{}
+	}
+	d.markedForRemoval[id] = true
+
+	d.replaceEntries(withoutPlugin(d.registryEntries, id))
+	return true
+}
+
+// Drained implements volman.DrainingRegistry.
+func (d *pluginRegistry) Drained(id string) {
+	d.Lock()
+	defer d.Unlock()
+
+	delete(d.draining, id)
+}
+
+// Subscribe implements volman.RegistryEventSource.
+func (d *pluginRegistry) Subscribe(handle func(volman.RegistryEvent)) func() {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.subscriptions == nil {
+		d.subscriptions = map[*registrySubscription]struct{}{}
+	}
+	subscription := newRegistrySubscription(handle)
+	d.subscriptions[subscription] = struct{}{}
+
+	return func() {
+		d.Lock()
+		defer d.Unlock()
+
+		if _, subscribed := d.subscriptions[subscription]; subscribed {
+			delete(d.subscriptions, subscription)
+			subscription.stop()
+		}
+	}
 }
 
 func (d *pluginRegistry) Keys() []string {
@@ -64,3 +160,173 @@ func (d *pluginRegistry) containsPlugin(id string) bool {
 	_, ok := d.registryEntries[id]
 	return ok
 }
+
+// replaceEntries registers plugins, moving the drivers that are no longer registered to
+// draining, and reports the changes to subscribers. The caller must hold the lock.
+func (d *pluginRegistry) replaceEntries(plugins map[string]volman.Plugin) {
+	events := registryEvents(d.registryEntries, plugins)
+
+	if d.drainTimeout > 0 {
+		if d.draining == nil {
+			d.draining = map[string]drainingPlugin{}
+		}
+		for driverId, draining := range d.draining {
+			if _, found := plugins[driverId]; found || d.drainExpired(draining) {
+				delete(d.draining, driverId)
+			}
+		}
+		for driverId, plugin := range d.registryEntries {
+			if _, found := plugins[driverId]; !found {
+				d.draining[driverId] = drainingPlugin{plugin: plugin, since: d.clock.Now()}
+			}
+		}
+	}
+
+	d.registryEntries = plugins
+
+	for subscription := range d.subscriptions {
+		subscription.publish(events)
+	}
+}
+
+func (d *pluginRegistry) drainExpired(draining drainingPlugin) bool {
+	return d.clock.Since(draining.since) >= d.drainTimeout
+}
+
+// withoutPlugin copies plugins without the given driver, leaving plugins untouched since
+// callers of Set may still hold it.
+func withoutPlugin(plugins map[string]volman.Plugin, driverId string) map[string]volman.Plugin {
+	remaining := make(map[string]volman.Plugin, len(plugins))
+	for id, plugin := range plugins {
+		if id != driverId {
+			remaining[id] = plugin
+		}
+	}
+	return remaining
+}
+
+// registryEvents lists the changes from one set of plugins to the next, ordered by
+// driver id.
+func registryEvents(previous map[string]volman.Plugin, current map[string]volman.Plugin) []volman.RegistryEvent {
+	var events []volman.RegistryEvent
+	for driverId, plugin := range current {
+		previousPlugin, found := previous[driverId]
+		switch {
+		case !found:
+			events = append(events, volman.RegistryEvent{Type: volman.RegistryEventAdded, DriverId: driverId, NewSpec: plugin.GetPluginSpec()})
+		case !samePlugin(previousPlugin, plugin) || !reflect.DeepEqual(previousPlugin.GetPluginSpec(), plugin.GetPluginSpec()):
+			events = append(events, volman.RegistryEvent{Type: volman.RegistryEventUpdated, DriverId: driverId, OldSpec: previousPlugin.GetPluginSpec(), NewSpec: plugin.GetPluginSpec()})
+		}
+	}
+	for driverId, plugin := range previous {
+		if _, found := current[driverId]; !found {
+			events = append(events, volman.RegistryEvent{Type: volman.RegistryEventRemoved, DriverId: driverId, OldSpec: plugin.GetPluginSpec()})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DriverId < events[j].DriverId
+	})
+	return events
+}
+
+// samePlugin reports whether two plugins are the same one. Plugins are compared by
+// identity when they are pointers, as they usually are. Other plugins may be of types
+// that == panics on, so they are compared by type and spec.
+func samePlugin(a volman.Plugin, b volman.Plugin) bool {
+	aValue, bValue := reflect.ValueOf(a), reflect.ValueOf(b)
+	if aValue.Type() != bValue.Type() {
+		return false
+	}
+	if aValue.Kind() == reflect.Pointer {
+		return aValue.Pointer() == bValue.Pointer()
+	}
+	return reflect.DeepEqual(a.GetPluginSpec(), b.GetPluginSpec())
+}
+
+// subscribeTo subscribes to registry when it reports its changes, and does nothing
+// otherwise.
+func subscribeTo(registry volman.PluginRegistry, handle func(volman.RegistryEvent)) func() {
+	if source, ok := registry.(volman.RegistryEventSource); ok {
+		return source.Subscribe(handle)
+	}
+	return func() {}
+}
+
+// drainingPluginOf looks up a draining driver when registry drains them, and reports
+// false otherwise.
+func drainingPluginOf(registry volman.PluginRegistry, id string) (volman.Plugin, bool) {
+	if draining, ok := registry.(volman.DrainingRegistry); ok {
+		return draining.Draining(id)
+	}
+	return nil, false
+}
+
+// registrySubscription queues events for a subscriber and hands them over in order on
+// its own goroutine, so that publishing never waits for the subscriber.
+type registrySubscription struct {
+	handle func(volman.RegistryEvent)
+
+	mutex sync.Mutex
+	queue []volman.RegistryEvent
+	wake  chan struct{}
+	done  chan struct{}
+}
+
+func newRegistrySubscription(handle func(volman.RegistryEvent)) *registrySubscription {
+	subscription := &registrySubscription{
+		handle: handle,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go subscription.run()
+	return subscription
+}
+
+func (s *registrySubscription) publish(events []volman.RegistryEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	s.queue = append(s.queue, events...)
+	s.mutex.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *registrySubscription) stop() {
+	close(s.done)
+}
+
+func (s *registrySubscription) run() {
+	for {
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+
+		for {
+			s.mutex.Lock()
+			events := s.queue
+			s.queue = nil
+			s.mutex.Unlock()
+
+			if len(events) == 0 {
+				break
+			}
+			for _, event := range events {
+				select {
+				case <-s.done:
+					return
+				default:
+				}
+				s.handle(event)
+			}
+		}
+	}
+}
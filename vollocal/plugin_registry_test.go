@@ -1,16 +1,25 @@
 package vollocal_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"code.cloudfoundry.org/volman/voldocker"
 	. "code.cloudfoundry.org/volman/vollocal"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	"code.cloudfoundry.org/dockerdriver/dockerdriverfakes"
 	"code.cloudfoundry.org/volman"
 )
 
+// labelledPlugin is a plugin of a type that == panics on, since it holds a map.
+type labelledPlugin struct {
+	volman.Plugin
+	labels map[string]string
+}
+
 var _ = Describe("PluginRegistry", func() {
 	var (
 		emptyRegistry, oneRegistry, manyRegistry volman.PluginRegistry
@@ -91,4 +100,168 @@ var _ = Describe("PluginRegistry", func() {
 			Expect(keys[0]).To(Equal("one"))
 		})
 	})
+
+	Describe("draining", func() {
+		var (
+			fakeClock *fakeclock.FakeClock
+			registry  volman.PluginRegistry
+			draining  volman.DrainingRegistry
+			onePlugin volman.Plugin
+		)
+
+		BeforeEach(func() {
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			registry = NewPluginRegistryWithDrainTimeout(fakeClock, time.Minute)
+			draining = registry.(volman.DrainingRegistry)
+
+			onePlugin = voldocker.NewVolmanPluginWithDockerDriver(new(dockerdriverfakes.FakeDriver), volman.PluginSpec{Name: "one"})
+			registry.Set(map[string]volman.Plugin{"one": onePlugin})
+		})
+
+		It("keeps removed drivers draining until the drain timeout", func() {
+			registry.Set(map[string]volman.Plugin{})
+
+			_, found := registry.Plugin("one")
+			Expect(found).To(BeFalse())
+			plugin, found := draining.Draining("one")
+			Expect(found).To(BeTrue())
+			Expect(plugin).To(Equal(onePlugin))
+
+			fakeClock.Increment(time.Minute)
+			_, found = draining.Draining("one")
+			Expect(found).To(BeFalse())
+		})
+
+		It("forgets drivers that drained", func() {
+			registry.Set(map[string]volman.Plugin{})
+			draining.Drained("one")
+
+			_, found := draining.Draining("one")
+			Expect(found).To(BeFalse())
+		})
+
+		It("stops draining drivers that come back", func() {
+			registry.Set(map[string]volman.Plugin{})
+			registry.Set(map[string]volman.Plugin{"one": onePlugin})
+
+			_, found := draining.Draining("one")
+			Expect(found).To(BeFalse())
+			_, found = registry.Plugin("one")
+			Expect(found).To(BeTrue())
+		})
+
+		It("keeps drivers marked for removal out until they are no longer found", func() {
+			Expect(draining.Drain("one")).To(BeTrue())
+
+			registry.Set(map[string]volman.Plugin{"one": onePlugin})
+			_, found := registry.Plugin("one")
+			Expect(found).To(BeFalse())
+			_, found = draining.Draining("one")
+			Expect(found).To(BeTrue())
+
+			registry.Set(map[string]volman.Plugin{})
+			registry.Set(map[string]volman.Plugin{"one": onePlugin})
+			_, found = registry.Plugin("one")
+			Expect(found).To(BeTrue())
+		})
+
+		It("does not drain drivers without a drain timeout", func() {
+			registry = NewPluginRegistry()
+			registry.Set(map[string]volman.Plugin{"one": onePlugin})
+			registry.Set(map[string]volman.Plugin{})
+
+			_, found := registry.(volman.DrainingRegistry).Draining("one")
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("#Subscribe", func() {
+		var (
+			events      <-chan volman.RegistryEvent
+			unsubscribe func()
+			onePlugin   volman.Plugin
+		)
+
+		pluginAt := func(address string) volman.Plugin {
+			return voldocker.NewVolmanPluginWithDockerDriver(new(dockerdriverfakes.FakeDriver), volman.PluginSpec{Name: "one", Address: address})
+		}
+
+		BeforeEach(func() {
+			onePlugin = pluginAt("/var/vcap/data/voldrivers/one.sock")
+			oneRegistry.Set(map[string]volman.Plugin{"one": onePlugin})
+			events, unsubscribe = volman.SubscribeChannel(oneRegistry.(volman.RegistryEventSource))
+		})
+
+		AfterEach(func() {
+			unsubscribe()
+		})
+
+		It("reports drivers being added, updated and removed, in order", func() {
+			twoPlugin := voldocker.NewVolmanPluginWithDockerDriver(new(dockerdriverfakes.FakeDriver), volman.PluginSpec{Name: "two"})
+			updatedPlugin := pluginAt("http://0.0.0.0:8080")
+
+			oneRegistry.Set(map[string]volman.Plugin{"one": onePlugin, "two": twoPlugin})
+			oneRegistry.Set(map[string]volman.Plugin{"one": updatedPlugin, "two": twoPlugin})
+			oneRegistry.Set(map[string]volman.Plugin{"one": updatedPlugin})
+
+			Eventually(events).Should(Receive(Equal(volman.RegistryEvent{
+				Type:     volman.RegistryEventAdded,
+				DriverId: "two",
+				NewSpec:  volman.PluginSpec{Name: "two"},
+			})))
+			Eventually(events).Should(Receive(Equal(volman.RegistryEvent{
+				Type:     volman.RegistryEventUpdated,
+				DriverId: "one",
+				OldSpec:  volman.PluginSpec{Name: "one", Address: "/var/vcap/data/voldrivers/one.sock"},
+				NewSpec:  volman.PluginSpec{Name: "one", Address: "http://0.0.0.0:8080"},
+			})))
+			Eventually(events).Should(Receive(Equal(volman.RegistryEvent{
+				Type:     volman.RegistryEventRemoved,
+				DriverId: "two",
+				OldSpec:  volman.PluginSpec{Name: "two"},
+			})))
+		})
+
+		It("compares plugins of types that cannot be compared with ==", func() {
+			labelled := labelledPlugin{Plugin: onePlugin, labels: map[string]string{"zone": "z1"}}
+			oneRegistry.Set(map[string]volman.Plugin{"one": labelled})
+			Eventually(events).Should(Receive(HaveField("Type", volman.RegistryEventUpdated)))
+
+			Expect(func() {
+				oneRegistry.Set(map[string]volman.Plugin{"one": labelledPlugin{Plugin: onePlugin, labels: map[string]string{"zone": "z2"}}})
+			}).NotTo(Panic())
+			Consistently(events).ShouldNot(Receive())
+		})
+
+		It("reports nothing when the drivers stay the same", func() {
+			oneRegistry.Set(map[string]volman.Plugin{"one": onePlugin})
+			Consistently(events).ShouldNot(Receive())
+		})
+
+		It("does not hold up the registry for subscribers that are not reading", func() {
+			for i := 0; i < 10; i++ {
+				oneRegistry.Set(map[string]volman.Plugin{})
+				oneRegistry.Set(map[string]volman.Plugin{"one": onePlugin})
+			}
+
+			for i := 0; i < 20; i++ {
+				Eventually(events).Should(Receive())
+			}
+		})
+
+		It("stops reporting once unsubscribed", func() {
+			unsubscribe()
+			oneRegistry.Set(map[string]volman.Plugin{})
+			Consistently(events).ShouldNot(Receive())
+		})
+
+		It("is passed on by the circuit breaker registry", func() {
+			breakers := NewCircuitBreakerRegistry(oneRegistry, nil, nil, PerDriver[CircuitBreakerPolicy]{})
+			breakerEvents, unsubscribeBreakers := volman.SubscribeChannel(breakers)
+			defer unsubscribeBreakers()
+
+			oneRegistry.Set(map[string]volman.Plugin{})
+			Eventually(breakerEvents).Should(Receive(HaveField("Type", volman.RegistryEventRemoved)))
+		})
+	})
 })
@@ -0,0 +1,94 @@
+package vollocal
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const (
+	volmanMountRetriesCounter   = "VolmanMountRetries"
+	volmanUnmountRetriesCounter = "VolmanUnmountRetries"
+)
+
+// RetryPolicy controls how often a failed driver call is retried. MaxAttempts counts the
+// first call, so a value of 0 or 1 disables retries. Backoff doubles after every attempt,
+// starting at InitialBackoff and capped at MaxBackoff, with up to half of it jittered away.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// transientErrorMessages are the failures seen while a driver process is restarting. The
+// driver client reports them as plain error strings, so they are matched by message.
+var transientErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"dial unix",
+	"unexpected EOF",
+}
+
+// retryable reports whether err is a transient failure worth retrying. Errors meant for
+// the user, timeouts and cancellations are never retried.
+func retryable(err error) bool {
+	if isSafeError(err) || isTimeout(err) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	for _, message := range transientErrorMessages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetries makes the driver call, retrying transient failures according to the
+// driver's retry policy for as long as ctx allows.
+func (client *localClient) withRetries(ctx context.Context, logger lager.Logger, pluginId string, operation string, counter string, call func() error) error {
+	policy := client.config.Retry.For(pluginId)
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		backoff := policy.backoff(attempt)
+		logger.Info("retrying-"+operation, lager.Data{"attempt": attempt, "max-attempts": policy.MaxAttempts, "backoff": backoff.String(), "error": err.Error()})
+		if metricErr := client.metronClient.IncrementCounter(counter); metricErr != nil {
+			logger.Debug("failed-emitting-retry-metric", lager.Data{"error": metricErr})
+		}
+
+		timer := client.clock.NewTimer(backoff)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
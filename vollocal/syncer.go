@@ -1,8 +1,12 @@
 package vollocal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
@@ -17,6 +21,49 @@ type Syncer struct {
 	scanInterval time.Duration
 	clock        clock.Clock
 	discoverer   []volman.Discoverer
+	watcher      volman.DriverWatcher
+
+	// staleAfter bounds how long drivers from a failing source are kept
+	staleAfter time.Duration
+
+	// syncMutex keeps full and single driver rediscoveries from overwriting each
+	// other's registry updates, and guards lastGood
+	syncMutex sync.Mutex
+	lastGood  []map[string]discoveredPlugin
+}
+
+// defaultDiscoveryStaleAfter is how long syncers not given a staleness bound keep the
+// drivers of a failing source.
+const defaultDiscoveryStaleAfter = time.Minute * 5
+
+type discoveredPlugin struct {
+	plugin       volman.Plugin
+	discoveredAt time.Time
+	// source is where the plugin was discovered, if its discoverer is a SourcedDiscoverer
+	source string
+}
+
+// DiscoveryResult describes what a discovery changed in the registry. The drivers of
+// sources that failed are kept as they were last discovered, and reported as Stale, until
+// they have not been seen for longer than the syncer's staleness bound.
+type DiscoveryResult struct {
+	Added   []string
+	Removed []string
+	Changed []string
+	Stale   []string
+	Failed  []volman.DiscoveryFailure
+}
+
+func (r DiscoveryResult) data() lager.Data {
+	var failed []string
+	for _, failure := range r.Failed {
+		failed = append(failed, failure.Source)
+	}
+	return lager.Data{"added": r.Added, "removed": r.Removed, "changed": r.Changed, "stale": r.Stale, "failed": failed}
+}
+
+func (r DiscoveryResult) empty() bool {
+	return len(r.Added)+len(r.Removed)+len(r.Changed)+len(r.Stale)+len(r.Failed) == 0
 }
 
 func NewSyncer(logger lager.Logger, registry volman.PluginRegistry, discoverer []volman.Discoverer, scanInterval time.Duration, clock clock.Clock) *Syncer {
@@ -26,6 +73,7 @@ func NewSyncer(logger lager.Logger, registry volman.PluginRegistry, discoverer [
 		scanInterval: scanInterval,
 		clock:        clock,
 		discoverer:   discoverer,
+		staleAfter:   defaultDiscoveryStaleAfter,
 	}
 }
 
@@ -36,6 +84,28 @@ func NewSyncerWithShims(logger lager.Logger, registry volman.PluginRegistry, dis
 		scanInterval: scanInterval,
 		clock:        clock,
 		discoverer:   discoverer,
+		staleAfter:   defaultDiscoveryStaleAfter,
+	}
+}
+
+// NewSyncerWithWatcher returns a syncer that also rediscovers a driver as soon as watcher
+// reports a change to it, keeping the full rediscovery every scanInterval as a safety net.
+func NewSyncerWithWatcher(logger lager.Logger, registry volman.PluginRegistry, discoverer []volman.Discoverer, scanInterval time.Duration, clock clock.Clock, watcher volman.DriverWatcher) *Syncer {
+	return NewSyncerWithStaleAfter(logger, registry, discoverer, scanInterval, clock, watcher, defaultDiscoveryStaleAfter)
+}
+
+// NewSyncerWithStaleAfter returns a syncer that keeps the drivers of a failing discoverer
+// or driver path for up to staleAfter since they were last discovered, or for as long as
+// the failure lasts if staleAfter is not positive. watcher may be nil.
+func NewSyncerWithStaleAfter(logger lager.Logger, registry volman.PluginRegistry, discoverer []volman.Discoverer, scanInterval time.Duration, clock clock.Clock, watcher volman.DriverWatcher, staleAfter time.Duration) *Syncer {
+	return &Syncer{
+		logger:       logger,
+		registry:     registry,
+		scanInterval: scanInterval,
+		clock:        clock,
+		discoverer:   discoverer,
+		watcher:      watcher,
+		staleAfter:   staleAfter,
 	}
 }
 
@@ -48,13 +118,18 @@ func (p *Syncer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	logger.Info("start")
 	defer logger.Info("end")
 
-	logger.Info("running-discovery")
-	allPlugins, err := discoverAllplugins(logger, p.discoverer)
-	if err != nil {
-		return err
+	// cancelled on return, so that a re-discovery still in flight does not outlive the syncer
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// watching starts before the first discovery, so that no change goes unnoticed
+	var changes <-chan string
+	if p.watcher != nil {
+		changes = p.watcher.Watch(ctx, logger)
 	}
 
-	p.registry.Set(allPlugins)
+	logger.Info("running-discovery")
+	p.Sync(ctx, logger)
 
 	timer := p.clock.NewTimer(p.scanInterval)
 	defer timer.Stop()
@@ -66,13 +141,18 @@ func (p *Syncer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 		case <-timer.C():
 			go func() {
 				logger.Info("running-re-discovery")
-				allPlugins, err := discoverAllplugins(logger, p.discoverer)
-				if err != nil {
-					logger.Error("failed-discover", err)
+				p.Sync(ctx, logger)
+				if ctx.Err() != nil {
+					return
 				}
-				p.registry.Set(allPlugins)
 				timer.Reset(p.scanInterval)
 			}()
+		case driverId, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+			go p.syncDriver(ctx, logger, driverId)
 		case signal := <-signals:
 			logger.Info("signalled", lager.Data{"signal": signal.String()})
 			return nil
@@ -80,18 +160,196 @@ func (p *Syncer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	}
 }
 
-func discoverAllplugins(logger lager.Logger, discoverers []volman.Discoverer) (map[string]volman.Plugin, error) {
+// Sync runs every discoverer and updates the registry with what they found. A failing
+// discoverer does not unregister the drivers it found before; they are kept until the
+// staleness bound. When it reports only some of its sources failed, with a
+// volman.DiscoveryError, only the drivers of those sources are kept, as far as a
+// volman.SourcedDiscoverer can tell where its drivers came from.
+func (p *Syncer) Sync(ctx context.Context, logger lager.Logger) DiscoveryResult {
+	p.syncMutex.Lock()
+	defer p.syncMutex.Unlock()
+
+	p.initLastGood()
+
+	now := p.clock.Now()
+	result := DiscoveryResult{}
 	allPlugins := map[string]volman.Plugin{}
-	for _, discoverer := range discoverers {
-		plugins, err := discoverer.Discover(logger)
-		logger.Debug(fmt.Sprintf("plugins found: %#v", plugins))
+
+	for i, discoverer := range p.discoverer {
+		plugins, err := discoverer.Discover(ctx, logger)
+		logger.Debug("plugins-found", lager.Data{"plugins": pluginNames(plugins)})
+
+		discovered := map[string]discoveredPlugin{}
+		for name, plugin := range plugins {
+			discovered[name] = discoveredPlugin{plugin: plugin, discoveredAt: now, source: driverSource(discoverer, name)}
+		}
+
 		if err != nil {
 			logger.Error("failed-discover", err)
-			return map[string]volman.Plugin{}, err
+			result.Failed = append(result.Failed, discoveryFailures(i, err)...)
+
+			for name, previous := range p.lastGood[i] {
+				if _, found := discovered[name]; found {
+					continue
+				}
+				if !failedSource(err, previous.source) {
+					continue
+				}
+				if p.staleAfter > 0 && now.Sub(previous.discoveredAt) > p.staleAfter {
+					logger.Info("dropping-stale-driver", lager.Data{"driver": name, "discoveredAt": previous.discoveredAt})
+					continue
+				}
+				discovered[name] = previous
+				result.Stale = append(result.Stale, name)
+			}
+		}
+
+		p.lastGood[i] = discovered
+		for name, discoveredPlugin := range discovered {
+			allPlugins[name] = discoveredPlugin.plugin
+		}
+	}
+
+	if ctx.Err() != nil {
+		return result
+	}
+
+	registered := p.registry.Plugins()
+	for name, plugin := range allPlugins {
+		if previous, found := registered[name]; !found {
+			result.Added = append(result.Added, name)
+		} else if !samePlugin(previous, plugin) {
+			result.Changed = append(result.Changed, name)
+		}
+	}
+	for name := range registered {
+		if _, found := allPlugins[name]; !found {
+			result.Removed = append(result.Removed, name)
+		}
+	}
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Changed)
+	sort.Strings(result.Stale)
+
+	p.registry.Set(allPlugins)
+
+	if result.empty() {
+		logger.Debug("discovery-result", result.data())
+	} else {
+		logger.Info("discovery-result", result.data())
+	}
+	return result
+}
+
+// initLastGood prepares the record of what each discoverer last found. The caller must
+// hold syncMutex.
+func (p *Syncer) initLastGood() {
+	if p.lastGood != nil {
+		return
+	}
+	p.lastGood = make([]map[string]discoveredPlugin, len(p.discoverer))
+	for i := range p.lastGood {
+		p.lastGood[i] = map[string]discoveredPlugin{}
+	}
+}
+
+// driverSource returns the source the discoverer last discovered the driver in, if it
+// can tell.
+func driverSource(discoverer volman.Discoverer, driverId string) string {
+	if sourced, ok := discoverer.(volman.SourcedDiscoverer); ok {
+		source, _ := sourced.DriverSource(driverId)
+		return source
+	}
+	return ""
+}
+
+// failedSource reports whether the discoverer error covers source. Errors that do not
+// name the sources that failed cover them all, as does an unknown source.
+func failedSource(err error, source string) bool {
+	var discoveryErr volman.DiscoveryError
+	if source == "" || !errors.As(err, &discoveryErr) {
+		return true
+	}
+	for _, failure := range discoveryErr.Failures {
+		if failure.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// discoveryFailures breaks a discoverer's error down into the sources that failed, naming
+// the discoverer itself when it does not say.
+func discoveryFailures(discovererIndex int, err error) []volman.DiscoveryFailure {
+	var discoveryErr volman.DiscoveryError
+	if errors.As(err, &discoveryErr) {
+		return discoveryErr.Failures
+	}
+	return []volman.DiscoveryFailure{{Source: fmt.Sprintf("discoverer %d", discovererIndex), Err: err}}
+}
+
+func pluginNames(plugins map[string]volman.Plugin) []string {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// syncDriver rediscovers a single driver and updates just its registry entry. Drivers
+// are only removed when every discoverer can look for single drivers, and none finds it.
+func (p *Syncer) syncDriver(ctx context.Context, logger lager.Logger, driverId string) {
+	logger = logger.Session("sync-driver", lager.Data{"driverId": driverId})
+	logger.Debug("start")
+	defer logger.Debug("end")
+
+	p.syncMutex.Lock()
+	defer p.syncMutex.Unlock()
+
+	p.initLastGood()
+
+	// like a full discovery, later discoverers win over earlier ones
+	var plugin volman.Plugin
+	found, removable := false, true
+	for i, discoverer := range p.discoverer {
+		driverDiscoverer, ok := discoverer.(volman.DriverDiscoverer)
+		if !ok {
+			removable = false
+			continue
+		}
+
+		discovered, discoveredFound, err := driverDiscoverer.DiscoverDriver(ctx, logger, driverId)
+		if err != nil {
+			logger.Error("failed-discover-driver", err)
+			return
+		}
+		if discoveredFound {
+			plugin, found = discovered, true
+			p.lastGood[i][driverId] = discoveredPlugin{plugin: discovered, discoveredAt: p.clock.Now(), source: driverSource(discoverer, driverId)}
+		} else {
+			delete(p.lastGood[i], driverId)
 		}
-		for k, v := range plugins {
-			allPlugins[k] = v
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	plugins := map[string]volman.Plugin{}
+	for name, existing := range p.registry.Plugins() {
+		plugins[name] = existing
+	}
+
+	if found {
+		logger.Info("driver-updated")
+		plugins[driverId] = plugin
+	} else {
+		if _, registered := plugins[driverId]; !registered || !removable {
+			return
 		}
+		logger.Info("driver-removed")
+		delete(plugins, driverId)
 	}
-	return allPlugins, nil
+	p.registry.Set(plugins)
 }
@@ -1,6 +1,9 @@
 package vollocal_test
 
 import (
+	"context"
+	"errors"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -11,6 +14,7 @@ import (
 	"code.cloudfoundry.org/clock/fakeclock"
 	. "code.cloudfoundry.org/volman/vollocal"
 	"code.cloudfoundry.org/volman/volmanfakes"
+	"github.com/onsi/gomega/gbytes"
 	"github.com/tedsuo/ifrit"
 	ginkgomon "github.com/tedsuo/ifrit/ginkgomon_v2"
 
@@ -146,4 +150,211 @@ var _ = Describe("Syncer", func() {
 			})
 		})
 	})
+
+	Describe("#Sync", func() {
+		var (
+			healthyDiscoverer *volmanfakes.FakeDiscoverer
+			flakyDiscoverer   *volmanfakes.FakeDiscoverer
+			plugin1           *volmanfakes.FakePlugin
+			plugin2           *volmanfakes.FakePlugin
+			staleAfter        time.Duration
+		)
+
+		BeforeEach(func() {
+			plugin1 = &volmanfakes.FakePlugin{}
+			plugin2 = &volmanfakes.FakePlugin{}
+			healthyDiscoverer = &volmanfakes.FakeDiscoverer{}
+			healthyDiscoverer.DiscoverReturns(map[string]volman.Plugin{"plugin1": plugin1}, nil)
+			flakyDiscoverer = &volmanfakes.FakeDiscoverer{}
+			flakyDiscoverer.DiscoverReturns(map[string]volman.Plugin{"plugin2": plugin2}, nil)
+			staleAfter = time.Minute
+		})
+
+		JustBeforeEach(func() {
+			syncer = NewSyncerWithStaleAfter(logger, registry, []volman.Discoverer{healthyDiscoverer, flakyDiscoverer}, scanInterval, fakeClock, nil, staleAfter)
+			result := syncer.Sync(context.Background(), logger)
+			Expect(result.Added).To(Equal([]string{"plugin1", "plugin2"}))
+		})
+
+		It("reports what changed in the registry", func() {
+			plugin3 := &volmanfakes.FakePlugin{}
+			healthyDiscoverer.DiscoverReturns(map[string]volman.Plugin{"plugin1": &volmanfakes.FakePlugin{}, "plugin3": plugin3}, nil)
+			flakyDiscoverer.DiscoverReturns(map[string]volman.Plugin{}, nil)
+
+			result := syncer.Sync(context.Background(), logger)
+			Expect(result).To(Equal(DiscoveryResult{
+				Added:   []string{"plugin3"},
+				Removed: []string{"plugin2"},
+				Changed: []string{"plugin1"},
+			}))
+			Expect(registry.Plugins()).To(HaveLen(2))
+			Expect(logger).To(gbytes.Say("discovery-result"))
+		})
+
+		Context("when a discoverer fails", func() {
+			BeforeEach(func() {
+				flakyDiscoverer.DiscoverReturnsOnCall(1, nil, errors.New("glob failed"))
+			})
+
+			It("keeps the drivers it last found, and those of the other discoverers", func() {
+				result := syncer.Sync(context.Background(), logger)
+
+				Expect(registry.Plugins()).To(Equal(map[string]volman.Plugin{"plugin1": plugin1, "plugin2": plugin2}))
+				Expect(result.Stale).To(Equal([]string{"plugin2"}))
+				Expect(result.Removed).To(BeEmpty())
+				Expect(result.Failed).To(Equal([]volman.DiscoveryFailure{{Source: "discoverer 1", Err: errors.New("glob failed")}}))
+			})
+
+			It("drops them once they have not been seen for longer than the staleness bound", func() {
+				flakyDiscoverer.DiscoverReturns(nil, errors.New("glob failed"))
+				syncer.Sync(context.Background(), logger)
+
+				fakeClock.Increment(staleAfter + time.Second)
+				result := syncer.Sync(context.Background(), logger)
+
+				Expect(registry.Plugins()).To(HaveLen(1))
+				Expect(result.Removed).To(Equal([]string{"plugin2"}))
+				Expect(logger).To(gbytes.Say("dropping-stale-driver"))
+			})
+
+			Context("without a staleness bound", func() {
+				BeforeEach(func() {
+					staleAfter = 0
+				})
+
+				It("keeps them for as long as the failure lasts", func() {
+					flakyDiscoverer.DiscoverReturns(nil, errors.New("glob failed"))
+					fakeClock.Increment(time.Hour)
+					syncer.Sync(context.Background(), logger)
+					Expect(registry.Plugins()).To(HaveKey("plugin2"))
+				})
+			})
+		})
+
+		Context("when some sources of a discoverer fail", func() {
+			var failure volman.DiscoveryFailure
+
+			BeforeEach(func() {
+				failure = volman.DiscoveryFailure{Source: "/var/vcap/data/voldrivers", Err: errors.New("bad pattern")}
+				flakyDiscoverer.DiscoverReturnsOnCall(1, map[string]volman.Plugin{"plugin4": plugin1}, volman.DiscoveryError{Failures: []volman.DiscoveryFailure{failure}})
+			})
+
+			It("takes what was found and keeps the rest", func() {
+				result := syncer.Sync(context.Background(), logger)
+
+				Expect(registry.Plugins()).To(HaveKey("plugin2"))
+				Expect(registry.Plugins()).To(HaveKey("plugin4"))
+				Expect(result.Added).To(Equal([]string{"plugin4"}))
+				Expect(result.Failed).To(Equal([]volman.DiscoveryFailure{failure}))
+			})
+		})
+
+		Context("when a discoverer that knows the sources of its drivers fails in some of them", func() {
+			var sourcedDiscoverer *volmanfakes.FakeSourcedDiscoverer
+
+			BeforeEach(func() {
+				sourcedDiscoverer = &volmanfakes.FakeSourcedDiscoverer{}
+				sourcedDiscoverer.DiscoverReturnsOnCall(0, map[string]volman.Plugin{"plugin3": plugin1, "plugin4": plugin2}, nil)
+				sourcedDiscoverer.DriverSourceStub = func(driverId string) (string, bool) {
+					return map[string]string{"plugin3": "/var/vcap/data/voldrivers", "plugin4": "/var/vcap/data/other-voldrivers"}[driverId], true
+				}
+			})
+
+			JustBeforeEach(func() {
+				syncer = NewSyncerWithStaleAfter(logger, registry, []volman.Discoverer{healthyDiscoverer, sourcedDiscoverer}, scanInterval, fakeClock, nil, staleAfter)
+				syncer.Sync(context.Background(), logger)
+				Expect(registry.Plugins()).To(HaveLen(3))
+			})
+
+			It("keeps only the drivers of the sources that failed", func() {
+				failure := volman.DiscoveryFailure{Source: "/var/vcap/data/voldrivers", Err: errors.New("bad pattern")}
+				sourcedDiscoverer.DiscoverReturns(map[string]volman.Plugin{}, volman.DiscoveryError{Failures: []volman.DiscoveryFailure{failure}})
+
+				result := syncer.Sync(context.Background(), logger)
+				Expect(registry.Plugins()).To(HaveKey("plugin3"))
+				Expect(registry.Plugins()).NotTo(HaveKey("plugin4"))
+				Expect(result.Stale).To(Equal([]string{"plugin3"}))
+				Expect(result.Removed).To(Equal([]string{"plugin4"}))
+			})
+
+			It("keeps them all when the discoverer fails as a whole", func() {
+				sourcedDiscoverer.DiscoverReturns(nil, errors.New("glob failed"))
+
+				result := syncer.Sync(context.Background(), logger)
+				Expect(registry.Plugins()).To(HaveLen(3))
+				Expect(result.Stale).To(Equal([]string{"plugin3", "plugin4"}))
+			})
+		})
+	})
+
+	Describe("with a driver watcher", func() {
+		var (
+			fakeWatcher          *volmanfakes.FakeDriverWatcher
+			fakeDriverDiscoverer *volmanfakes.FakeDriverDiscoverer
+			changes              chan string
+			discoverers          []volman.Discoverer
+		)
+
+		BeforeEach(func() {
+			changes = make(chan string)
+			fakeWatcher = &volmanfakes.FakeDriverWatcher{}
+			fakeWatcher.WatchReturns(changes)
+
+			fakeDriverDiscoverer = &volmanfakes.FakeDriverDiscoverer{}
+			fakeDriverDiscoverer.DiscoverReturns(map[string]volman.Plugin{"plugin1": &volmanfakes.FakePlugin{}}, nil)
+			discoverers = []volman.Discoverer{fakeDriverDiscoverer}
+		})
+
+		JustBeforeEach(func() {
+			syncer = NewSyncerWithWatcher(logger, registry, discoverers, scanInterval, fakeClock, fakeWatcher)
+			process = ginkgomon.Invoke(syncer.Runner())
+		})
+
+		AfterEach(func() {
+			ginkgomon.Kill(process)
+		})
+
+		It("adds a driver as soon as it is reported, without a full rediscovery", func() {
+			plugin2 := &volmanfakes.FakePlugin{}
+			fakeDriverDiscoverer.DiscoverDriverReturns(plugin2, true, nil)
+
+			changes <- "plugin2"
+
+			Eventually(registry.Plugins).Should(HaveLen(2))
+			Expect(registry.Plugins()["plugin2"]).To(BeIdenticalTo(plugin2))
+			_, _, driverId := fakeDriverDiscoverer.DiscoverDriverArgsForCall(0)
+			Expect(driverId).To(Equal("plugin2"))
+			Expect(fakeDriverDiscoverer.DiscoverCallCount()).To(Equal(1))
+		})
+
+		It("removes a driver that can no longer be found", func() {
+			fakeDriverDiscoverer.DiscoverDriverReturns(nil, false, nil)
+
+			changes <- "plugin1"
+
+			Eventually(registry.Plugins).Should(BeEmpty())
+		})
+
+		It("keeps the periodic full rediscovery", func() {
+			fakeClock.Increment(scanInterval + 1)
+			Eventually(fakeDriverDiscoverer.DiscoverCallCount).Should(Equal(2))
+		})
+
+		Context("when another discoverer cannot discover single drivers", func() {
+			BeforeEach(func() {
+				fakeDiscoverer := &volmanfakes.FakeDiscoverer{}
+				fakeDiscoverer.DiscoverReturns(map[string]volman.Plugin{"plugin3": &volmanfakes.FakePlugin{}}, nil)
+				discoverers = append(discoverers, fakeDiscoverer)
+				fakeDriverDiscoverer.DiscoverDriverReturns(nil, false, nil)
+			})
+
+			It("leaves removing drivers to the full rediscovery", func() {
+				Eventually(registry.Plugins).Should(HaveLen(2))
+				changes <- "plugin3"
+
+				Eventually(fakeDriverDiscoverer.DiscoverDriverCallCount).Should(Equal(1))
+				Consistently(registry.Plugins).Should(HaveLen(2))
+			})
+		})
+	})
 })
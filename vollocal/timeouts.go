@@ -0,0 +1,61 @@
+package vollocal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/volman"
+)
+
+const (
+	volmanMountTimeoutsCounter   = "VolmanMountTimeouts"
+	volmanUnmountTimeoutsCounter = "VolmanUnmountTimeouts"
+)
+
+type callResult[T any] struct {
+	value T
+	err   error
+}
+
+// callWithTimeout calls the driver with a context bounded by timeout and returns a
+// volman.TimeoutError once the timeout passes. The call is abandoned at that point even
+// if the plugin ignores the context, so that a wedged driver cannot stall the caller.
+func callWithTimeout[T any](ctx context.Context, driverId string, operation string, timeout time.Duration, call func(context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return call(ctx)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make(chan callResult[T], 1)
+	go func() {
+		value, err := call(timeoutCtx)
+		results <- callResult[T]{value: value, err: err}
+	}()
+
+	select {
+	case result := <-results:
+		if result.err != nil && timedOut(ctx, timeoutCtx) {
+			return result.value, volman.TimeoutError{DriverId: driverId, Operation: operation, Timeout: timeout}
+		}
+		return result.value, result.err
+	case <-timeoutCtx.Done():
+		var zero T
+		if timedOut(ctx, timeoutCtx) {
+			return zero, volman.TimeoutError{DriverId: driverId, Operation: operation, Timeout: timeout}
+		}
+		return zero, ctx.Err()
+	}
+}
+
+// timedOut reports whether the timeout expired rather than the caller's own context.
+func timedOut(ctx context.Context, timeoutCtx context.Context) bool {
+	return errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+}
+
+func isTimeout(err error) bool {
+	var timeoutErr volman.TimeoutError
+	return errors.As(err, &timeoutErr)
+}
@@ -0,0 +1,73 @@
+package vollocal
+
+import (
+	"context"
+	"sync"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// volumeLocks serializes operations on the same driver volume, so that an unmount cannot
+// race a mount of the volume it is tearing down. Volumes are keyed by the id the driver
+// sees, which with UniqueVolumeIds differs per container. Locks are dropped once no
+// operation holds or waits for them.
+type volumeLocks struct {
+	mutex sync.Mutex
+	locks map[volumeKey]*volumeLock
+}
+
+type volumeKey struct {
+	driverId       string
+	driverVolumeId string
+}
+
+type volumeLock struct {
+	held chan struct{}
+	refs int
+}
+
+func newVolumeLocks() *volumeLocks {
+	return &volumeLocks{locks: map[volumeKey]*volumeLock{}}
+}
+
+// lock waits until no other operation holds the driver volume, or until ctx is done. The
+// returned unlock func must be called once the operation is finished.
+func (v *volumeLocks) lock(ctx context.Context, logger lager.Logger, driverId string, driverVolumeId string) (func(), error) {
+	key := volumeKey{driverId: driverId, driverVolumeId: driverVolumeId}
+
+	v.mutex.Lock()
+	lock, found := v.locks[key]
+	if !found {
+		lock = &volumeLock{held: make(chan struct{}, 1)}
+		v.locks[key] = lock
+	}
+	lock.refs++
+	v.mutex.Unlock()
+
+	select {
+	case lock.held <- struct{}{}:
+	default:
+		logger.Info("waiting-for-volume", lager.Data{"driverId": driverId, "driverVolumeId": driverVolumeId})
+		select {
+		case lock.held <- struct{}{}:
+		case <-ctx.Done():
+			v.drop(key, lock)
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() {
+		<-lock.held
+		v.drop(key, lock)
+	}, nil
+}
+
+func (v *volumeLocks) drop(key volumeKey, lock *volumeLock) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(v.locks, key)
+	}
+}
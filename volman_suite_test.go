@@ -0,0 +1,13 @@
+package volman_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestVolman(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Volman Suite")
+}
@@ -2,6 +2,7 @@
 package volmanfakes
 
 import (
+	context "context"
 	sync "sync"
 
 	lager "code.cloudfoundry.org/lager/v3"
@@ -9,10 +10,11 @@ import (
 )
 
 type FakeDiscoverer struct {
-	DiscoverStub        func(lager.Logger) (map[string]volman.Plugin, error)
+	DiscoverStub        func(context.Context, lager.Logger) (map[string]volman.Plugin, error)
 	discoverMutex       sync.RWMutex
 	discoverArgsForCall []struct {
-		arg1 lager.Logger
+		arg1 context.Context
+		arg2 lager.Logger
 	}
 	discoverReturns struct {
 		result1 map[string]volman.Plugin
@@ -26,16 +28,17 @@ type FakeDiscoverer struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeDiscoverer) Discover(arg1 lager.Logger) (map[string]volman.Plugin, error) {
+func (fake *FakeDiscoverer) Discover(arg1 context.Context, arg2 lager.Logger) (map[string]volman.Plugin, error) {
 	fake.discoverMutex.Lock()
 	ret, specificReturn := fake.discoverReturnsOnCall[len(fake.discoverArgsForCall)]
 	fake.discoverArgsForCall = append(fake.discoverArgsForCall, struct {
-		arg1 lager.Logger
-	}{arg1})
-	fake.recordInvocation("Discover", []interface{}{arg1})
+		arg1 context.Context
+		arg2 lager.Logger
+	}{arg1, arg2})
+	fake.recordInvocation("Discover", []interface{}{arg1, arg2})
 	fake.discoverMutex.Unlock()
 	if fake.DiscoverStub != nil {
-		return fake.DiscoverStub(arg1)
+		return fake.DiscoverStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -50,17 +53,17 @@ func (fake *FakeDiscoverer) DiscoverCallCount() int {
 	return len(fake.discoverArgsForCall)
 }
 
-func (fake *FakeDiscoverer) DiscoverCalls(stub func(lager.Logger) (map[string]volman.Plugin, error)) {
+func (fake *FakeDiscoverer) DiscoverCalls(stub func(context.Context, lager.Logger) (map[string]volman.Plugin, error)) {
 	fake.discoverMutex.Lock()
 	defer fake.discoverMutex.Unlock()
 	fake.DiscoverStub = stub
 }
 
-func (fake *FakeDiscoverer) DiscoverArgsForCall(i int) lager.Logger {
+func (fake *FakeDiscoverer) DiscoverArgsForCall(i int) (context.Context, lager.Logger) {
 	fake.discoverMutex.RLock()
 	defer fake.discoverMutex.RUnlock()
 	argsForCall := fake.discoverArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeDiscoverer) DiscoverReturns(result1 map[string]volman.Plugin, result2 error) {
@@ -0,0 +1,206 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package volmanfakes
+
+import (
+	context "context"
+	sync "sync"
+
+	lager "code.cloudfoundry.org/lager/v3"
+	volman "code.cloudfoundry.org/volman"
+)
+
+type FakeDriverDiscoverer struct {
+	DiscoverStub        func(context.Context, lager.Logger) (map[string]volman.Plugin, error)
+	discoverMutex       sync.RWMutex
+	discoverArgsForCall []struct {
+		arg1 context.Context
+		arg2 lager.Logger
+	}
+	discoverReturns struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}
+	discoverReturnsOnCall map[int]struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}
+	DiscoverDriverStub        func(context.Context, lager.Logger, string) (volman.Plugin, bool, error)
+	discoverDriverMutex       sync.RWMutex
+	discoverDriverArgsForCall []struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+	}
+	discoverDriverReturns struct {
+		result1 volman.Plugin
+		result2 bool
+		result3 error
+	}
+	discoverDriverReturnsOnCall map[int]struct {
+		result1 volman.Plugin
+		result2 bool
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDriverDiscoverer) Discover(arg1 context.Context, arg2 lager.Logger) (map[string]volman.Plugin, error) {
+	fake.discoverMutex.Lock()
+	ret, specificReturn := fake.discoverReturnsOnCall[len(fake.discoverArgsForCall)]
+	fake.discoverArgsForCall = append(fake.discoverArgsForCall, struct {
+		arg1 context.Context
+		arg2 lager.Logger
+	}{arg1, arg2})
+	fake.recordInvocation("Discover", []interface{}{arg1, arg2})
+	fake.discoverMutex.Unlock()
+	if fake.DiscoverStub != nil {
+		return fake.DiscoverStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.discoverReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverCallCount() int {
+	fake.discoverMutex.RLock()
+	defer fake.discoverMutex.RUnlock()
+	return len(fake.discoverArgsForCall)
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverCalls(stub func(context.Context, lager.Logger) (map[string]volman.Plugin, error)) {
+	fake.discoverMutex.Lock()
+	defer fake.discoverMutex.Unlock()
+	fake.DiscoverStub = stub
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverArgsForCall(i int) (context.Context, lager.Logger) {
+	fake.discoverMutex.RLock()
+	defer fake.discoverMutex.RUnlock()
+	argsForCall := fake.discoverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverReturns(result1 map[string]volman.Plugin, result2 error) {
+	fake.discoverMutex.Lock()
+	defer fake.discoverMutex.Unlock()
+	fake.DiscoverStub = nil
+	fake.discoverReturns = struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverReturnsOnCall(i int, result1 map[string]volman.Plugin, result2 error) {
+	fake.discoverMutex.Lock()
+	defer fake.discoverMutex.Unlock()
+	fake.DiscoverStub = nil
+	if fake.discoverReturnsOnCall == nil {
+		fake.discoverReturnsOnCall = make(map[int]struct {
+			result1 map[string]volman.Plugin
+			result2 error
+		})
+	}
+	fake.discoverReturnsOnCall[i] = struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverDriver(arg1 context.Context, arg2 lager.Logger, arg3 string) (volman.Plugin, bool, error) {
+	fake.discoverDriverMutex.Lock()
+	ret, specificReturn := fake.discoverDriverReturnsOnCall[len(fake.discoverDriverArgsForCall)]
+	fake.discoverDriverArgsForCall = append(fake.discoverDriverArgsForCall, struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("DiscoverDriver", []interface{}{arg1, arg2, arg3})
+	fake.discoverDriverMutex.Unlock()
+	if fake.DiscoverDriverStub != nil {
+		return fake.DiscoverDriverStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.discoverDriverReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverDriverCallCount() int {
+	fake.discoverDriverMutex.RLock()
+	defer fake.discoverDriverMutex.RUnlock()
+	return len(fake.discoverDriverArgsForCall)
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverDriverCalls(stub func(context.Context, lager.Logger, string) (volman.Plugin, bool, error)) {
+	fake.discoverDriverMutex.Lock()
+	defer fake.discoverDriverMutex.Unlock()
+	fake.DiscoverDriverStub = stub
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverDriverArgsForCall(i int) (context.Context, lager.Logger, string) {
+	fake.discoverDriverMutex.RLock()
+	defer fake.discoverDriverMutex.RUnlock()
+	argsForCall := fake.discoverDriverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverDriverReturns(result1 volman.Plugin, result2 bool, result3 error) {
+	fake.discoverDriverMutex.Lock()
+	defer fake.discoverDriverMutex.Unlock()
+	fake.DiscoverDriverStub = nil
+	fake.discoverDriverReturns = struct {
+		result1 volman.Plugin
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDriverDiscoverer) DiscoverDriverReturnsOnCall(i int, result1 volman.Plugin, result2 bool, result3 error) {
+	fake.discoverDriverMutex.Lock()
+	defer fake.discoverDriverMutex.Unlock()
+	fake.DiscoverDriverStub = nil
+	if fake.discoverDriverReturnsOnCall == nil {
+		fake.discoverDriverReturnsOnCall = make(map[int]struct {
+			result1 volman.Plugin
+			result2 bool
+			result3 error
+		})
+	}
+	fake.discoverDriverReturnsOnCall[i] = struct {
+		result1 volman.Plugin
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeDriverDiscoverer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.discoverMutex.RLock()
+	defer fake.discoverMutex.RUnlock()
+	fake.discoverDriverMutex.RLock()
+	defer fake.discoverDriverMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDriverDiscoverer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ volman.DriverDiscoverer = new(FakeDriverDiscoverer)
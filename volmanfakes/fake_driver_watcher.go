@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package volmanfakes
+
+import (
+	context "context"
+	sync "sync"
+
+	lager "code.cloudfoundry.org/lager/v3"
+	volman "code.cloudfoundry.org/volman"
+)
+
+type FakeDriverWatcher struct {
+	WatchStub        func(context.Context, lager.Logger) <-chan string
+	watchMutex       sync.RWMutex
+	watchArgsForCall []struct {
+		arg1 context.Context
+		arg2 lager.Logger
+	}
+	watchReturns struct {
+		result1 <-chan string
+	}
+	watchReturnsOnCall map[int]struct {
+		result1 <-chan string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDriverWatcher) Watch(arg1 context.Context, arg2 lager.Logger) <-chan string {
+	fake.watchMutex.Lock()
+	ret, specificReturn := fake.watchReturnsOnCall[len(fake.watchArgsForCall)]
+	fake.watchArgsForCall = append(fake.watchArgsForCall, struct {
+		arg1 context.Context
+		arg2 lager.Logger
+	}{arg1, arg2})
+	fake.recordInvocation("Watch", []interface{}{arg1, arg2})
+	fake.watchMutex.Unlock()
+	if fake.WatchStub != nil {
+		return fake.WatchStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.watchReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverWatcher) WatchCallCount() int {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return len(fake.watchArgsForCall)
+}
+
+func (fake *FakeDriverWatcher) WatchCalls(stub func(context.Context, lager.Logger) <-chan string) {
+	fake.watchMutex.Lock()
+	defer fake.watchMutex.Unlock()
+	fake.WatchStub = stub
+}
+
+func (fake *FakeDriverWatcher) WatchArgsForCall(i int) (context.Context, lager.Logger) {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	argsForCall := fake.watchArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverWatcher) WatchReturns(result1 <-chan string) {
+	fake.watchMutex.Lock()
+	defer fake.watchMutex.Unlock()
+	fake.WatchStub = nil
+	fake.watchReturns = struct {
+		result1 <-chan string
+	}{result1}
+}
+
+func (fake *FakeDriverWatcher) WatchReturnsOnCall(i int, result1 <-chan string) {
+	fake.watchMutex.Lock()
+	defer fake.watchMutex.Unlock()
+	fake.WatchStub = nil
+	if fake.watchReturnsOnCall == nil {
+		fake.watchReturnsOnCall = make(map[int]struct {
+			result1 <-chan string
+		})
+	}
+	fake.watchReturnsOnCall[i] = struct {
+		result1 <-chan string
+	}{result1}
+}
+
+func (fake *FakeDriverWatcher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDriverWatcher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ volman.DriverWatcher = new(FakeDriverWatcher)
@@ -0,0 +1,116 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package volmanfakes
+
+import (
+	sync "sync"
+
+	lager "code.cloudfoundry.org/lager/v3"
+	vollocal "code.cloudfoundry.org/volman/vollocal"
+)
+
+type FakeLiveContainers struct {
+	ContainerIdsStub        func(lager.Logger) ([]string, error)
+	containerIdsMutex       sync.RWMutex
+	containerIdsArgsForCall []struct {
+		arg1 lager.Logger
+	}
+	containerIdsReturns struct {
+		result1 []string
+		result2 error
+	}
+	containerIdsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeLiveContainers) ContainerIds(arg1 lager.Logger) ([]string, error) {
+	fake.containerIdsMutex.Lock()
+	ret, specificReturn := fake.containerIdsReturnsOnCall[len(fake.containerIdsArgsForCall)]
+	fake.containerIdsArgsForCall = append(fake.containerIdsArgsForCall, struct {
+		arg1 lager.Logger
+	}{arg1})
+	fake.recordInvocation("ContainerIds", []interface{}{arg1})
+	fake.containerIdsMutex.Unlock()
+	if fake.ContainerIdsStub != nil {
+		return fake.ContainerIdsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.containerIdsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLiveContainers) ContainerIdsCallCount() int {
+	fake.containerIdsMutex.RLock()
+	defer fake.containerIdsMutex.RUnlock()
+	return len(fake.containerIdsArgsForCall)
+}
+
+func (fake *FakeLiveContainers) ContainerIdsCalls(stub func(lager.Logger) ([]string, error)) {
+	fake.containerIdsMutex.Lock()
+	defer fake.containerIdsMutex.Unlock()
+	fake.ContainerIdsStub = stub
+}
+
+func (fake *FakeLiveContainers) ContainerIdsArgsForCall(i int) lager.Logger {
+	fake.containerIdsMutex.RLock()
+	defer fake.containerIdsMutex.RUnlock()
+	argsForCall := fake.containerIdsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLiveContainers) ContainerIdsReturns(result1 []string, result2 error) {
+	fake.containerIdsMutex.Lock()
+	defer fake.containerIdsMutex.Unlock()
+	fake.ContainerIdsStub = nil
+	fake.containerIdsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLiveContainers) ContainerIdsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.containerIdsMutex.Lock()
+	defer fake.containerIdsMutex.Unlock()
+	fake.ContainerIdsStub = nil
+	if fake.containerIdsReturnsOnCall == nil {
+		fake.containerIdsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.containerIdsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLiveContainers) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.containerIdsMutex.RLock()
+	defer fake.containerIdsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeLiveContainers) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ vollocal.LiveContainers = new(FakeLiveContainers)
@@ -2,6 +2,7 @@
 package volmanfakes
 
 import (
+	context "context"
 	sync "sync"
 
 	lager "code.cloudfoundry.org/lager/v3"
@@ -9,10 +10,28 @@ import (
 )
 
 type FakeManager struct {
-	ListDriversStub        func(lager.Logger) (volman.ListDriversResponse, error)
+	GetMountStub        func(context.Context, lager.Logger, string, string, string) (volman.MountInfo, error)
+	getMountMutex       sync.RWMutex
+	getMountArgsForCall []struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+		arg4 string
+		arg5 string
+	}
+	getMountReturns struct {
+		result1 volman.MountInfo
+		result2 error
+	}
+	getMountReturnsOnCall map[int]struct {
+		result1 volman.MountInfo
+		result2 error
+	}
+	ListDriversStub        func(context.Context, lager.Logger) (volman.ListDriversResponse, error)
 	listDriversMutex       sync.RWMutex
 	listDriversArgsForCall []struct {
-		arg1 lager.Logger
+		arg1 context.Context
+		arg2 lager.Logger
 	}
 	listDriversReturns struct {
 		result1 volman.ListDriversResponse
@@ -22,14 +41,47 @@ type FakeManager struct {
 		result1 volman.ListDriversResponse
 		result2 error
 	}
-	MountStub        func(lager.Logger, string, string, string, map[string]interface{}) (volman.MountResponse, error)
+	ListDriversMatchingStub        func(context.Context, lager.Logger, volman.DriverFilter) (volman.ListDriversResponse, error)
+	listDriversMatchingMutex       sync.RWMutex
+	listDriversMatchingArgsForCall []struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 volman.DriverFilter
+	}
+	listDriversMatchingReturns struct {
+		result1 volman.ListDriversResponse
+		result2 error
+	}
+	listDriversMatchingReturnsOnCall map[int]struct {
+		result1 volman.ListDriversResponse
+		result2 error
+	}
+	ListMountsStub        func(context.Context, lager.Logger, string, string, string) (volman.ListMountsResponse, error)
+	listMountsMutex       sync.RWMutex
+	listMountsArgsForCall []struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+		arg4 string
+		arg5 string
+	}
+	listMountsReturns struct {
+		result1 volman.ListMountsResponse
+		result2 error
+	}
+	listMountsReturnsOnCall map[int]struct {
+		result1 volman.ListMountsResponse
+		result2 error
+	}
+	MountStub        func(context.Context, lager.Logger, string, string, string, map[string]interface{}) (volman.MountResponse, error)
 	mountMutex       sync.RWMutex
 	mountArgsForCall []struct {
-		arg1 lager.Logger
-		arg2 string
+		arg1 context.Context
+		arg2 lager.Logger
 		arg3 string
 		arg4 string
-		arg5 map[string]interface{}
+		arg5 string
+		arg6 map[string]interface{}
 	}
 	mountReturns struct {
 		result1 volman.MountResponse
@@ -39,13 +91,14 @@ type FakeManager struct {
 		result1 volman.MountResponse
 		result2 error
 	}
-	UnmountStub        func(lager.Logger, string, string, string) error
+	UnmountStub        func(context.Context, lager.Logger, string, string, string) error
 	unmountMutex       sync.RWMutex
 	unmountArgsForCall []struct {
-		arg1 lager.Logger
-		arg2 string
+		arg1 context.Context
+		arg2 lager.Logger
 		arg3 string
 		arg4 string
+		arg5 string
 	}
 	unmountReturns struct {
 		result1 error
@@ -57,16 +110,84 @@ type FakeManager struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeManager) ListDrivers(arg1 lager.Logger) (volman.ListDriversResponse, error) {
+func (fake *FakeManager) GetMount(arg1 context.Context, arg2 lager.Logger, arg3 string, arg4 string, arg5 string) (volman.MountInfo, error) {
+	fake.getMountMutex.Lock()
+	ret, specificReturn := fake.getMountReturnsOnCall[len(fake.getMountArgsForCall)]
+	fake.getMountArgsForCall = append(fake.getMountArgsForCall, struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+		arg4 string
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("GetMount", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.getMountMutex.Unlock()
+	if fake.GetMountStub != nil {
+		return fake.GetMountStub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getMountReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) GetMountCallCount() int {
+	fake.getMountMutex.RLock()
+	defer fake.getMountMutex.RUnlock()
+	return len(fake.getMountArgsForCall)
+}
+
+func (fake *FakeManager) GetMountCalls(stub func(context.Context, lager.Logger, string, string, string) (volman.MountInfo, error)) {
+	fake.getMountMutex.Lock()
+	defer fake.getMountMutex.Unlock()
+	fake.GetMountStub = stub
+}
+
+func (fake *FakeManager) GetMountArgsForCall(i int) (context.Context, lager.Logger, string, string, string) {
+	fake.getMountMutex.RLock()
+	defer fake.getMountMutex.RUnlock()
+	argsForCall := fake.getMountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeManager) GetMountReturns(result1 volman.MountInfo, result2 error) {
+	fake.getMountMutex.Lock()
+	defer fake.getMountMutex.Unlock()
+	fake.GetMountStub = nil
+	fake.getMountReturns = struct {
+		result1 volman.MountInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) GetMountReturnsOnCall(i int, result1 volman.MountInfo, result2 error) {
+	fake.getMountMutex.Lock()
+	defer fake.getMountMutex.Unlock()
+	fake.GetMountStub = nil
+	if fake.getMountReturnsOnCall == nil {
+		fake.getMountReturnsOnCall = make(map[int]struct {
+			result1 volman.MountInfo
+			result2 error
+		})
+	}
+	fake.getMountReturnsOnCall[i] = struct {
+		result1 volman.MountInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) ListDrivers(arg1 context.Context, arg2 lager.Logger) (volman.ListDriversResponse, error) {
 	fake.listDriversMutex.Lock()
 	ret, specificReturn := fake.listDriversReturnsOnCall[len(fake.listDriversArgsForCall)]
 	fake.listDriversArgsForCall = append(fake.listDriversArgsForCall, struct {
-		arg1 lager.Logger
-	}{arg1})
-	fake.recordInvocation("ListDrivers", []interface{}{arg1})
+		arg1 context.Context
+		arg2 lager.Logger
+	}{arg1, arg2})
+	fake.recordInvocation("ListDrivers", []interface{}{arg1, arg2})
 	fake.listDriversMutex.Unlock()
 	if fake.ListDriversStub != nil {
-		return fake.ListDriversStub(arg1)
+		return fake.ListDriversStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -81,17 +202,17 @@ func (fake *FakeManager) ListDriversCallCount() int {
 	return len(fake.listDriversArgsForCall)
 }
 
-func (fake *FakeManager) ListDriversCalls(stub func(lager.Logger) (volman.ListDriversResponse, error)) {
+func (fake *FakeManager) ListDriversCalls(stub func(context.Context, lager.Logger) (volman.ListDriversResponse, error)) {
 	fake.listDriversMutex.Lock()
 	defer fake.listDriversMutex.Unlock()
 	fake.ListDriversStub = stub
 }
 
-func (fake *FakeManager) ListDriversArgsForCall(i int) lager.Logger {
+func (fake *FakeManager) ListDriversArgsForCall(i int) (context.Context, lager.Logger) {
 	fake.listDriversMutex.RLock()
 	defer fake.listDriversMutex.RUnlock()
 	argsForCall := fake.listDriversArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeManager) ListDriversReturns(result1 volman.ListDriversResponse, result2 error) {
@@ -120,20 +241,153 @@ func (fake *FakeManager) ListDriversReturnsOnCall(i int, result1 volman.ListDriv
 	}{result1, result2}
 }
 
-func (fake *FakeManager) Mount(arg1 lager.Logger, arg2 string, arg3 string, arg4 string, arg5 map[string]interface{}) (volman.MountResponse, error) {
+func (fake *FakeManager) ListDriversMatching(arg1 context.Context, arg2 lager.Logger, arg3 volman.DriverFilter) (volman.ListDriversResponse, error) {
+	fake.listDriversMatchingMutex.Lock()
+	ret, specificReturn := fake.listDriversMatchingReturnsOnCall[len(fake.listDriversMatchingArgsForCall)]
+	fake.listDriversMatchingArgsForCall = append(fake.listDriversMatchingArgsForCall, struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 volman.DriverFilter
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("ListDriversMatching", []interface{}{arg1, arg2, arg3})
+	fake.listDriversMatchingMutex.Unlock()
+	if fake.ListDriversMatchingStub != nil {
+		return fake.ListDriversMatchingStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listDriversMatchingReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) ListDriversMatchingCallCount() int {
+	fake.listDriversMatchingMutex.RLock()
+	defer fake.listDriversMatchingMutex.RUnlock()
+	return len(fake.listDriversMatchingArgsForCall)
+}
+
+func (fake *FakeManager) ListDriversMatchingCalls(stub func(context.Context, lager.Logger, volman.DriverFilter) (volman.ListDriversResponse, error)) {
+	fake.listDriversMatchingMutex.Lock()
+	defer fake.listDriversMatchingMutex.Unlock()
+	fake.ListDriversMatchingStub = stub
+}
+
+func (fake *FakeManager) ListDriversMatchingArgsForCall(i int) (context.Context, lager.Logger, volman.DriverFilter) {
+	fake.listDriversMatchingMutex.RLock()
+	defer fake.listDriversMatchingMutex.RUnlock()
+	argsForCall := fake.listDriversMatchingArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeManager) ListDriversMatchingReturns(result1 volman.ListDriversResponse, result2 error) {
+	fake.listDriversMatchingMutex.Lock()
+	defer fake.listDriversMatchingMutex.Unlock()
+	fake.ListDriversMatchingStub = nil
+	fake.listDriversMatchingReturns = struct {
+		result1 volman.ListDriversResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) ListDriversMatchingReturnsOnCall(i int, result1 volman.ListDriversResponse, result2 error) {
+	fake.listDriversMatchingMutex.Lock()
+	defer fake.listDriversMatchingMutex.Unlock()
+	fake.ListDriversMatchingStub = nil
+	if fake.listDriversMatchingReturnsOnCall == nil {
+		fake.listDriversMatchingReturnsOnCall = make(map[int]struct {
+			result1 volman.ListDriversResponse
+			result2 error
+		})
+	}
+	fake.listDriversMatchingReturnsOnCall[i] = struct {
+		result1 volman.ListDriversResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) ListMounts(arg1 context.Context, arg2 lager.Logger, arg3 string, arg4 string, arg5 string) (volman.ListMountsResponse, error) {
+	fake.listMountsMutex.Lock()
+	ret, specificReturn := fake.listMountsReturnsOnCall[len(fake.listMountsArgsForCall)]
+	fake.listMountsArgsForCall = append(fake.listMountsArgsForCall, struct {
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+		arg4 string
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("ListMounts", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.listMountsMutex.Unlock()
+	if fake.ListMountsStub != nil {
+		return fake.ListMountsStub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listMountsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) ListMountsCallCount() int {
+	fake.listMountsMutex.RLock()
+	defer fake.listMountsMutex.RUnlock()
+	return len(fake.listMountsArgsForCall)
+}
+
+func (fake *FakeManager) ListMountsCalls(stub func(context.Context, lager.Logger, string, string, string) (volman.ListMountsResponse, error)) {
+	fake.listMountsMutex.Lock()
+	defer fake.listMountsMutex.Unlock()
+	fake.ListMountsStub = stub
+}
+
+func (fake *FakeManager) ListMountsArgsForCall(i int) (context.Context, lager.Logger, string, string, string) {
+	fake.listMountsMutex.RLock()
+	defer fake.listMountsMutex.RUnlock()
+	argsForCall := fake.listMountsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeManager) ListMountsReturns(result1 volman.ListMountsResponse, result2 error) {
+	fake.listMountsMutex.Lock()
+	defer fake.listMountsMutex.Unlock()
+	fake.ListMountsStub = nil
+	fake.listMountsReturns = struct {
+		result1 volman.ListMountsResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) ListMountsReturnsOnCall(i int, result1 volman.ListMountsResponse, result2 error) {
+	fake.listMountsMutex.Lock()
+	defer fake.listMountsMutex.Unlock()
+	fake.ListMountsStub = nil
+	if fake.listMountsReturnsOnCall == nil {
+		fake.listMountsReturnsOnCall = make(map[int]struct {
+			result1 volman.ListMountsResponse
+			result2 error
+		})
+	}
+	fake.listMountsReturnsOnCall[i] = struct {
+		result1 volman.ListMountsResponse
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) Mount(arg1 context.Context, arg2 lager.Logger, arg3 string, arg4 string, arg5 string, arg6 map[string]interface{}) (volman.MountResponse, error) {
 	fake.mountMutex.Lock()
 	ret, specificReturn := fake.mountReturnsOnCall[len(fake.mountArgsForCall)]
 	fake.mountArgsForCall = append(fake.mountArgsForCall, struct {
-		arg1 lager.Logger
-		arg2 string
+		arg1 context.Context
+		arg2 lager.Logger
 		arg3 string
 		arg4 string
-		arg5 map[string]interface{}
-	}{arg1, arg2, arg3, arg4, arg5})
-	fake.recordInvocation("Mount", []interface{}{arg1, arg2, arg3, arg4, arg5})
+		arg5 string
+		arg6 map[string]interface{}
+	}{arg1, arg2, arg3, arg4, arg5, arg6})
+	fake.recordInvocation("Mount", []interface{}{arg1, arg2, arg3, arg4, arg5, arg6})
 	fake.mountMutex.Unlock()
 	if fake.MountStub != nil {
-		return fake.MountStub(arg1, arg2, arg3, arg4, arg5)
+		return fake.MountStub(arg1, arg2, arg3, arg4, arg5, arg6)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -148,17 +402,17 @@ func (fake *FakeManager) MountCallCount() int {
 	return len(fake.mountArgsForCall)
 }
 
-func (fake *FakeManager) MountCalls(stub func(lager.Logger, string, string, string, map[string]interface{}) (volman.MountResponse, error)) {
+func (fake *FakeManager) MountCalls(stub func(context.Context, lager.Logger, string, string, string, map[string]interface{}) (volman.MountResponse, error)) {
 	fake.mountMutex.Lock()
 	defer fake.mountMutex.Unlock()
 	fake.MountStub = stub
 }
 
-func (fake *FakeManager) MountArgsForCall(i int) (lager.Logger, string, string, string, map[string]interface{}) {
+func (fake *FakeManager) MountArgsForCall(i int) (context.Context, lager.Logger, string, string, string, map[string]interface{}) {
 	fake.mountMutex.RLock()
 	defer fake.mountMutex.RUnlock()
 	argsForCall := fake.mountArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5, argsForCall.arg6
 }
 
 func (fake *FakeManager) MountReturns(result1 volman.MountResponse, result2 error) {
@@ -187,19 +441,20 @@ func (fake *FakeManager) MountReturnsOnCall(i int, result1 volman.MountResponse,
 	}{result1, result2}
 }
 
-func (fake *FakeManager) Unmount(arg1 lager.Logger, arg2 string, arg3 string, arg4 string) error {
+func (fake *FakeManager) Unmount(arg1 context.Context, arg2 lager.Logger, arg3 string, arg4 string, arg5 string) error {
 	fake.unmountMutex.Lock()
 	ret, specificReturn := fake.unmountReturnsOnCall[len(fake.unmountArgsForCall)]
 	fake.unmountArgsForCall = append(fake.unmountArgsForCall, struct {
-		arg1 lager.Logger
-		arg2 string
+		arg1 context.Context
+		arg2 lager.Logger
 		arg3 string
 		arg4 string
-	}{arg1, arg2, arg3, arg4})
-	fake.recordInvocation("Unmount", []interface{}{arg1, arg2, arg3, arg4})
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	fake.recordInvocation("Unmount", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.unmountMutex.Unlock()
 	if fake.UnmountStub != nil {
-		return fake.UnmountStub(arg1, arg2, arg3, arg4)
+		return fake.UnmountStub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -214,17 +469,17 @@ func (fake *FakeManager) UnmountCallCount() int {
 	return len(fake.unmountArgsForCall)
 }
 
-func (fake *FakeManager) UnmountCalls(stub func(lager.Logger, string, string, string) error) {
+func (fake *FakeManager) UnmountCalls(stub func(context.Context, lager.Logger, string, string, string) error) {
 	fake.unmountMutex.Lock()
 	defer fake.unmountMutex.Unlock()
 	fake.UnmountStub = stub
 }
 
-func (fake *FakeManager) UnmountArgsForCall(i int) (lager.Logger, string, string, string) {
+func (fake *FakeManager) UnmountArgsForCall(i int) (context.Context, lager.Logger, string, string, string) {
 	fake.unmountMutex.RLock()
 	defer fake.unmountMutex.RUnlock()
 	argsForCall := fake.unmountArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeManager) UnmountReturns(result1 error) {
@@ -253,8 +508,14 @@ func (fake *FakeManager) UnmountReturnsOnCall(i int, result1 error) {
 func (fake *FakeManager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.getMountMutex.RLock()
+	defer fake.getMountMutex.RUnlock()
 	fake.listDriversMutex.RLock()
 	defer fake.listDriversMutex.RUnlock()
+	fake.listDriversMatchingMutex.RLock()
+	defer fake.listDriversMatchingMutex.RUnlock()
+	fake.listMountsMutex.RLock()
+	defer fake.listMountsMutex.RUnlock()
 	fake.mountMutex.RLock()
 	defer fake.mountMutex.RUnlock()
 	fake.unmountMutex.RLock()
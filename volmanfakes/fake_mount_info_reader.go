@@ -0,0 +1,116 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package volmanfakes
+
+import (
+	sync "sync"
+
+	lager "code.cloudfoundry.org/lager/v3"
+	vollocal "code.cloudfoundry.org/volman/vollocal"
+)
+
+type FakeMountInfoReader struct {
+	MountInfoStub        func(lager.Logger) ([]byte, error)
+	mountInfoMutex       sync.RWMutex
+	mountInfoArgsForCall []struct {
+		arg1 lager.Logger
+	}
+	mountInfoReturns struct {
+		result1 []byte
+		result2 error
+	}
+	mountInfoReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMountInfoReader) MountInfo(arg1 lager.Logger) ([]byte, error) {
+	fake.mountInfoMutex.Lock()
+	ret, specificReturn := fake.mountInfoReturnsOnCall[len(fake.mountInfoArgsForCall)]
+	fake.mountInfoArgsForCall = append(fake.mountInfoArgsForCall, struct {
+		arg1 lager.Logger
+	}{arg1})
+	fake.recordInvocation("MountInfo", []interface{}{arg1})
+	fake.mountInfoMutex.Unlock()
+	if fake.MountInfoStub != nil {
+		return fake.MountInfoStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.mountInfoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMountInfoReader) MountInfoCallCount() int {
+	fake.mountInfoMutex.RLock()
+	defer fake.mountInfoMutex.RUnlock()
+	return len(fake.mountInfoArgsForCall)
+}
+
+func (fake *FakeMountInfoReader) MountInfoCalls(stub func(lager.Logger) ([]byte, error)) {
+	fake.mountInfoMutex.Lock()
+	defer fake.mountInfoMutex.Unlock()
+	fake.MountInfoStub = stub
+}
+
+func (fake *FakeMountInfoReader) MountInfoArgsForCall(i int) lager.Logger {
+	fake.mountInfoMutex.RLock()
+	defer fake.mountInfoMutex.RUnlock()
+	argsForCall := fake.mountInfoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMountInfoReader) MountInfoReturns(result1 []byte, result2 error) {
+	fake.mountInfoMutex.Lock()
+	defer fake.mountInfoMutex.Unlock()
+	fake.MountInfoStub = nil
+	fake.mountInfoReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMountInfoReader) MountInfoReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.mountInfoMutex.Lock()
+	defer fake.mountInfoMutex.Unlock()
+	fake.MountInfoStub = nil
+	if fake.mountInfoReturnsOnCall == nil {
+		fake.mountInfoReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.mountInfoReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeMountInfoReader) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.mountInfoMutex.RLock()
+	defer fake.mountInfoMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMountInfoReader) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ vollocal.MountInfoReader = new(FakeMountInfoReader)
@@ -0,0 +1,338 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package volmanfakes
+
+import (
+	sync "sync"
+
+	lager "code.cloudfoundry.org/lager/v3"
+	vollocal "code.cloudfoundry.org/volman/vollocal"
+)
+
+type FakeMountLedger struct {
+	AddStub        func(lager.Logger, vollocal.MountRecord) error
+	addMutex       sync.RWMutex
+	addArgsForCall []struct {
+		arg1 lager.Logger
+		arg2 vollocal.MountRecord
+	}
+	addReturns struct {
+		result1 error
+	}
+	addReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetStub        func(string, string, string) (vollocal.MountRecord, bool)
+	getMutex       sync.RWMutex
+	getArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	getReturns struct {
+		result1 vollocal.MountRecord
+		result2 bool
+	}
+	getReturnsOnCall map[int]struct {
+		result1 vollocal.MountRecord
+		result2 bool
+	}
+	RecordsStub        func() []vollocal.MountRecord
+	recordsMutex       sync.RWMutex
+	recordsArgsForCall []struct {
+	}
+	recordsReturns struct {
+		result1 []vollocal.MountRecord
+	}
+	recordsReturnsOnCall map[int]struct {
+		result1 []vollocal.MountRecord
+	}
+	RemoveStub        func(lager.Logger, string, string, string) error
+	removeMutex       sync.RWMutex
+	removeArgsForCall []struct {
+		arg1 lager.Logger
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	removeReturns struct {
+		result1 error
+	}
+	removeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMountLedger) Add(arg1 lager.Logger, arg2 vollocal.MountRecord) error {
+	fake.addMutex.Lock()
+	ret, specificReturn := fake.addReturnsOnCall[len(fake.addArgsForCall)]
+	fake.addArgsForCall = append(fake.addArgsForCall, struct {
+		arg1 lager.Logger
+		arg2 vollocal.MountRecord
+	}{arg1, arg2})
+	fake.recordInvocation("Add", []interface{}{arg1, arg2})
+	fake.addMutex.Unlock()
+	if fake.AddStub != nil {
+		return fake.AddStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountLedger) AddCallCount() int {
+	fake.addMutex.RLock()
+	defer fake.addMutex.RUnlock()
+	return len(fake.addArgsForCall)
+}
+
+func (fake *FakeMountLedger) AddCalls(stub func(lager.Logger, vollocal.MountRecord) error) {
+	fake.addMutex.Lock()
+	defer fake.addMutex.Unlock()
+	fake.AddStub = stub
+}
+
+func (fake *FakeMountLedger) AddArgsForCall(i int) (lager.Logger, vollocal.MountRecord) {
+	fake.addMutex.RLock()
+	defer fake.addMutex.RUnlock()
+	argsForCall := fake.addArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMountLedger) AddReturns(result1 error) {
+	fake.addMutex.Lock()
+	defer fake.addMutex.Unlock()
+	fake.AddStub = nil
+	fake.addReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountLedger) AddReturnsOnCall(i int, result1 error) {
+	fake.addMutex.Lock()
+	defer fake.addMutex.Unlock()
+	fake.AddStub = nil
+	if fake.addReturnsOnCall == nil {
+		fake.addReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountLedger) Get(arg1 string, arg2 string, arg3 string) (vollocal.MountRecord, bool) {
+	fake.getMutex.Lock()
+	ret, specificReturn := fake.getReturnsOnCall[len(fake.getArgsForCall)]
+	fake.getArgsForCall = append(fake.getArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("Get", []interface{}{arg1, arg2, arg3})
+	fake.getMutex.Unlock()
+	if fake.GetStub != nil {
+		return fake.GetStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeMountLedger) GetCallCount() int {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return len(fake.getArgsForCall)
+}
+
+func (fake *FakeMountLedger) GetCalls(stub func(string, string, string) (vollocal.MountRecord, bool)) {
+	fake.getMutex.Lock()
+	defer fake.getMutex.Unlock()
+	fake.GetStub = stub
+}
+
+func (fake *FakeMountLedger) GetArgsForCall(i int) (string, string, string) {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	argsForCall := fake.getArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeMountLedger) GetReturns(result1 vollocal.MountRecord, result2 bool) {
+	fake.getMutex.Lock()
+	defer fake.getMutex.Unlock()
+	fake.GetStub = nil
+	fake.getReturns = struct {
+		result1 vollocal.MountRecord
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeMountLedger) GetReturnsOnCall(i int, result1 vollocal.MountRecord, result2 bool) {
+	fake.getMutex.Lock()
+	defer fake.getMutex.Unlock()
+	fake.GetStub = nil
+	if fake.getReturnsOnCall == nil {
+		fake.getReturnsOnCall = make(map[int]struct {
+			result1 vollocal.MountRecord
+			result2 bool
+		})
+	}
+	fake.getReturnsOnCall[i] = struct {
+		result1 vollocal.MountRecord
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeMountLedger) Records() []vollocal.MountRecord {
+	fake.recordsMutex.Lock()
+	ret, specificReturn := fake.recordsReturnsOnCall[len(fake.recordsArgsForCall)]
+	fake.recordsArgsForCall = append(fake.recordsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Records", []interface{}{})
+	fake.recordsMutex.Unlock()
+	if fake.RecordsStub != nil {
+		return fake.RecordsStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.recordsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountLedger) RecordsCallCount() int {
+	fake.recordsMutex.RLock()
+	defer fake.recordsMutex.RUnlock()
+	return len(fake.recordsArgsForCall)
+}
+
+func (fake *FakeMountLedger) RecordsCalls(stub func() []vollocal.MountRecord) {
+	fake.recordsMutex.Lock()
+	defer fake.recordsMutex.Unlock()
+	fake.RecordsStub = stub
+}
+
+func (fake *FakeMountLedger) RecordsReturns(result1 []vollocal.MountRecord) {
+	fake.recordsMutex.Lock()
+	defer fake.recordsMutex.Unlock()
+	fake.RecordsStub = nil
+	fake.recordsReturns = struct {
+		result1 []vollocal.MountRecord
+	}{result1}
+}
+
+func (fake *FakeMountLedger) RecordsReturnsOnCall(i int, result1 []vollocal.MountRecord) {
+	fake.recordsMutex.Lock()
+	defer fake.recordsMutex.Unlock()
+	fake.RecordsStub = nil
+	if fake.recordsReturnsOnCall == nil {
+		fake.recordsReturnsOnCall = make(map[int]struct {
+			result1 []vollocal.MountRecord
+		})
+	}
+	fake.recordsReturnsOnCall[i] = struct {
+		result1 []vollocal.MountRecord
+	}{result1}
+}
+
+func (fake *FakeMountLedger) Remove(arg1 lager.Logger, arg2 string, arg3 string, arg4 string) error {
+	fake.removeMutex.Lock()
+	ret, specificReturn := fake.removeReturnsOnCall[len(fake.removeArgsForCall)]
+	fake.removeArgsForCall = append(fake.removeArgsForCall, struct {
+		arg1 lager.Logger
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("Remove", []interface{}{arg1, arg2, arg3, arg4})
+	fake.removeMutex.Unlock()
+	if fake.RemoveStub != nil {
+		return fake.RemoveStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountLedger) RemoveCallCount() int {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return len(fake.removeArgsForCall)
+}
+
+func (fake *FakeMountLedger) RemoveCalls(stub func(lager.Logger, string, string, string) error) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = stub
+}
+
+func (fake *FakeMountLedger) RemoveArgsForCall(i int) (lager.Logger, string, string, string) {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	argsForCall := fake.removeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeMountLedger) RemoveReturns(result1 error) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = nil
+	fake.removeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountLedger) RemoveReturnsOnCall(i int, result1 error) {
+	fake.removeMutex.Lock()
+	defer fake.removeMutex.Unlock()
+	fake.RemoveStub = nil
+	if fake.removeReturnsOnCall == nil {
+		fake.removeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountLedger) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.addMutex.RLock()
+	defer fake.addMutex.RUnlock()
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	fake.recordsMutex.RLock()
+	defer fake.recordsMutex.RUnlock()
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMountLedger) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ vollocal.MountLedger = new(FakeMountLedger)
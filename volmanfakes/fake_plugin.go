@@ -2,6 +2,7 @@
 package volmanfakes
 
 import (
+	context "context"
 	sync "sync"
 
 	lager "code.cloudfoundry.org/lager/v3"
@@ -19,10 +20,11 @@ type FakePlugin struct {
 	getPluginSpecReturnsOnCall map[int]struct {
 		result1 volman.PluginSpec
 	}
-	ListVolumesStub        func(lager.Logger) ([]string, error)
+	ListVolumesStub        func(context.Context, lager.Logger) ([]string, error)
 	listVolumesMutex       sync.RWMutex
 	listVolumesArgsForCall []struct {
-		arg1 lager.Logger
+		arg1 context.Context
+		arg2 lager.Logger
 	}
 	listVolumesReturns struct {
 		result1 []string
@@ -44,12 +46,13 @@ type FakePlugin struct {
 	matchesReturnsOnCall map[int]struct {
 		result1 bool
 	}
-	MountStub        func(lager.Logger, string, map[string]interface{}) (volman.MountResponse, error)
+	MountStub        func(context.Context, lager.Logger, string, map[string]interface{}) (volman.MountResponse, error)
 	mountMutex       sync.RWMutex
 	mountArgsForCall []struct {
-		arg1 lager.Logger
-		arg2 string
-		arg3 map[string]interface{}
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+		arg4 map[string]interface{}
 	}
 	mountReturns struct {
 		result1 volman.MountResponse
@@ -59,11 +62,12 @@ type FakePlugin struct {
 		result1 volman.MountResponse
 		result2 error
 	}
-	UnmountStub        func(lager.Logger, string) error
+	UnmountStub        func(context.Context, lager.Logger, string) error
 	unmountMutex       sync.RWMutex
 	unmountArgsForCall []struct {
-		arg1 lager.Logger
-		arg2 string
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
 	}
 	unmountReturns struct {
 		result1 error
@@ -127,16 +131,17 @@ func (fake *FakePlugin) GetPluginSpecReturnsOnCall(i int, result1 volman.PluginS
 	}{result1}
 }
 
-func (fake *FakePlugin) ListVolumes(arg1 lager.Logger) ([]string, error) {
+func (fake *FakePlugin) ListVolumes(arg1 context.Context, arg2 lager.Logger) ([]string, error) {
 	fake.listVolumesMutex.Lock()
 	ret, specificReturn := fake.listVolumesReturnsOnCall[len(fake.listVolumesArgsForCall)]
 	fake.listVolumesArgsForCall = append(fake.listVolumesArgsForCall, struct {
-		arg1 lager.Logger
-	}{arg1})
-	fake.recordInvocation("ListVolumes", []interface{}{arg1})
+		arg1 context.Context
+		arg2 lager.Logger
+	}{arg1, arg2})
+	fake.recordInvocation("ListVolumes", []interface{}{arg1, arg2})
 	fake.listVolumesMutex.Unlock()
 	if fake.ListVolumesStub != nil {
-		return fake.ListVolumesStub(arg1)
+		return fake.ListVolumesStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -151,17 +156,17 @@ func (fake *FakePlugin) ListVolumesCallCount() int {
 	return len(fake.listVolumesArgsForCall)
 }
 
-func (fake *FakePlugin) ListVolumesCalls(stub func(lager.Logger) ([]string, error)) {
+func (fake *FakePlugin) ListVolumesCalls(stub func(context.Context, lager.Logger) ([]string, error)) {
 	fake.listVolumesMutex.Lock()
 	defer fake.listVolumesMutex.Unlock()
 	fake.ListVolumesStub = stub
 }
 
-func (fake *FakePlugin) ListVolumesArgsForCall(i int) lager.Logger {
+func (fake *FakePlugin) ListVolumesArgsForCall(i int) (context.Context, lager.Logger) {
 	fake.listVolumesMutex.RLock()
 	defer fake.listVolumesMutex.RUnlock()
 	argsForCall := fake.listVolumesArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakePlugin) ListVolumesReturns(result1 []string, result2 error) {
@@ -251,18 +256,19 @@ func (fake *FakePlugin) MatchesReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
-func (fake *FakePlugin) Mount(arg1 lager.Logger, arg2 string, arg3 map[string]interface{}) (volman.MountResponse, error) {
+func (fake *FakePlugin) Mount(arg1 context.Context, arg2 lager.Logger, arg3 string, arg4 map[string]interface{}) (volman.MountResponse, error) {
 	fake.mountMutex.Lock()
 	ret, specificReturn := fake.mountReturnsOnCall[len(fake.mountArgsForCall)]
 	fake.mountArgsForCall = append(fake.mountArgsForCall, struct {
-		arg1 lager.Logger
-		arg2 string
-		arg3 map[string]interface{}
-	}{arg1, arg2, arg3})
-	fake.recordInvocation("Mount", []interface{}{arg1, arg2, arg3})
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+		arg4 map[string]interface{}
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("Mount", []interface{}{arg1, arg2, arg3, arg4})
 	fake.mountMutex.Unlock()
 	if fake.MountStub != nil {
-		return fake.MountStub(arg1, arg2, arg3)
+		return fake.MountStub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -277,17 +283,17 @@ func (fake *FakePlugin) MountCallCount() int {
 	return len(fake.mountArgsForCall)
 }
 
-func (fake *FakePlugin) MountCalls(stub func(lager.Logger, string, map[string]interface{}) (volman.MountResponse, error)) {
+func (fake *FakePlugin) MountCalls(stub func(context.Context, lager.Logger, string, map[string]interface{}) (volman.MountResponse, error)) {
 	fake.mountMutex.Lock()
 	defer fake.mountMutex.Unlock()
 	fake.MountStub = stub
 }
 
-func (fake *FakePlugin) MountArgsForCall(i int) (lager.Logger, string, map[string]interface{}) {
+func (fake *FakePlugin) MountArgsForCall(i int) (context.Context, lager.Logger, string, map[string]interface{}) {
 	fake.mountMutex.RLock()
 	defer fake.mountMutex.RUnlock()
 	argsForCall := fake.mountArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakePlugin) MountReturns(result1 volman.MountResponse, result2 error) {
@@ -316,17 +322,18 @@ func (fake *FakePlugin) MountReturnsOnCall(i int, result1 volman.MountResponse,
 	}{result1, result2}
 }
 
-func (fake *FakePlugin) Unmount(arg1 lager.Logger, arg2 string) error {
+func (fake *FakePlugin) Unmount(arg1 context.Context, arg2 lager.Logger, arg3 string) error {
 	fake.unmountMutex.Lock()
 	ret, specificReturn := fake.unmountReturnsOnCall[len(fake.unmountArgsForCall)]
 	fake.unmountArgsForCall = append(fake.unmountArgsForCall, struct {
-		arg1 lager.Logger
-		arg2 string
-	}{arg1, arg2})
-	fake.recordInvocation("Unmount", []interface{}{arg1, arg2})
+		arg1 context.Context
+		arg2 lager.Logger
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("Unmount", []interface{}{arg1, arg2, arg3})
 	fake.unmountMutex.Unlock()
 	if fake.UnmountStub != nil {
-		return fake.UnmountStub(arg1, arg2)
+		return fake.UnmountStub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -341,17 +348,17 @@ func (fake *FakePlugin) UnmountCallCount() int {
 	return len(fake.unmountArgsForCall)
 }
 
-func (fake *FakePlugin) UnmountCalls(stub func(lager.Logger, string) error) {
+func (fake *FakePlugin) UnmountCalls(stub func(context.Context, lager.Logger, string) error) {
 	fake.unmountMutex.Lock()
 	defer fake.unmountMutex.Unlock()
 	fake.UnmountStub = stub
 }
 
-func (fake *FakePlugin) UnmountArgsForCall(i int) (lager.Logger, string) {
+func (fake *FakePlugin) UnmountArgsForCall(i int) (context.Context, lager.Logger, string) {
 	fake.unmountMutex.RLock()
 	defer fake.unmountMutex.RUnlock()
 	argsForCall := fake.unmountArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakePlugin) UnmountReturns(result1 error) {
@@ -0,0 +1,197 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package volmanfakes
+
+import (
+	context "context"
+	sync "sync"
+
+	lager "code.cloudfoundry.org/lager/v3"
+	volman "code.cloudfoundry.org/volman"
+)
+
+type FakeSourcedDiscoverer struct {
+	DiscoverStub        func(context.Context, lager.Logger) (map[string]volman.Plugin, error)
+	discoverMutex       sync.RWMutex
+	discoverArgsForCall []struct {
+		arg1 context.Context
+		arg2 lager.Logger
+	}
+	discoverReturns struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}
+	discoverReturnsOnCall map[int]struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}
+	DriverSourceStub        func(string) (string, bool)
+	driverSourceMutex       sync.RWMutex
+	driverSourceArgsForCall []struct {
+		arg1 string
+	}
+	driverSourceReturns struct {
+		result1 string
+		result2 bool
+	}
+	driverSourceReturnsOnCall map[int]struct {
+		result1 string
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSourcedDiscoverer) Discover(arg1 context.Context, arg2 lager.Logger) (map[string]volman.Plugin, error) {
+	fake.discoverMutex.Lock()
+	ret, specificReturn := fake.discoverReturnsOnCall[len(fake.discoverArgsForCall)]
+	fake.discoverArgsForCall = append(fake.discoverArgsForCall, struct {
+		arg1 context.Context
+		arg2 lager.Logger
+	}{arg1, arg2})
+	fake.recordInvocation("Discover", []interface{}{arg1, arg2})
+	fake.discoverMutex.Unlock()
+	if fake.DiscoverStub != nil {
+		return fake.DiscoverStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.discoverReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSourcedDiscoverer) DiscoverCallCount() int {
+	fake.discoverMutex.RLock()
+	defer fake.discoverMutex.RUnlock()
+	return len(fake.discoverArgsForCall)
+}
+
+func (fake *FakeSourcedDiscoverer) DiscoverCalls(stub func(context.Context, lager.Logger) (map[string]volman.Plugin, error)) {
+	fake.discoverMutex.Lock()
+	defer fake.discoverMutex.Unlock()
+	fake.DiscoverStub = stub
+}
+
+func (fake *FakeSourcedDiscoverer) DiscoverArgsForCall(i int) (context.Context, lager.Logger) {
+	fake.discoverMutex.RLock()
+	defer fake.discoverMutex.RUnlock()
+	argsForCall := fake.discoverArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeSourcedDiscoverer) DiscoverReturns(result1 map[string]volman.Plugin, result2 error) {
+	fake.discoverMutex.Lock()
+	defer fake.discoverMutex.Unlock()
+	fake.DiscoverStub = nil
+	fake.discoverReturns = struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSourcedDiscoverer) DiscoverReturnsOnCall(i int, result1 map[string]volman.Plugin, result2 error) {
+	fake.discoverMutex.Lock()
+	defer fake.discoverMutex.Unlock()
+	fake.DiscoverStub = nil
+	if fake.discoverReturnsOnCall == nil {
+		fake.discoverReturnsOnCall = make(map[int]struct {
+			result1 map[string]volman.Plugin
+			result2 error
+		})
+	}
+	fake.discoverReturnsOnCall[i] = struct {
+		result1 map[string]volman.Plugin
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSourcedDiscoverer) DriverSource(arg1 string) (string, bool) {
+	fake.driverSourceMutex.Lock()
+	ret, specificReturn := fake.driverSourceReturnsOnCall[len(fake.driverSourceArgsForCall)]
+	fake.driverSourceArgsForCall = append(fake.driverSourceArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("DriverSource", []interface{}{arg1})
+	fake.driverSourceMutex.Unlock()
+	if fake.DriverSourceStub != nil {
+		return fake.DriverSourceStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.driverSourceReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSourcedDiscoverer) DriverSourceCallCount() int {
+	fake.driverSourceMutex.RLock()
+	defer fake.driverSourceMutex.RUnlock()
+	return len(fake.driverSourceArgsForCall)
+}
+
+func (fake *FakeSourcedDiscoverer) DriverSourceCalls(stub func(string) (string, bool)) {
+	fake.driverSourceMutex.Lock()
+	defer fake.driverSourceMutex.Unlock()
+	fake.DriverSourceStub = stub
+}
+
+func (fake *FakeSourcedDiscoverer) DriverSourceArgsForCall(i int) string {
+	fake.driverSourceMutex.RLock()
+	defer fake.driverSourceMutex.RUnlock()
+	argsForCall := fake.driverSourceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSourcedDiscoverer) DriverSourceReturns(result1 string, result2 bool) {
+	fake.driverSourceMutex.Lock()
+	defer fake.driverSourceMutex.Unlock()
+	fake.DriverSourceStub = nil
+	fake.driverSourceReturns = struct {
+		result1 string
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeSourcedDiscoverer) DriverSourceReturnsOnCall(i int, result1 string, result2 bool) {
+	fake.driverSourceMutex.Lock()
+	defer fake.driverSourceMutex.Unlock()
+	fake.DriverSourceStub = nil
+	if fake.driverSourceReturnsOnCall == nil {
+		fake.driverSourceReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 bool
+		})
+	}
+	fake.driverSourceReturnsOnCall[i] = struct {
+		result1 string
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeSourcedDiscoverer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.discoverMutex.RLock()
+	defer fake.discoverMutex.RUnlock()
+	fake.driverSourceMutex.RLock()
+	defer fake.driverSourceMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSourcedDiscoverer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ volman.SourcedDiscoverer = new(FakeSourcedDiscoverer)
@@ -0,0 +1,120 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package volmanfakes
+
+import (
+	sync "sync"
+
+	dockerdriver "code.cloudfoundry.org/dockerdriver"
+	lager "code.cloudfoundry.org/lager/v3"
+	volman "code.cloudfoundry.org/volman"
+	voldiscoverers "code.cloudfoundry.org/volman/voldiscoverers"
+)
+
+type FakeSpecDriverFactory struct {
+	DockerDriverForSpecStub        func(lager.Logger, volman.PluginSpec) (dockerdriver.Driver, error)
+	dockerDriverForSpecMutex       sync.RWMutex
+	dockerDriverForSpecArgsForCall []struct {
+		arg1 lager.Logger
+		arg2 volman.PluginSpec
+	}
+	dockerDriverForSpecReturns struct {
+		result1 dockerdriver.Driver
+		result2 error
+	}
+	dockerDriverForSpecReturnsOnCall map[int]struct {
+		result1 dockerdriver.Driver
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSpecDriverFactory) DockerDriverForSpec(arg1 lager.Logger, arg2 volman.PluginSpec) (dockerdriver.Driver, error) {
+	fake.dockerDriverForSpecMutex.Lock()
+	ret, specificReturn := fake.dockerDriverForSpecReturnsOnCall[len(fake.dockerDriverForSpecArgsForCall)]
+	fake.dockerDriverForSpecArgsForCall = append(fake.dockerDriverForSpecArgsForCall, struct {
+		arg1 lager.Logger
+		arg2 volman.PluginSpec
+	}{arg1, arg2})
+	fake.recordInvocation("DockerDriverForSpec", []interface{}{arg1, arg2})
+	fake.dockerDriverForSpecMutex.Unlock()
+	if fake.DockerDriverForSpecStub != nil {
+		return fake.DockerDriverForSpecStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.dockerDriverForSpecReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSpecDriverFactory) DockerDriverForSpecCallCount() int {
+	fake.dockerDriverForSpecMutex.RLock()
+	defer fake.dockerDriverForSpecMutex.RUnlock()
+	return len(fake.dockerDriverForSpecArgsForCall)
+}
+
+func (fake *FakeSpecDriverFactory) DockerDriverForSpecCalls(stub func(lager.Logger, volman.PluginSpec) (dockerdriver.Driver, error)) {
+	fake.dockerDriverForSpecMutex.Lock()
+	defer fake.dockerDriverForSpecMutex.Unlock()
+	fake.DockerDriverForSpecStub = stub
+}
+
+func (fake *FakeSpecDriverFactory) DockerDriverForSpecArgsForCall(i int) (lager.Logger, volman.PluginSpec) {
+	fake.dockerDriverForSpecMutex.RLock()
+	defer fake.dockerDriverForSpecMutex.RUnlock()
+	argsForCall := fake.dockerDriverForSpecArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeSpecDriverFactory) DockerDriverForSpecReturns(result1 dockerdriver.Driver, result2 error) {
+	fake.dockerDriverForSpecMutex.Lock()
+	defer fake.dockerDriverForSpecMutex.Unlock()
+	fake.DockerDriverForSpecStub = nil
+	fake.dockerDriverForSpecReturns = struct {
+		result1 dockerdriver.Driver
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpecDriverFactory) DockerDriverForSpecReturnsOnCall(i int, result1 dockerdriver.Driver, result2 error) {
+	fake.dockerDriverForSpecMutex.Lock()
+	defer fake.dockerDriverForSpecMutex.Unlock()
+	fake.DockerDriverForSpecStub = nil
+	if fake.dockerDriverForSpecReturnsOnCall == nil {
+		fake.dockerDriverForSpecReturnsOnCall = make(map[int]struct {
+			result1 dockerdriver.Driver
+			result2 error
+		})
+	}
+	fake.dockerDriverForSpecReturnsOnCall[i] = struct {
+		result1 dockerdriver.Driver
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSpecDriverFactory) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.dockerDriverForSpecMutex.RLock()
+	defer fake.dockerDriverForSpecMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSpecDriverFactory) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ voldiscoverers.SpecDriverFactory = new(FakeSpecDriverFactory)